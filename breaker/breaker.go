@@ -0,0 +1,261 @@
+// Package breaker 提供基于 rollingwindows.RollingWindow 的熔断器，
+// 支持 Google SRE 自适应限流和经典的关闭/打开/半开三态熔断，
+// 并提供 gin 中间件与 http.RoundTripper 包装。
+package breaker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/trancecho/ragnarok/rollingwindows"
+)
+
+// ErrOpen 熔断器拒绝请求时返回
+var ErrOpen = errors.New("breaker: circuit breaker is open")
+
+// State 熔断器状态
+type State int
+
+const (
+	StateClosed   State = iota // 正常放行
+	StateOpen                  // 拒绝请求；自适应模式下表示正在按概率丢弃
+	StateHalfOpen              // 放行少量探测请求，只用于经典模式
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// Classifier 判断调用结果是否计为失败
+type Classifier func(err error) bool
+
+// DefaultClassifier 除 nil 和调用方主动取消外的错误都计为失败
+func DefaultClassifier(err error) bool {
+	return err != nil && !errors.Is(err, context.Canceled)
+}
+
+// IgnoreErrors 返回在 DefaultClassifier 基础上忽略指定错误的分类器，
+// 适合把参数错误、记录不存在等业务错误排除在熔断统计之外
+func IgnoreErrors(errs ...error) Classifier {
+	return func(err error) bool {
+		for _, e := range errs {
+			if errors.Is(err, e) {
+				return false
+			}
+		}
+		return DefaultClassifier(err)
+	}
+}
+
+// Promise 手动模式下由 Allow 返回，调用结束后必须且只能调用一次 Accept 或 Reject
+type Promise interface {
+	Accept() // 调用成功
+	Reject() // 调用失败
+}
+
+// throttle 熔断策略
+type throttle interface {
+	allow() (Promise, error)
+	state() State
+}
+
+// ClassicConfig 经典三态熔断的参数
+type ClassicConfig struct {
+	FailureRatio float64       // 窗口内失败率达到该值时打开，默认 0.5
+	MinRequests  int64         // 窗口内请求数不足时不打开，默认 20
+	OpenTimeout  time.Duration // 打开后经过该时间进入半开，默认 5s
+	HalfOpenMax  int           // 半开状态允许的探测请求数，全部成功后关闭，默认 1
+}
+
+type options struct {
+	name       string
+	size       int
+	interval   time.Duration
+	classic    *ClassicConfig
+	k          float64
+	protection int64
+	classifier Classifier
+	onChange   []func(name string, from, to State)
+}
+
+// Option 熔断器配置项
+type Option func(*options)
+
+// WithName 设置名称，用于回调和 Group
+func WithName(name string) Option {
+	return func(o *options) {
+		o.name = name
+	}
+}
+
+// WithWindow 设置统计窗口：size 个桶，每个桶 interval，默认 40 × 250ms 即 10s
+func WithWindow(size int, interval time.Duration) Option {
+	return func(o *options) {
+		o.size = size
+		o.interval = interval
+	}
+}
+
+// WithSRE 使用 Google SRE 自适应限流（默认）。
+// 丢弃概率为 max(0, (requests - protection - k*accepts) / (requests + 1))，默认 k=1.5、protection=5
+func WithSRE(k float64, protection int64) Option {
+	return func(o *options) {
+		o.classic = nil
+		o.k = k
+		o.protection = protection
+	}
+}
+
+// WithClassic 使用经典的关闭/打开/半开三态熔断，零值字段使用默认值
+func WithClassic(cfg ClassicConfig) Option {
+	return func(o *options) {
+		o.classic = &cfg
+	}
+}
+
+// WithClassifier 设置失败判定，默认 DefaultClassifier
+func WithClassifier(c Classifier) Option {
+	return func(o *options) {
+		o.classifier = c
+	}
+}
+
+// OnStateChange 注册状态变化回调，回调在锁外同步执行
+func OnStateChange(f func(name string, from, to State)) Option {
+	return func(o *options) {
+		o.onChange = append(o.onChange, f)
+	}
+}
+
+// Breaker 熔断器，并发安全
+type Breaker struct {
+	name       string
+	t          throttle
+	classifier Classifier
+}
+
+// New 创建熔断器，默认使用 SRE 自适应限流
+func New(opts ...Option) *Breaker {
+	o := &options{
+		size:       40,
+		interval:   250 * time.Millisecond,
+		k:          1.5,
+		protection: 5,
+		classifier: DefaultClassifier,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	b := &Breaker{name: o.name, classifier: o.classifier}
+	notify := func(from, to State) {
+		for _, f := range o.onChange {
+			f(b.name, from, to)
+		}
+	}
+	newWindow := func() *rollingwindows.RollingWindow {
+		return rollingwindows.NewRollingWindow(o.size, o.interval)
+	}
+	if o.classic != nil {
+		b.t = newClassicThrottle(*o.classic, newWindow, notify)
+	} else {
+		b.t = newSREThrottle(o.k, o.protection, newWindow(), notify)
+	}
+	return b
+}
+
+// Name 返回名称
+func (b *Breaker) Name() string {
+	return b.name
+}
+
+// State 返回当前状态
+func (b *Breaker) State() State {
+	return b.t.state()
+}
+
+// Allow 手动模式：放行时返回 Promise，调用方根据结果调用 Accept 或 Reject；拒绝时返回 ErrOpen
+func (b *Breaker) Allow() (Promise, error) {
+	return b.t.allow()
+}
+
+// Do 在熔断器保护下执行 fn，被拒绝时返回 ErrOpen，否则返回 fn 的错误
+func (b *Breaker) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return b.DoWithFallback(ctx, fn, nil)
+}
+
+// DoWithFallback 与 Do 相同，但被熔断器拒绝时调用 fallback 并返回其结果。
+// fn 自身返回的错误不会触发 fallback
+func (b *Breaker) DoWithFallback(ctx context.Context, fn func(ctx context.Context) error,
+	fallback func(ctx context.Context, err error) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	p, err := b.Allow()
+	if err != nil {
+		if fallback != nil {
+			return fallback(ctx, err)
+		}
+		return err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			p.Reject()
+			panic(r)
+		}
+	}()
+	err = fn(ctx)
+	b.finish(p, err)
+	return err
+}
+
+// finish 按分类器的结果结束一次调用
+func (b *Breaker) finish(p Promise, err error) {
+	if b.classifier(err) {
+		p.Reject()
+	} else {
+		p.Accept()
+	}
+}
+
+// Group 按名称懒加载熔断器，同一组内的熔断器共享配置
+type Group struct {
+	opts     []Option
+	mu       sync.RWMutex
+	breakers map[string]*Breaker
+}
+
+// NewGroup 创建熔断器组，opts 应用于组内每个熔断器
+func NewGroup(opts ...Option) *Group {
+	return &Group{opts: opts, breakers: make(map[string]*Breaker)}
+}
+
+// Get 返回名称对应的熔断器，不存在时创建
+func (g *Group) Get(name string) *Breaker {
+	g.mu.RLock()
+	b, ok := g.breakers[name]
+	g.mu.RUnlock()
+	if ok {
+		return b
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if b, ok = g.breakers[name]; ok {
+		return b
+	}
+	b = New(append(append([]Option{}, g.opts...), WithName(name))...)
+	g.breakers[name] = b
+	return b
+}
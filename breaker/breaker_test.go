@@ -0,0 +1,263 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var errBackend = errors.New("backend down")
+
+func fail(context.Context) error { return errBackend }
+func ok(context.Context) error   { return nil }
+
+type stateLog struct {
+	mu      sync.Mutex
+	changes []string
+}
+
+func (l *stateLog) record(name string, from, to State) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.changes = append(l.changes, name+":"+from.String()+"->"+to.String())
+}
+
+func (l *stateLog) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return fmtList(l.changes)
+}
+
+func fmtList(s []string) string {
+	out := ""
+	for i, v := range s {
+		if i > 0 {
+			out += " "
+		}
+		out += v
+	}
+	return out
+}
+
+func TestSREBreaker(t *testing.T) {
+	log := &stateLog{}
+	b := New(WithName("sre"), WithWindow(10, 20*time.Millisecond), OnStateChange(log.record))
+	ctx := context.Background()
+
+	var rejected int
+	for i := 0; i < 200; i++ {
+		if err := b.Do(ctx, fail); errors.Is(err, ErrOpen) {
+			rejected++
+		} else if !errors.Is(err, errBackend) {
+			t.Fatalf("unexpected error %v", err)
+		}
+	}
+	// 全部失败时丢弃概率趋近 1
+	if rejected < 150 || b.State() != StateOpen {
+		t.Errorf("rejected %d of 200, state %v", rejected, b.State())
+	}
+
+	// 窗口过期后恢复放行
+	time.Sleep(250 * time.Millisecond)
+	for i := 0; i < 20; i++ {
+		if err := b.Do(ctx, ok); err != nil {
+			t.Fatalf("request rejected after recovery: %v", err)
+		}
+	}
+	if b.State() != StateClosed || log.String() != "sre:closed->open sre:open->closed" {
+		t.Errorf("state %v, changes %s", b.State(), log)
+	}
+}
+
+func TestSREProtection(t *testing.T) {
+	b := New(WithSRE(2, 10))
+	for i := 0; i < 10; i++ {
+		if err := b.Do(context.Background(), fail); !errors.Is(err, errBackend) {
+			t.Fatalf("request %d should pass within protection: %v", i, err)
+		}
+	}
+}
+
+func TestClassicBreaker(t *testing.T) {
+	log := &stateLog{}
+	b := New(WithName("api"), OnStateChange(log.record), WithClassic(ClassicConfig{
+		FailureRatio: 0.5,
+		MinRequests:  4,
+		OpenTimeout:  time.Second,
+		HalfOpenMax:  2,
+	}))
+	ct := b.t.(*classicThrottle)
+	now := time.Now()
+	ct.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	b.Do(ctx, ok)
+	b.Do(ctx, ok)
+	b.Do(ctx, fail)
+	if b.State() != StateClosed {
+		t.Fatal("should stay closed below MinRequests")
+	}
+	b.Do(ctx, fail)
+	if b.State() != StateOpen {
+		t.Fatal("should open at 50% failures")
+	}
+	if err := b.Do(ctx, ok); !errors.Is(err, ErrOpen) {
+		t.Fatalf("open breaker returned %v", err)
+	}
+
+	// 超时后半开，探测失败重新打开
+	now = now.Add(time.Second)
+	if err := b.Do(ctx, fail); !errors.Is(err, errBackend) {
+		t.Fatalf("half-open probe returned %v", err)
+	}
+	if b.State() != StateOpen {
+		t.Fatal("failed probe should reopen")
+	}
+
+	// 半开时最多放行 HalfOpenMax 个探测，全部成功后关闭
+	now = now.Add(time.Second)
+	p1, err1 := b.Allow()
+	p2, err2 := b.Allow()
+	_, err3 := b.Allow()
+	if err1 != nil || err2 != nil || !errors.Is(err3, ErrOpen) {
+		t.Fatalf("half-open admission: %v %v %v", err1, err2, err3)
+	}
+	p1.Accept()
+	if b.State() != StateHalfOpen {
+		t.Fatal("should wait for all probes")
+	}
+	p2.Accept()
+	if b.State() != StateClosed {
+		t.Fatal("should close after successful probes")
+	}
+
+	want := "api:closed->open api:open->half-open api:half-open->open api:open->half-open api:half-open->closed"
+	if log.String() != want {
+		t.Errorf("changes = %s", log)
+	}
+}
+
+func TestClassicIgnoresStaleResults(t *testing.T) {
+	b := New(WithClassic(ClassicConfig{MinRequests: 1, OpenTimeout: time.Hour}))
+	stale, _ := b.Allow()
+	b.Do(context.Background(), fail)
+	if b.State() != StateOpen {
+		t.Fatal("should open")
+	}
+	// 打开之前放行的请求结束时不应影响新状态
+	stale.Accept()
+	if b.State() != StateOpen {
+		t.Error("stale result changed the state")
+	}
+}
+
+func TestClassifierAndFallback(t *testing.T) {
+	errNotFound := errors.New("not found")
+	b := New(WithClassifier(IgnoreErrors(errNotFound)), WithClassic(ClassicConfig{MinRequests: 1}))
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		b.Do(ctx, func(context.Context) error { return errNotFound })
+	}
+	b.Do(ctx, func(context.Context) error { return context.Canceled })
+	if b.State() != StateClosed {
+		t.Fatal("ignored errors should not open the breaker")
+	}
+
+	b = New(WithClassic(ClassicConfig{MinRequests: 1, OpenTimeout: time.Hour}))
+	b.Do(ctx, fail)
+	var fallbackErr error
+	err := b.DoWithFallback(ctx, ok, func(_ context.Context, err error) error {
+		fallbackErr = err
+		return nil
+	})
+	if err != nil || !errors.Is(fallbackErr, ErrOpen) {
+		t.Errorf("fallback: err=%v fallbackErr=%v", err, fallbackErr)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := b.Do(cancelled, ok); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled context returned %v", err)
+	}
+}
+
+func TestGroup(t *testing.T) {
+	g := NewGroup(WithClassic(ClassicConfig{MinRequests: 1}))
+	a := g.Get("a")
+	if g.Get("a") != a || a.Name() != "a" {
+		t.Fatal("Get should return the same breaker")
+	}
+	a.Do(context.Background(), fail)
+	if a.State() != StateOpen || g.Get("b").State() != StateClosed {
+		t.Error("breakers in a group should be independent")
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	g := NewGroup(WithClassic(ClassicConfig{MinRequests: 2, OpenTimeout: time.Hour}))
+	r := gin.New()
+	r.Use(GroupMiddleware(g))
+	r.GET("/bad/:id", func(c *gin.Context) { c.Status(http.StatusBadGateway) })
+	r.GET("/good", func(c *gin.Context) { c.Status(http.StatusNotFound) })
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+	get("/bad/1")
+	get("/bad/2")
+	w := get("/bad/3")
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503", w.Code)
+	}
+	if body := w.Body.String(); body != `{"err_code":"Error.ServiceUnavailable","message":"breaker: circuit breaker is open"}` {
+		t.Errorf("body = %s", body)
+	}
+	// 4xx 不计为失败，其他路由不受影响
+	for i := 0; i < 5; i++ {
+		if w := get("/good"); w.Code != http.StatusNotFound {
+			t.Fatalf("status = %d", w.Code)
+		}
+	}
+}
+
+func TestTransport(t *testing.T) {
+	var hits int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	b := New(WithClassic(ClassicConfig{MinRequests: 3, OpenTimeout: time.Hour}))
+	client := &http.Client{Transport: NewTransport(b, nil)}
+	for i := 0; i < 3; i++ {
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	if _, err := client.Get(srv.URL); !errors.Is(err, ErrOpen) {
+		t.Fatalf("expected ErrOpen, got %v", err)
+	}
+	if hits != 3 {
+		t.Errorf("backend hit %d times after opening", hits)
+	}
+
+	hostClient := &http.Client{Transport: NewHostTransport(NewGroup(), nil)}
+	resp, err := hostClient.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+}
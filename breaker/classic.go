@@ -0,0 +1,148 @@
+package breaker
+
+import (
+	"sync"
+	"time"
+
+	"github.com/trancecho/ragnarok/rollingwindows"
+)
+
+// classicThrottle 经典三态熔断：关闭状态下窗口内失败率超限则打开，
+// 打开 OpenTimeout 后进入半开，半开期间 HalfOpenMax 个探测请求全部成功则关闭，任一失败则重新打开
+type classicThrottle struct {
+	cfg       ClassicConfig
+	newWindow func() *rollingwindows.RollingWindow
+	notify    func(from, to State)
+	now       func() time.Time
+
+	mu       sync.Mutex
+	cur      State
+	gen      uint64 // 每次状态变化加一，旧状态下放行的请求结果被忽略
+	win      *rollingwindows.RollingWindow
+	openedAt time.Time
+	probes   int // 半开状态已放行的探测请求数
+	passed   int // 半开状态已成功的探测请求数
+}
+
+func newClassicThrottle(cfg ClassicConfig, newWindow func() *rollingwindows.RollingWindow,
+	notify func(from, to State)) *classicThrottle {
+	if cfg.FailureRatio <= 0 {
+		cfg.FailureRatio = 0.5
+	}
+	if cfg.MinRequests <= 0 {
+		cfg.MinRequests = 20
+	}
+	if cfg.OpenTimeout <= 0 {
+		cfg.OpenTimeout = 5 * time.Second
+	}
+	if cfg.HalfOpenMax <= 0 {
+		cfg.HalfOpenMax = 1
+	}
+	return &classicThrottle{
+		cfg:       cfg,
+		newWindow: newWindow,
+		notify:    notify,
+		now:       time.Now,
+		win:       newWindow(),
+	}
+}
+
+func (t *classicThrottle) allow() (Promise, error) {
+	t.mu.Lock()
+	from := t.cur
+	if t.cur == StateOpen && t.now().Sub(t.openedAt) >= t.cfg.OpenTimeout {
+		t.transition(StateHalfOpen)
+	}
+	switch t.cur {
+	case StateOpen:
+		t.mu.Unlock()
+		return nil, ErrOpen
+	case StateHalfOpen:
+		if t.probes >= t.cfg.HalfOpenMax {
+			t.unlockNotify(from)
+			return nil, ErrOpen
+		}
+		t.probes++
+	}
+	call := classicCall{t: t, gen: t.gen}
+	t.unlockNotify(from)
+	return call, nil
+}
+
+func (t *classicThrottle) state() State {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.cur
+}
+
+func (t *classicThrottle) done(gen uint64, ok bool) {
+	t.mu.Lock()
+	from := t.cur
+	if gen != t.gen {
+		t.mu.Unlock()
+		return
+	}
+	switch t.cur {
+	case StateClosed:
+		if ok {
+			t.win.Add(1)
+		} else {
+			t.win.Add(0)
+			t.checkFailures()
+		}
+	case StateHalfOpen:
+		if !ok {
+			t.transition(StateOpen)
+		} else if t.passed++; t.passed >= t.cfg.HalfOpenMax {
+			t.transition(StateClosed)
+		}
+	}
+	t.unlockNotify(from)
+}
+
+// checkFailures 关闭状态下失败率超限时打开
+func (t *classicThrottle) checkFailures() {
+	var accepts float64
+	var total int64
+	t.win.Reduce(func(b *rollingwindows.Bucket) {
+		accepts += b.Sum
+		total += b.Count
+	})
+	if total >= t.cfg.MinRequests && (float64(total)-accepts)/float64(total) >= t.cfg.FailureRatio {
+		t.transition(StateOpen)
+	}
+}
+
+func (t *classicThrottle) transition(to State) {
+	t.cur = to
+	t.gen++
+	t.probes, t.passed = 0, 0
+	switch to {
+	case StateOpen:
+		t.openedAt = t.now()
+	case StateClosed:
+		t.win = t.newWindow()
+	}
+}
+
+// unlockNotify 解锁，状态相对 from 发生变化时在锁外触发回调
+func (t *classicThrottle) unlockNotify(from State) {
+	to := t.cur
+	t.mu.Unlock()
+	if from != to {
+		t.notify(from, to)
+	}
+}
+
+type classicCall struct {
+	t   *classicThrottle
+	gen uint64
+}
+
+func (c classicCall) Accept() {
+	c.t.done(c.gen, true)
+}
+
+func (c classicCall) Reject() {
+	c.t.done(c.gen, false)
+}
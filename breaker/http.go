@@ -0,0 +1,108 @@
+package breaker
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trancecho/ragnarok/util"
+)
+
+// StatusError 表示 HTTP 5xx 响应，中间件和 Transport 把它交给分类器判定
+type StatusError struct {
+	Code int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("breaker: http status %d", e.Code)
+}
+
+// statusErr 5xx 状态码转换为 StatusError，其余返回 nil
+func statusErr(code int) error {
+	if code >= http.StatusInternalServerError {
+		return &StatusError{Code: code}
+	}
+	return nil
+}
+
+// Middleware 用同一个熔断器保护所有路由，被拒绝时返回 503，
+// 响应体格式与 util.ServerError 一致；处理函数写出 5xx 或 panic 时计为失败
+func Middleware(b *Breaker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		serve(c, b)
+	}
+}
+
+// GroupMiddleware 按路由模板（c.FullPath()）为每个路由使用独立的熔断器
+func GroupMiddleware(g *Group) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name := c.FullPath()
+		if name == "" {
+			name = c.Request.URL.Path
+		}
+		serve(c, g.Get(c.Request.Method+" "+name))
+	}
+}
+
+func serve(c *gin.Context, b *Breaker) {
+	p, err := b.Allow()
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+			"err_code": util.ServiceUnavailableError,
+			"message":  err.Error(),
+		})
+		return
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			p.Reject()
+			panic(r)
+		}
+	}()
+	c.Next()
+	b.finish(p, statusErr(c.Writer.Status()))
+}
+
+// Transport 在熔断器保护下发送请求的 http.RoundTripper，
+// 传输错误和 5xx 响应交给分类器判定，被拒绝时返回 ErrOpen
+type Transport struct {
+	base    http.RoundTripper
+	breaker *Breaker
+	group   *Group
+}
+
+// NewTransport 所有请求共用一个熔断器，base 为 nil 时使用 http.DefaultTransport
+func NewTransport(b *Breaker, base http.RoundTripper) *Transport {
+	return &Transport{base: base, breaker: b}
+}
+
+// NewHostTransport 按请求的 Host 为每个下游使用独立的熔断器
+func NewHostTransport(g *Group, base http.RoundTripper) *Transport {
+	return &Transport{base: base, group: g}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	b := t.breaker
+	if t.group != nil {
+		b = t.group.Get(req.URL.Host)
+	}
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	p, err := b.Allow()
+	if err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		b.finish(p, err)
+		return nil, err
+	}
+	b.finish(p, statusErr(resp.StatusCode))
+	return resp, nil
+}
@@ -0,0 +1,77 @@
+package breaker
+
+import (
+	"math/rand/v2"
+	"sync"
+
+	"github.com/trancecho/ragnarok/rollingwindows"
+)
+
+// sreThrottle Google SRE 客户端自适应限流，见《Site Reliability Engineering》第 21 章。
+// 窗口中每个请求记一次，成功记 1、失败和本地丢弃记 0，因此 Sum 为 accepts、Count 为 requests
+type sreThrottle struct {
+	k          float64
+	protection int64
+	win        *rollingwindows.RollingWindow
+	notify     func(from, to State)
+
+	mu  sync.Mutex
+	cur State
+}
+
+func newSREThrottle(k float64, protection int64, win *rollingwindows.RollingWindow,
+	notify func(from, to State)) *sreThrottle {
+	return &sreThrottle{k: k, protection: protection, win: win, notify: notify}
+}
+
+func (t *sreThrottle) dropRatio() float64 {
+	var accepts float64
+	var total int64
+	t.win.Reduce(func(b *rollingwindows.Bucket) {
+		accepts += b.Sum
+		total += b.Count
+	})
+	return max(0, (float64(total-t.protection)-t.k*accepts)/float64(total+1))
+}
+
+func (t *sreThrottle) allow() (Promise, error) {
+	ratio := t.dropRatio()
+	if ratio > 0 {
+		t.setState(StateOpen)
+	} else {
+		t.setState(StateClosed)
+	}
+	if ratio > 0 && rand.Float64() < ratio {
+		t.win.Add(0)
+		return nil, ErrOpen
+	}
+	return sreCall{t}, nil
+}
+
+func (t *sreThrottle) setState(to State) {
+	t.mu.Lock()
+	from := t.cur
+	t.cur = to
+	t.mu.Unlock()
+	if from != to {
+		t.notify(from, to)
+	}
+}
+
+func (t *sreThrottle) state() State {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.cur
+}
+
+type sreCall struct {
+	t *sreThrottle
+}
+
+func (c sreCall) Accept() {
+	c.t.win.Add(1)
+}
+
+func (c sreCall) Reject() {
+	c.t.win.Add(0)
+}
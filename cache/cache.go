@@ -0,0 +1,333 @@
+// Package cache 提供分片的并发安全缓存，支持按条目设置过期时间、命中率统计、
+// 淘汰回调，以及 LRU、LFU、2Q、W-TinyLFU 四种淘汰策略。
+package cache
+
+import (
+	"hash/maphash"
+	"sync"
+	"time"
+
+	"github.com/trancecho/ragnarok/list"
+)
+
+// EvictReason 条目离开缓存的原因
+type EvictReason int
+
+const (
+	ReasonEvicted EvictReason = iota // 容量不足被淘汰（含 TinyLFU 拒绝准入）
+	ReasonExpired                    // 过期
+	ReasonDeleted                    // 调用 Delete 删除
+)
+
+func (r EvictReason) String() string {
+	switch r {
+	case ReasonEvicted:
+		return "evicted"
+	case ReasonExpired:
+		return "expired"
+	case ReasonDeleted:
+		return "deleted"
+	}
+	return "unknown"
+}
+
+// Policy 淘汰策略
+type Policy int
+
+const (
+	LRU      Policy = iota // 最近最少使用
+	LFU                    // 最不经常使用，频率相同时淘汰最久未使用的
+	TwoQueue               // 2Q：首次访问进入 FIFO，再次访问才进入 LRU，抵抗一次性扫描
+	TinyLFU                // W-TinyLFU：窗口 LRU + 分段 LRU，按 Count-Min Sketch 估计的频率决定准入
+)
+
+// Stats 缓存统计
+type Stats struct {
+	Hits        uint64
+	Misses      uint64
+	Evictions   uint64 // 容量淘汰次数
+	Expirations uint64 // 过期清理次数
+}
+
+// HitRatio 返回命中率，没有访问时为 0
+func (s Stats) HitRatio() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+// Option 自定义 Cache
+type Option func(o *options)
+
+type options struct {
+	shards     int
+	policy     Policy
+	defaultTTL time.Duration
+}
+
+// WithShards 设置分片数，会向下取整为 2 的幂且不超过容量，默认 16
+func WithShards(n int) Option {
+	return func(o *options) {
+		o.shards = n
+	}
+}
+
+// WithPolicy 设置淘汰策略，默认 LRU
+func WithPolicy(p Policy) Option {
+	return func(o *options) {
+		o.policy = p
+	}
+}
+
+// WithDefaultTTL 设置 Set 使用的默认过期时间，<= 0 表示永不过期
+func WithDefaultTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.defaultTTL = ttl
+	}
+}
+
+// entry 缓存条目，由所在分片的锁保护
+type entry[K comparable, V any] struct {
+	key      K
+	value    V
+	hash     uint64
+	expireAt int64 // UnixNano，0 表示永不过期
+
+	// 以下字段由淘汰策略维护
+	node  *list.Node[K, *entry[K, V]] // 所在链表的节点
+	queue queueID                     // 所在的队列
+	freq  int                         // LFU 访问频率
+}
+
+type evicted[K comparable, V any] struct {
+	key    K
+	value  V
+	reason EvictReason
+}
+
+type shard[K comparable, V any] struct {
+	mu     sync.Mutex
+	items  map[K]*entry[K, V]
+	policy policy[K, V]
+	stats  Stats
+}
+
+// Cache 分片缓存。每个分片有独立的锁和淘汰策略，容量平均分配到各分片，
+// 因此淘汰只在分片内近似全局策略
+type Cache[K comparable, V any] struct {
+	shards     []*shard[K, V]
+	mask       uint64
+	seed       maphash.Seed
+	defaultTTL time.Duration
+	onEvict    []func(key K, value V, reason EvictReason)
+	now        func() time.Time
+}
+
+// New 创建缓存，capacity 为总条目数（至少为 1）
+func New[K comparable, V any](capacity int, opts ...Option) *Cache[K, V] {
+	o := options{shards: 16, policy: LRU}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if capacity < 1 {
+		capacity = 1
+	}
+	n := 1
+	for n*2 <= o.shards && n*2 <= capacity {
+		n *= 2
+	}
+
+	c := &Cache[K, V]{
+		shards:     make([]*shard[K, V], n),
+		mask:       uint64(n - 1),
+		seed:       maphash.MakeSeed(),
+		defaultTTL: o.defaultTTL,
+		now:        time.Now,
+	}
+	per := (capacity + n - 1) / n
+	for i := range c.shards {
+		c.shards[i] = &shard[K, V]{
+			items:  make(map[K]*entry[K, V]),
+			policy: newPolicy[K, V](o.policy, per),
+		}
+	}
+	return c
+}
+
+// OnEvict 注册条目离开缓存时的回调，回调在分片锁之外执行。应在使用缓存前注册
+func (c *Cache[K, V]) OnEvict(f func(key K, value V, reason EvictReason)) {
+	c.onEvict = append(c.onEvict, f)
+}
+
+func (c *Cache[K, V]) shardOf(key K) (*shard[K, V], uint64) {
+	h := maphash.Comparable(c.seed, key)
+	return c.shards[h&c.mask], h
+}
+
+// Get 获取值并记录一次访问，过期的条目视为不存在
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	s, h := c.shardOf(key)
+	now := c.now().UnixNano()
+	s.mu.Lock()
+	e, ok := s.items[key]
+	if ok && e.expired(now) {
+		s.removeLocked(e)
+		s.stats.Expirations++
+		s.stats.Misses++
+		s.mu.Unlock()
+		c.emit([]evicted[K, V]{{key: e.key, value: e.value, reason: ReasonExpired}})
+		var zero V
+		return zero, false
+	}
+	if !ok {
+		s.policy.miss(h)
+		s.stats.Misses++
+		s.mu.Unlock()
+		var zero V
+		return zero, false
+	}
+	s.policy.touch(e)
+	s.stats.Hits++
+	value := e.value
+	s.mu.Unlock()
+	return value, true
+}
+
+// Peek 获取值，不影响淘汰顺序和统计
+func (c *Cache[K, V]) Peek(key K) (V, bool) {
+	s, _ := c.shardOf(key)
+	now := c.now().UnixNano()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.items[key]
+	if !ok || e.expired(now) {
+		var zero V
+		return zero, false
+	}
+	return e.value, true
+}
+
+// Set 写入值，使用默认过期时间
+func (c *Cache[K, V]) Set(key K, value V) {
+	c.SetWithTTL(key, value, c.defaultTTL)
+}
+
+// SetWithTTL 写入值并设置过期时间，ttl <= 0 表示永不过期
+func (c *Cache[K, V]) SetWithTTL(key K, value V, ttl time.Duration) {
+	s, h := c.shardOf(key)
+	var expireAt int64
+	if ttl > 0 {
+		expireAt = c.now().Add(ttl).UnixNano()
+	}
+	s.mu.Lock()
+	if e, ok := s.items[key]; ok {
+		e.value = value
+		e.expireAt = expireAt
+		s.policy.touch(e)
+		s.mu.Unlock()
+		return
+	}
+	e := &entry[K, V]{key: key, value: value, hash: h, expireAt: expireAt}
+	s.items[key] = e
+	var out []evicted[K, V]
+	for _, victim := range s.policy.add(e) {
+		delete(s.items, victim.key)
+		s.stats.Evictions++
+		out = append(out, evicted[K, V]{key: victim.key, value: victim.value, reason: ReasonEvicted})
+	}
+	s.mu.Unlock()
+	c.emit(out)
+}
+
+// Delete 删除条目，返回条目是否存在
+func (c *Cache[K, V]) Delete(key K) bool {
+	s, _ := c.shardOf(key)
+	s.mu.Lock()
+	e, ok := s.items[key]
+	if !ok {
+		s.mu.Unlock()
+		return false
+	}
+	s.removeLocked(e)
+	s.mu.Unlock()
+	c.emit([]evicted[K, V]{{key: e.key, value: e.value, reason: ReasonDeleted}})
+	return true
+}
+
+// DeleteExpired 清理所有已过期的条目，返回清理的数量。过期条目默认在访问时才清理，
+// 对于写多读少的场景可以定期调用
+func (c *Cache[K, V]) DeleteExpired() int {
+	now := c.now().UnixNano()
+	total := 0
+	for _, s := range c.shards {
+		var out []evicted[K, V]
+		s.mu.Lock()
+		for _, e := range s.items {
+			if e.expired(now) {
+				s.removeLocked(e)
+				s.stats.Expirations++
+				out = append(out, evicted[K, V]{key: e.key, value: e.value, reason: ReasonExpired})
+			}
+		}
+		s.mu.Unlock()
+		c.emit(out)
+		total += len(out)
+	}
+	return total
+}
+
+// Len 返回条目数，包括尚未清理的过期条目
+func (c *Cache[K, V]) Len() int {
+	n := 0
+	for _, s := range c.shards {
+		s.mu.Lock()
+		n += len(s.items)
+		s.mu.Unlock()
+	}
+	return n
+}
+
+// Clear 清空缓存，不触发淘汰回调，统计保留
+func (c *Cache[K, V]) Clear() {
+	for _, s := range c.shards {
+		s.mu.Lock()
+		for _, e := range s.items {
+			s.policy.remove(e)
+		}
+		s.items = make(map[K]*entry[K, V])
+		s.mu.Unlock()
+	}
+}
+
+// Stats 返回各分片统计之和
+func (c *Cache[K, V]) Stats() Stats {
+	var total Stats
+	for _, s := range c.shards {
+		s.mu.Lock()
+		total.Hits += s.stats.Hits
+		total.Misses += s.stats.Misses
+		total.Evictions += s.stats.Evictions
+		total.Expirations += s.stats.Expirations
+		s.mu.Unlock()
+	}
+	return total
+}
+
+func (c *Cache[K, V]) emit(out []evicted[K, V]) {
+	for _, ev := range out {
+		for _, f := range c.onEvict {
+			f(ev.key, ev.value, ev.reason)
+		}
+	}
+}
+
+func (e *entry[K, V]) expired(now int64) bool {
+	return e.expireAt != 0 && now >= e.expireAt
+}
+
+func (s *shard[K, V]) removeLocked(e *entry[K, V]) {
+	delete(s.items, e.key)
+	s.policy.remove(e)
+}
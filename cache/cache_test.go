@@ -0,0 +1,280 @@
+package cache
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+)
+
+var allPolicies = map[string]Policy{
+	"lru":     LRU,
+	"lfu":     LFU,
+	"2q":      TwoQueue,
+	"tinylfu": TinyLFU,
+}
+
+// newTestCache 创建单分片缓存，便于验证淘汰顺序
+func newTestCache[V any](capacity int, p Policy) *Cache[string, V] {
+	return New[string, V](capacity, WithShards(1), WithPolicy(p))
+}
+
+func TestCacheBasic(t *testing.T) {
+	for name, p := range allPolicies {
+		t.Run(name, func(t *testing.T) {
+			c := New[string, int](100, WithPolicy(p))
+			c.Set("a", 1)
+			c.Set("b", 2)
+			if v, ok := c.Get("a"); !ok || v != 1 {
+				t.Errorf("Get(a) = %d %v, want 1", v, ok)
+			}
+			c.Set("a", 10)
+			if v, ok := c.Peek("a"); !ok || v != 10 {
+				t.Errorf("Peek(a) = %d %v, want 10", v, ok)
+			}
+			if _, ok := c.Get("missing"); ok {
+				t.Error("Get(missing) should miss")
+			}
+			if !c.Delete("b") || c.Delete("b") {
+				t.Error("Delete(b) should succeed exactly once")
+			}
+			if c.Len() != 1 {
+				t.Errorf("Len() = %d, want 1", c.Len())
+			}
+			st := c.Stats()
+			if st.Hits != 1 || st.Misses != 1 || st.HitRatio() != 0.5 {
+				t.Errorf("Stats() = %+v", st)
+			}
+			c.Clear()
+			if c.Len() != 0 {
+				t.Errorf("Len() after Clear = %d", c.Len())
+			}
+			c.Set("a", 1)
+			if v, _ := c.Get("a"); v != 1 {
+				t.Error("cache should be usable after Clear")
+			}
+		})
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	c := New[string, string](10, WithDefaultTTL(time.Minute))
+	c.now = func() time.Time { return now }
+
+	var expired []string
+	c.OnEvict(func(key string, _ string, reason EvictReason) {
+		if reason == ReasonExpired {
+			expired = append(expired, key)
+		}
+	})
+	c.Set("session", "s")
+	c.SetWithTTL("short", "x", time.Second)
+	c.SetWithTTL("forever", "y", 0)
+
+	now = now.Add(2 * time.Second)
+	if _, ok := c.Peek("short"); ok {
+		t.Error("Peek() should not return expired entries")
+	}
+	if _, ok := c.Get("short"); ok {
+		t.Error("Get() should not return expired entries")
+	}
+	if v, ok := c.Get("session"); !ok || v != "s" {
+		t.Error("session should still be alive")
+	}
+
+	now = now.Add(time.Hour)
+	if n := c.DeleteExpired(); n != 1 {
+		t.Errorf("DeleteExpired() = %d, want 1", n)
+	}
+	if _, ok := c.Get("forever"); !ok {
+		t.Error("entry without TTL should never expire")
+	}
+	if len(expired) != 2 || c.Stats().Expirations != 2 {
+		t.Errorf("expired = %v, Expirations = %d", expired, c.Stats().Expirations)
+	}
+}
+
+func TestCacheEvictCallback(t *testing.T) {
+	c := newTestCache[int](2, LRU)
+	var evicted, deleted []string
+	c.OnEvict(func(key string, _ int, reason EvictReason) {
+		switch reason {
+		case ReasonEvicted:
+			evicted = append(evicted, key)
+		case ReasonDeleted:
+			deleted = append(deleted, key)
+		}
+	})
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Get("a") // a 最近被访问，淘汰 b
+	c.Set("c", 3)
+	c.Delete("a")
+	if fmt.Sprint(evicted) != "[b]" || fmt.Sprint(deleted) != "[a]" {
+		t.Errorf("evicted = %v, deleted = %v", evicted, deleted)
+	}
+	if c.Stats().Evictions != 1 {
+		t.Errorf("Evictions = %d, want 1", c.Stats().Evictions)
+	}
+}
+
+func TestLFUKeepsFrequent(t *testing.T) {
+	c := newTestCache[int](3, LFU)
+	c.Set("hot", 0)
+	c.Set("warm", 0)
+	for i := 0; i < 5; i++ {
+		c.Get("hot")
+	}
+	c.Get("warm")
+	c.Set("cold", 0)
+	c.Set("new", 0) // cold 频率最低
+	if _, ok := c.Peek("cold"); ok {
+		t.Error("cold should be evicted first")
+	}
+	for _, k := range []string{"hot", "warm", "new"} {
+		if _, ok := c.Peek(k); !ok {
+			t.Errorf("%s should be kept", k)
+		}
+	}
+	c.Delete("new")
+	c.Set("x", 0)
+	c.Set("y", 0) // 删除后最小频率仍然正确
+	if _, ok := c.Peek("x"); ok {
+		t.Error("x should be evicted by y")
+	}
+}
+
+// 热点数据在一次性扫描之后仍然保留
+func TestScanResistance(t *testing.T) {
+	for _, p := range []Policy{TwoQueue, TinyLFU} {
+		c := newTestCache[int](100, p)
+		hot := make([]string, 20)
+		for i := range hot {
+			hot[i] = fmt.Sprintf("hot-%d", i)
+		}
+		for round := 0; round < 10; round++ {
+			for _, k := range hot {
+				if _, ok := c.Get(k); !ok {
+					c.Set(k, 0)
+				}
+			}
+		}
+		for i := 0; i < 1000; i++ {
+			c.Set(fmt.Sprintf("scan-%d", i), 0)
+		}
+		kept := 0
+		for _, k := range hot {
+			if _, ok := c.Peek(k); ok {
+				kept++
+			}
+		}
+		if kept < len(hot)*3/4 {
+			t.Errorf("policy %d kept %d/%d hot keys after a scan", p, kept, len(hot))
+		}
+	}
+
+	lru := newTestCache[int](100, LRU)
+	lru.Set("hot", 0)
+	for i := 0; i < 1000; i++ {
+		lru.Set(fmt.Sprintf("scan-%d", i), 0)
+	}
+	if _, ok := lru.Peek("hot"); ok {
+		t.Error("plain LRU is expected to lose hot keys to a scan")
+	}
+}
+
+// 随机操作下各策略的条目数不超过容量，且策略内部状态与 map 一致
+func TestCacheRandomOps(t *testing.T) {
+	for name, p := range allPolicies {
+		t.Run(name, func(t *testing.T) {
+			const capacity = 64
+			c := newTestCache[int](capacity, p)
+			r := rand.New(rand.NewSource(7))
+			live := map[string]bool{}
+			c.OnEvict(func(key string, _ int, _ EvictReason) { delete(live, key) })
+			for i := 0; i < 20000; i++ {
+				k := fmt.Sprintf("k%d", r.Intn(200))
+				switch r.Intn(4) {
+				case 0, 1:
+					c.Get(k)
+				case 2:
+					c.Set(k, i)
+					live[k] = true
+				case 3:
+					c.Delete(k)
+				}
+				if c.Len() > capacity {
+					t.Fatalf("Len() = %d exceeds capacity", c.Len())
+				}
+			}
+			if c.Len() != len(live) {
+				t.Fatalf("Len() = %d, callbacks imply %d", c.Len(), len(live))
+			}
+			for k := range live {
+				if _, ok := c.Peek(k); !ok {
+					t.Fatalf("%s should be present", k)
+				}
+			}
+			s := c.shards[0]
+			for _, e := range s.items {
+				if e.node == nil || e.node.Value != e || !e.node.IsValid() {
+					t.Fatalf("entry %s is not linked in the policy", e.key)
+				}
+			}
+		})
+	}
+}
+
+func TestCacheConcurrent(t *testing.T) {
+	for name, p := range allPolicies {
+		t.Run(name, func(t *testing.T) {
+			c := New[int, int](256, WithPolicy(p), WithShards(8))
+			var wg sync.WaitGroup
+			for g := 0; g < 8; g++ {
+				wg.Add(1)
+				go func(g int) {
+					defer wg.Done()
+					r := rand.New(rand.NewSource(int64(g)))
+					for i := 0; i < 5000; i++ {
+						k := r.Intn(1000)
+						if _, ok := c.Get(k); !ok {
+							c.Set(k, k)
+						}
+						if i%50 == 0 {
+							c.Delete(k)
+						}
+					}
+				}(g)
+			}
+			wg.Wait()
+			if c.Len() > 256 {
+				t.Errorf("Len() = %d exceeds capacity", c.Len())
+			}
+			st := c.Stats()
+			if st.Hits+st.Misses != 8*5000 {
+				t.Errorf("Hits+Misses = %d, want %d", st.Hits+st.Misses, 8*5000)
+			}
+		})
+	}
+}
+
+func BenchmarkCacheGetSet(b *testing.B) {
+	for name, p := range allPolicies {
+		b.Run(name, func(b *testing.B) {
+			c := New[int, int](10000, WithPolicy(p))
+			b.RunParallel(func(pb *testing.PB) {
+				r := rand.New(rand.NewSource(rand.Int63()))
+				for pb.Next() {
+					// Zipf 分布模拟热点访问
+					k := int(r.ExpFloat64() * 5000)
+					if _, ok := c.Get(k); !ok {
+						c.Set(k, k)
+					}
+				}
+			})
+			b.ReportMetric(c.Stats().HitRatio(), "hit-ratio")
+		})
+	}
+}
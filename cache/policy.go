@@ -0,0 +1,247 @@
+package cache
+
+import "github.com/trancecho/ragnarok/list"
+
+// queueID 标识条目所在的队列
+type queueID uint8
+
+const (
+	queueNone queueID = iota
+	queueMain
+	queueIn        // 2Q 的 A1in
+	queueWindow    // TinyLFU 的窗口
+	queueProbation // TinyLFU 的试用段
+	queueProtected // TinyLFU 的保护段
+)
+
+// policy 淘汰策略，由分片的锁保护
+type policy[K comparable, V any] interface {
+	// add 记录新条目，返回因容量不足被淘汰的条目（TinyLFU 下可能是新条目自身）
+	add(e *entry[K, V]) []*entry[K, V]
+	// touch 记录一次命中
+	touch(e *entry[K, V])
+	// miss 记录一次未命中，用于频率统计
+	miss(hash uint64)
+	// remove 移除条目
+	remove(e *entry[K, V])
+}
+
+func newPolicy[K comparable, V any](p Policy, capacity int) policy[K, V] {
+	switch p {
+	case LFU:
+		return newLFU[K, V](capacity)
+	case TwoQueue:
+		return newTwoQueue[K, V](capacity)
+	case TinyLFU:
+		return newTinyLFU[K, V](capacity)
+	default:
+		return newLRU[K, V](capacity)
+	}
+}
+
+// entryList 存放条目的双向链表，节点值指回条目
+type entryList[K comparable, V any] struct {
+	*list.List[K, *entry[K, V]]
+}
+
+func newEntryList[K comparable, V any]() entryList[K, V] {
+	return entryList[K, V]{list.New[K, *entry[K, V]](false)}
+}
+
+func (l entryList[K, V]) pushFront(e *entry[K, V], q queueID) {
+	e.node = l.AddFront(e.key, e)
+	e.queue = q
+}
+
+func (l entryList[K, V]) moveToFront(e *entry[K, V]) {
+	l.MoveToFront(e.node)
+}
+
+func (l entryList[K, V]) unlink(e *entry[K, V]) {
+	l.Remove(e.node)
+	e.node = nil
+	e.queue = queueNone
+}
+
+// popBack 移除并返回最久未访问的条目
+func (l entryList[K, V]) popBack() *entry[K, V] {
+	n := l.BackNode()
+	if n == nil {
+		return nil
+	}
+	e := n.Value
+	l.unlink(e)
+	return e
+}
+
+// lruPolicy 最近最少使用
+type lruPolicy[K comparable, V any] struct {
+	capacity int
+	ll       entryList[K, V]
+}
+
+func newLRU[K comparable, V any](capacity int) *lruPolicy[K, V] {
+	return &lruPolicy[K, V]{capacity: capacity, ll: newEntryList[K, V]()}
+}
+
+func (p *lruPolicy[K, V]) add(e *entry[K, V]) []*entry[K, V] {
+	p.ll.pushFront(e, queueMain)
+	if p.ll.Len() <= p.capacity {
+		return nil
+	}
+	return []*entry[K, V]{p.ll.popBack()}
+}
+
+func (p *lruPolicy[K, V]) touch(e *entry[K, V]) { p.ll.moveToFront(e) }
+func (p *lruPolicy[K, V]) miss(uint64)          {}
+func (p *lruPolicy[K, V]) remove(e *entry[K, V]) {
+	p.ll.unlink(e)
+}
+
+// lfuPolicy 按访问频率分桶的 O(1) LFU，同频率内按 LRU 淘汰
+type lfuPolicy[K comparable, V any] struct {
+	capacity int
+	size     int
+	minFreq  int
+	buckets  map[int]entryList[K, V]
+}
+
+func newLFU[K comparable, V any](capacity int) *lfuPolicy[K, V] {
+	return &lfuPolicy[K, V]{capacity: capacity, buckets: make(map[int]entryList[K, V])}
+}
+
+func (p *lfuPolicy[K, V]) bucket(freq int) entryList[K, V] {
+	b, ok := p.buckets[freq]
+	if !ok {
+		b = newEntryList[K, V]()
+		p.buckets[freq] = b
+	}
+	return b
+}
+
+func (p *lfuPolicy[K, V]) add(e *entry[K, V]) []*entry[K, V] {
+	var victims []*entry[K, V]
+	if p.size >= p.capacity {
+		victim := p.bucket(p.minFreq).popBack()
+		p.dropIfEmpty(victim.freq)
+		p.size--
+		victims = append(victims, victim)
+	}
+	e.freq = 1
+	p.bucket(1).pushFront(e, queueMain)
+	p.minFreq = 1
+	p.size++
+	return victims
+}
+
+func (p *lfuPolicy[K, V]) touch(e *entry[K, V]) {
+	old := e.freq
+	p.buckets[old].unlink(e)
+	if p.dropIfEmpty(old) && p.minFreq == old {
+		p.minFreq = old + 1
+	}
+	e.freq++
+	p.bucket(e.freq).pushFront(e, queueMain)
+}
+
+func (p *lfuPolicy[K, V]) miss(uint64) {}
+
+func (p *lfuPolicy[K, V]) remove(e *entry[K, V]) {
+	p.buckets[e.freq].unlink(e)
+	p.size--
+	if p.dropIfEmpty(e.freq) && p.minFreq == e.freq {
+		p.resetMinFreq()
+	}
+}
+
+func (p *lfuPolicy[K, V]) dropIfEmpty(freq int) bool {
+	if b, ok := p.buckets[freq]; ok && b.Len() == 0 {
+		delete(p.buckets, freq)
+		return true
+	}
+	return false
+}
+
+// resetMinFreq 删除任意条目后重新计算最小频率，只在最小频率桶被删空时发生
+func (p *lfuPolicy[K, V]) resetMinFreq() {
+	p.minFreq = 0
+	for f := range p.buckets {
+		if p.minFreq == 0 || f < p.minFreq {
+			p.minFreq = f
+		}
+	}
+}
+
+// twoQueuePolicy 2Q：新条目进入 FIFO 队列 A1in，再次命中或在幽灵队列 A1out 中的键再次写入时
+// 进入 LRU 队列 Am；A1in 超出配额时优先从 A1in 淘汰并把键记入 A1out。一次性扫描只会冲刷 A1in
+type twoQueuePolicy[K comparable, V any] struct {
+	capacity int
+	kin      int // A1in 的容量
+	kout     int // A1out 记录的键数
+	in       entryList[K, V]
+	main     entryList[K, V]
+	ghost    *list.List[K, struct{}]
+	ghostIdx map[K]*list.Node[K, struct{}]
+}
+
+func newTwoQueue[K comparable, V any](capacity int) *twoQueuePolicy[K, V] {
+	return &twoQueuePolicy[K, V]{
+		capacity: capacity,
+		kin:      max(1, capacity/4),
+		kout:     max(1, capacity/2),
+		in:       newEntryList[K, V](),
+		main:     newEntryList[K, V](),
+		ghost:    list.New[K, struct{}](false),
+		ghostIdx: make(map[K]*list.Node[K, struct{}]),
+	}
+}
+
+func (p *twoQueuePolicy[K, V]) add(e *entry[K, V]) []*entry[K, V] {
+	if n, ok := p.ghostIdx[e.key]; ok {
+		p.ghost.Remove(n)
+		delete(p.ghostIdx, e.key)
+		p.main.pushFront(e, queueMain)
+	} else {
+		p.in.pushFront(e, queueIn)
+	}
+
+	var victims []*entry[K, V]
+	for p.in.Len()+p.main.Len() > p.capacity {
+		if p.in.Len() > p.kin || p.main.Len() == 0 {
+			victim := p.in.popBack()
+			p.remember(victim.key)
+			victims = append(victims, victim)
+		} else {
+			victims = append(victims, p.main.popBack())
+		}
+	}
+	return victims
+}
+
+func (p *twoQueuePolicy[K, V]) remember(key K) {
+	p.ghostIdx[key] = p.ghost.AddFront(key, struct{}{})
+	if p.ghost.Len() > p.kout {
+		oldest := p.ghost.BackNode()
+		p.ghost.Remove(oldest)
+		delete(p.ghostIdx, oldest.Key)
+	}
+}
+
+func (p *twoQueuePolicy[K, V]) touch(e *entry[K, V]) {
+	if e.queue == queueMain {
+		p.main.moveToFront(e)
+		return
+	}
+	p.in.unlink(e)
+	p.main.pushFront(e, queueMain)
+}
+
+func (p *twoQueuePolicy[K, V]) miss(uint64) {}
+
+func (p *twoQueuePolicy[K, V]) remove(e *entry[K, V]) {
+	if e.queue == queueIn {
+		p.in.unlink(e)
+	} else {
+		p.main.unlink(e)
+	}
+}
@@ -0,0 +1,142 @@
+package cache
+
+// cmSketch 4 行的 Count-Min Sketch，计数器上限为 15，累计计数达到采样上限后全部减半，
+// 使频率估计随时间衰减
+type cmSketch struct {
+	rows      [4][]uint8
+	mask      uint64
+	additions int
+	sample    int
+}
+
+func newCMSketch(capacity int) *cmSketch {
+	width := 16
+	for width < capacity {
+		width *= 2
+	}
+	s := &cmSketch{mask: uint64(width - 1), sample: 10 * width}
+	for i := range s.rows {
+		s.rows[i] = make([]uint8, width)
+	}
+	return s
+}
+
+// index 由 64 位哈希的高低两半做双重哈希得到每行的下标
+func (s *cmSketch) index(hash uint64, row int) uint64 {
+	h1, h2 := hash&0xffffffff, hash>>32|1
+	return (h1 + uint64(row)*h2) & s.mask
+}
+
+func (s *cmSketch) increment(hash uint64) {
+	for i := range s.rows {
+		if idx := s.index(hash, i); s.rows[i][idx] < 15 {
+			s.rows[i][idx]++
+		}
+	}
+	s.additions++
+	if s.additions >= s.sample {
+		s.reset()
+	}
+}
+
+func (s *cmSketch) estimate(hash uint64) uint8 {
+	est := uint8(15)
+	for i := range s.rows {
+		est = min(est, s.rows[i][s.index(hash, i)])
+	}
+	return est
+}
+
+func (s *cmSketch) reset() {
+	for i := range s.rows {
+		for j := range s.rows[i] {
+			s.rows[i][j] >>= 1
+		}
+	}
+	s.additions /= 2
+}
+
+// tinyLFUPolicy W-TinyLFU：新条目先进入容量约 1% 的窗口 LRU，被挤出窗口时与主区的淘汰候选
+// 比较估计频率，频率更高者留下。主区是分段 LRU，试用段中再次命中的条目晋升到保护段（占主区 80%）
+type tinyLFUPolicy[K comparable, V any] struct {
+	windowCap    int
+	mainCap      int
+	protectedCap int
+	window       entryList[K, V]
+	probation    entryList[K, V]
+	protected    entryList[K, V]
+	sketch       *cmSketch
+}
+
+func newTinyLFU[K comparable, V any](capacity int) *tinyLFUPolicy[K, V] {
+	windowCap := max(1, capacity/100)
+	mainCap := capacity - windowCap
+	return &tinyLFUPolicy[K, V]{
+		windowCap:    windowCap,
+		mainCap:      mainCap,
+		protectedCap: mainCap * 8 / 10,
+		window:       newEntryList[K, V](),
+		probation:    newEntryList[K, V](),
+		protected:    newEntryList[K, V](),
+		sketch:       newCMSketch(capacity),
+	}
+}
+
+func (p *tinyLFUPolicy[K, V]) add(e *entry[K, V]) []*entry[K, V] {
+	p.sketch.increment(e.hash)
+	p.window.pushFront(e, queueWindow)
+	if p.window.Len() <= p.windowCap {
+		return nil
+	}
+
+	candidate := p.window.popBack()
+	if p.probation.Len()+p.protected.Len() < p.mainCap {
+		p.probation.pushFront(candidate, queueProbation)
+		return nil
+	}
+	if p.mainCap == 0 {
+		return []*entry[K, V]{candidate}
+	}
+	victimList := p.probation
+	if victimList.Len() == 0 {
+		victimList = p.protected
+	}
+	victim := victimList.BackNode().Value
+	if p.sketch.estimate(candidate.hash) <= p.sketch.estimate(victim.hash) {
+		return []*entry[K, V]{candidate} // 拒绝准入
+	}
+	victimList.unlink(victim)
+	p.probation.pushFront(candidate, queueProbation)
+	return []*entry[K, V]{victim}
+}
+
+func (p *tinyLFUPolicy[K, V]) touch(e *entry[K, V]) {
+	p.sketch.increment(e.hash)
+	switch e.queue {
+	case queueWindow:
+		p.window.moveToFront(e)
+	case queueProtected:
+		p.protected.moveToFront(e)
+	case queueProbation:
+		p.probation.unlink(e)
+		p.protected.pushFront(e, queueProtected)
+		if p.protected.Len() > p.protectedCap {
+			p.probation.pushFront(p.protected.popBack(), queueProbation)
+		}
+	}
+}
+
+func (p *tinyLFUPolicy[K, V]) miss(hash uint64) {
+	p.sketch.increment(hash)
+}
+
+func (p *tinyLFUPolicy[K, V]) remove(e *entry[K, V]) {
+	switch e.queue {
+	case queueWindow:
+		p.window.unlink(e)
+	case queueProbation:
+		p.probation.unlink(e)
+	case queueProtected:
+		p.protected.unlink(e)
+	}
+}
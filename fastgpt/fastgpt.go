@@ -16,15 +16,37 @@ type Client struct {
 	httpClient *http.Client
 }
 
+// ClientOption customizes the FastGPT client
+type ClientOption func(*Client)
+
+// WithHTTPClient replaces the default http.Client, e.g. to wrap the transport
+// with breaker.NewTransport
+func WithHTTPClient(c *http.Client) ClientOption {
+	return func(s *Client) {
+		s.httpClient = c
+	}
+}
+
+// WithTransport keeps the default timeout but sends requests through rt
+func WithTransport(rt http.RoundTripper) ClientOption {
+	return func(s *Client) {
+		s.httpClient.Transport = rt
+	}
+}
+
 // NewFastClient creates a new FastGPT service client
-func NewFastClient(apiKey string, apiBaseURL string) *Client {
-	return &Client{
+func NewFastClient(apiKey string, apiBaseURL string, opts ...ClientOption) *Client {
+	s := &Client{
 		apiKey:     apiKey,
 		apiBaseURL: apiBaseURL,
 		httpClient: &http.Client{
 			Timeout: time.Second * 30,
 		},
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 // FastGPTChat sends a chat request to FastGPT and returns the response
@@ -0,0 +1,95 @@
+package heap
+
+import (
+	"context"
+	"sync"
+)
+
+// BlockingPriorityQueue 并发安全的阻塞优先队列，可作为协程之间的工作队列
+type BlockingPriorityQueue[T any] struct {
+	mu     sync.Mutex
+	heap   *Heap[T]
+	closed bool
+	wait   chan struct{} // 有新元素或关闭时关闭并替换，用于唤醒等待中的 Take
+}
+
+// NewBlockingPriorityQueue 创建阻塞优先队列，maxSize <= 0 表示不限制大小
+func NewBlockingPriorityQueue[T any](maxSize int, compareFn Comparable[T]) *BlockingPriorityQueue[T] {
+	return &BlockingPriorityQueue[T]{
+		heap: NewGrowableHeap[T](maxSize, compareFn),
+		wait: make(chan struct{}),
+	}
+}
+
+// Put 入队，队列已关闭时返回 ErrClosed，已满时返回 ErrFull
+func (q *BlockingPriorityQueue[T]) Put(value T) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return ErrClosed
+	}
+	if err := q.heap.TryInsert(value); err != nil {
+		return err
+	}
+	q.broadcast()
+	return nil
+}
+
+// Take 取出优先级最高的元素，队列为空时阻塞直到有新元素、ctx 结束或队列关闭。
+// 关闭后仍会先返回队列中剩余的元素，取完后返回 ErrClosed
+func (q *BlockingPriorityQueue[T]) Take(ctx context.Context) (T, error) {
+	for {
+		q.mu.Lock()
+		if !q.heap.IsEmpty() {
+			value, err := q.heap.Pop()
+			q.mu.Unlock()
+			return value, err
+		}
+		if q.closed {
+			q.mu.Unlock()
+			var zero T
+			return zero, ErrClosed
+		}
+		wait := q.wait
+		q.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			var zero T
+			return zero, ctx.Err()
+		case <-wait:
+		}
+	}
+}
+
+// TryTake 非阻塞地取出优先级最高的元素，队列为空时返回 false
+func (q *BlockingPriorityQueue[T]) TryTake() (T, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	value, err := q.heap.Pop()
+	return value, err == nil
+}
+
+// Len 返回队列中的元素个数
+func (q *BlockingPriorityQueue[T]) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.heap.Size()
+}
+
+// Close 关闭队列，之后 Put 返回 ErrClosed，等待中的 Take 被唤醒
+func (q *BlockingPriorityQueue[T]) Close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return
+	}
+	q.closed = true
+	q.broadcast()
+}
+
+// broadcast 唤醒所有等待者（由调用方持有锁）
+func (q *BlockingPriorityQueue[T]) broadcast() {
+	close(q.wait)
+	q.wait = make(chan struct{})
+}
@@ -0,0 +1,184 @@
+package heap
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestGrowableHeap(t *testing.T) {
+	h := NewGrowableHeap[int](0, MinHeap(intLess))
+	for i := 100; i > 0; i-- {
+		if err := h.TryInsert(i); err != nil {
+			t.Fatalf("TryInsert(%d) on unbounded heap = %v", i, err)
+		}
+	}
+	if h.Size() != 100 {
+		t.Fatalf("Size() = %d, want 100", h.Size())
+	}
+	for want := 1; want <= 100; want++ {
+		if got, _ := h.Pop(); got != want {
+			t.Fatalf("Pop() = %d, want %d", got, want)
+		}
+	}
+	if _, err := h.Pop(); !errors.Is(err, ErrEmpty) {
+		t.Errorf("Pop() on empty heap = %v, want ErrEmpty", err)
+	}
+
+	bounded := NewGrowableHeap[int](3, MaxHeap(intLess))
+	for i := 0; i < 3; i++ {
+		if err := bounded.TryInsert(i); err != nil {
+			t.Fatalf("TryInsert(%d) = %v", i, err)
+		}
+	}
+	if err := bounded.TryInsert(3); !errors.Is(err, ErrFull) {
+		t.Errorf("TryInsert() over bound = %v, want ErrFull", err)
+	}
+	bounded.Pop()
+	if err := bounded.TryInsert(3); err != nil {
+		t.Errorf("TryInsert() after Pop = %v", err)
+	}
+
+	fixed := NewHeap[int](1, MinHeap(intLess))
+	fixed.Insert(1)
+	if err := fixed.TryInsert(2); !errors.Is(err, ErrFull) {
+		t.Errorf("TryInsert() on full fixed heap = %v, want ErrFull", err)
+	}
+
+	pq := NewGrowablePriorityQueue[int](2, MinHeap(intLess))
+	pq.Enqueue(2)
+	pq.Enqueue(1)
+	if err := pq.TryEnqueue(0); !errors.Is(err, ErrFull) {
+		t.Errorf("TryEnqueue() over bound = %v, want ErrFull", err)
+	}
+	if pq.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", pq.Len())
+	}
+}
+
+func TestBlockingPriorityQueue(t *testing.T) {
+	q := NewBlockingPriorityQueue[int](0, MinHeap(intLess))
+	if _, ok := q.TryTake(); ok {
+		t.Error("TryTake() on empty queue should return false")
+	}
+
+	q.Put(3)
+	q.Put(1)
+	q.Put(2)
+	if q.Len() != 3 {
+		t.Errorf("Len() = %d, want 3", q.Len())
+	}
+	if v, ok := q.TryTake(); !ok || v != 1 {
+		t.Errorf("TryTake() = %d %v, want 1 true", v, ok)
+	}
+	if v, err := q.Take(context.Background()); err != nil || v != 2 {
+		t.Errorf("Take() = %d %v, want 2", v, err)
+	}
+
+	// 队列为空时 Take 阻塞，直到有新元素
+	got := make(chan int)
+	go func() {
+		q.Take(context.Background()) // 取走 3
+		v, _ := q.Take(context.Background())
+		got <- v
+	}()
+	time.Sleep(20 * time.Millisecond)
+	q.Put(42)
+	select {
+	case v := <-got:
+		if v != 42 {
+			t.Errorf("blocked Take() = %d, want 42", v)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("blocked Take() was not woken by Put")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := q.Take(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Take() with expired ctx = %v, want DeadlineExceeded", err)
+	}
+
+	bounded := NewBlockingPriorityQueue[int](1, MinHeap(intLess))
+	bounded.Put(1)
+	if err := bounded.Put(2); !errors.Is(err, ErrFull) {
+		t.Errorf("Put() over bound = %v, want ErrFull", err)
+	}
+}
+
+func TestBlockingPriorityQueueClose(t *testing.T) {
+	q := NewBlockingPriorityQueue[int](0, MinHeap(intLess))
+	q.Put(1)
+
+	done := make(chan error)
+	go func() {
+		q.Take(context.Background())
+		_, err := q.Take(context.Background())
+		done <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	q.Close()
+	q.Close()
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrClosed) {
+			t.Errorf("Take() after Close = %v, want ErrClosed", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Close did not wake blocked Take")
+	}
+	if err := q.Put(2); !errors.Is(err, ErrClosed) {
+		t.Errorf("Put() after Close = %v, want ErrClosed", err)
+	}
+}
+
+func TestBlockingPriorityQueueWorkers(t *testing.T) {
+	q := NewBlockingPriorityQueue[int](0, MinHeap(intLess))
+	const producers, perProducer, consumers = 4, 250, 4
+
+	var mu sync.Mutex
+	var taken []int
+	var cwg sync.WaitGroup
+	for i := 0; i < consumers; i++ {
+		cwg.Add(1)
+		go func() {
+			defer cwg.Done()
+			for {
+				v, err := q.Take(context.Background())
+				if err != nil {
+					return
+				}
+				mu.Lock()
+				taken = append(taken, v)
+				mu.Unlock()
+			}
+		}()
+	}
+
+	var pwg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		pwg.Add(1)
+		go func(p int) {
+			defer pwg.Done()
+			for i := 0; i < perProducer; i++ {
+				q.Put(p*perProducer + i)
+			}
+		}(p)
+	}
+	pwg.Wait()
+	q.Close()
+	cwg.Wait()
+
+	if len(taken) != producers*perProducer {
+		t.Fatalf("took %d items, want %d", len(taken), producers*perProducer)
+	}
+	sort.Ints(taken)
+	for i, v := range taken {
+		if v != i {
+			t.Fatalf("missing or duplicated item around %d", i)
+		}
+	}
+}
@@ -0,0 +1,316 @@
+package heap
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Clock 时间来源，测试中可以替换为 ManualClock 以获得确定性的结果
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// RealClock 使用系统时间的时钟
+var RealClock Clock = realClock{}
+
+// DelayHandle 延迟任务的句柄，用于取消
+type DelayHandle[T any] struct {
+	item       T
+	at         time.Time
+	seq        uint64 // 相同到期时间按入队顺序出队
+	expiration int64  // 时间轮模式下的到期刻度
+	state      handleState
+}
+
+type handleState int
+
+const (
+	handlePending handleState = iota
+	handleCancelled
+	handleDone
+)
+
+// At 返回任务的到期时间
+func (h *DelayHandle[T]) At() time.Time {
+	return h.at
+}
+
+// delayBackend 按到期时间组织任务的存储结构（由 DelayQueue 的锁保护）
+type delayBackend[T any] interface {
+	push(h *DelayHandle[T])
+	// popDue 弹出一个已到期的任务，已取消的任务由调用方跳过
+	popDue(now time.Time) (*DelayHandle[T], bool)
+	// nextWake 返回下一次可能有任务到期的时间
+	nextWake() (time.Time, bool)
+	// remove 删除已取消的任务，不支持随机删除的实现可以留到出队时跳过
+	remove(h *DelayHandle[T])
+}
+
+// DelayQueueOption 自定义 DelayQueue
+type DelayQueueOption func(o *delayQueueOptions)
+
+type delayQueueOptions struct {
+	clock     Clock
+	wheelTick time.Duration
+	wheelSize int
+}
+
+// WithClock 设置时钟，默认使用 RealClock
+func WithClock(clock Clock) DelayQueueOption {
+	return func(o *delayQueueOptions) {
+		o.clock = clock
+	}
+}
+
+// WithTimingWheel 使用分层时间轮代替堆，插入和取消为 O(1)，适合大量定时任务；
+// 到期精度为 tick，任务可能最多延迟一个 tick 出队。wheelSize 为每层的槽数
+func WithTimingWheel(tick time.Duration, wheelSize int) DelayQueueOption {
+	return func(o *delayQueueOptions) {
+		o.wheelTick = tick
+		o.wheelSize = wheelSize
+	}
+}
+
+// DelayQueue 延迟队列，任务到期后才能被 Poll 取出，可替代零散的 time.AfterFunc
+type DelayQueue[T any] struct {
+	mu      sync.Mutex
+	backend delayBackend[T]
+	clock   Clock
+	seq     uint64
+	live    int           // 未取消、未取出的任务数
+	wait    chan struct{} // 有新任务或关闭时关闭并替换，用于唤醒等待中的 Poll
+	closed  bool
+}
+
+// NewDelayQueue 创建延迟队列，默认基于堆实现
+func NewDelayQueue[T any](opts ...DelayQueueOption) *DelayQueue[T] {
+	o := delayQueueOptions{clock: RealClock}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	q := &DelayQueue[T]{
+		clock: o.clock,
+		wait:  make(chan struct{}),
+	}
+	if o.wheelTick > 0 {
+		q.backend = newTimingWheel[T](o.wheelTick, o.wheelSize, o.clock.Now())
+	} else {
+		q.backend = newDelayHeap[T]()
+	}
+	return q
+}
+
+// Offer 添加在 at 时刻到期的任务，队列已关闭时返回 ErrClosed
+func (q *DelayQueue[T]) Offer(item T, at time.Time) (*DelayHandle[T], error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return nil, ErrClosed
+	}
+	q.seq++
+	h := &DelayHandle[T]{item: item, at: at, seq: q.seq}
+	q.backend.push(h)
+	q.live++
+	q.broadcast()
+	return h, nil
+}
+
+// OfferAfter 添加在 d 之后到期的任务
+func (q *DelayQueue[T]) OfferAfter(item T, d time.Duration) (*DelayHandle[T], error) {
+	return q.Offer(item, q.clock.Now().Add(d))
+}
+
+// Cancel 取消任务，任务已被取出或已取消时返回 false
+func (q *DelayQueue[T]) Cancel(h *DelayHandle[T]) bool {
+	if h == nil {
+		return false
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if h.state != handlePending {
+		return false
+	}
+	h.state = handleCancelled
+	var zero T
+	h.item = zero // 时间轮中取消的任务在所在槽被处理前仍会保留，先释放引用
+	q.backend.remove(h)
+	q.live--
+	return true
+}
+
+// Poll 取出一个到期的任务，没有到期任务时阻塞直到有任务到期、ctx 结束或队列关闭
+func (q *DelayQueue[T]) Poll(ctx context.Context) (T, error) {
+	for {
+		q.mu.Lock()
+		if item, ok := q.popDueLocked(); ok {
+			q.mu.Unlock()
+			return item, nil
+		}
+		if q.closed {
+			q.mu.Unlock()
+			var zero T
+			return zero, ErrClosed
+		}
+		var timer <-chan time.Time
+		if wake, ok := q.backend.nextWake(); ok {
+			timer = q.clock.After(wake.Sub(q.clock.Now()))
+		}
+		wait := q.wait
+		q.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			var zero T
+			return zero, ctx.Err()
+		case <-wait:
+		case <-timer:
+		}
+	}
+}
+
+// TryPoll 非阻塞地取出一个到期的任务
+func (q *DelayQueue[T]) TryPoll() (T, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.popDueLocked()
+}
+
+// Len 返回未取消、未取出的任务数
+func (q *DelayQueue[T]) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.live
+}
+
+// Close 关闭队列，之后 Offer 返回 ErrClosed，Poll 立即返回 ErrClosed
+func (q *DelayQueue[T]) Close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return
+	}
+	q.closed = true
+	q.broadcast()
+}
+
+func (q *DelayQueue[T]) popDueLocked() (T, bool) {
+	now := q.clock.Now()
+	for {
+		h, ok := q.backend.popDue(now)
+		if !ok {
+			var zero T
+			return zero, false
+		}
+		if h.state != handlePending {
+			continue // 已取消
+		}
+		h.state = handleDone
+		q.live--
+		return h.item, true
+	}
+}
+
+// broadcast 唤醒所有等待者（由调用方持有锁）
+func (q *DelayQueue[T]) broadcast() {
+	close(q.wait)
+	q.wait = make(chan struct{})
+}
+
+// delayHeap 基于 IndexedHeap 的实现，按 (at, seq) 排序，取消时 O(log n) 删除
+type delayHeap[T any] struct {
+	heap *IndexedHeap[*DelayHandle[T], *DelayHandle[T]]
+}
+
+func newDelayHeap[T any]() *delayHeap[T] {
+	return &delayHeap[T]{
+		heap: NewIndexedHeap[*DelayHandle[T]](2, MinHeap(func(a, b *DelayHandle[T]) bool {
+			if a.at.Equal(b.at) {
+				return a.seq < b.seq
+			}
+			return a.at.Before(b.at)
+		})),
+	}
+}
+
+func (d *delayHeap[T]) push(h *DelayHandle[T]) {
+	_ = d.heap.Insert(h, h)
+}
+
+func (d *delayHeap[T]) popDue(now time.Time) (*DelayHandle[T], bool) {
+	_, top, err := d.heap.Peek()
+	if err != nil || top.at.After(now) {
+		return nil, false
+	}
+	d.heap.Pop()
+	return top, true
+}
+
+func (d *delayHeap[T]) nextWake() (time.Time, bool) {
+	_, top, err := d.heap.Peek()
+	if err != nil {
+		return time.Time{}, false
+	}
+	return top.at, true
+}
+
+func (d *delayHeap[T]) remove(h *DelayHandle[T]) {
+	d.heap.Remove(h)
+}
+
+// ManualClock 手动推进的时钟，用于测试
+type ManualClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []manualWaiter
+}
+
+type manualWaiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+// NewManualClock 创建从 now 开始的手动时钟
+func NewManualClock(now time.Time) *ManualClock {
+	return &ManualClock{now: now}
+}
+
+func (c *ManualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *ManualClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, manualWaiter{at: c.now.Add(d), ch: ch})
+	return ch
+}
+
+// Advance 推进时间，并触发所有到期的 After
+func (c *ManualClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	remaining := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.at.After(c.now) {
+			remaining = append(remaining, w)
+			continue
+		}
+		w.ch <- c.now
+	}
+	c.waiters = remaining
+}
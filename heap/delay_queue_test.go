@@ -0,0 +1,257 @@
+package heap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+)
+
+var testEpoch = time.Unix(1700000000, 0)
+
+// delayQueueVariants 返回堆和时间轮两种实现的构造选项
+func delayQueueVariants() map[string][]DelayQueueOption {
+	return map[string][]DelayQueueOption{
+		"heap":  nil,
+		"wheel": {WithTimingWheel(time.Millisecond, 8)},
+	}
+}
+
+func newTestDelayQueue(opts []DelayQueueOption) (*DelayQueue[string], *ManualClock) {
+	clock := NewManualClock(testEpoch)
+	return NewDelayQueue[string](append([]DelayQueueOption{WithClock(clock)}, opts...)...), clock
+}
+
+func TestDelayQueueOrdering(t *testing.T) {
+	for name, opts := range delayQueueVariants() {
+		t.Run(name, func(t *testing.T) {
+			q, clock := newTestDelayQueue(opts)
+			q.OfferAfter("c", 300*time.Millisecond)
+			q.OfferAfter("a", 100*time.Millisecond)
+			q.OfferAfter("b", 200*time.Millisecond)
+			q.OfferAfter("far", time.Hour)
+
+			if _, ok := q.TryPoll(); ok {
+				t.Fatal("TryPoll() should not return items before they are due")
+			}
+			clock.Advance(250 * time.Millisecond)
+			for _, want := range []string{"a", "b"} {
+				if got, ok := q.TryPoll(); !ok || got != want {
+					t.Fatalf("TryPoll() = %q %v, want %q", got, ok, want)
+				}
+			}
+			if _, ok := q.TryPoll(); ok {
+				t.Fatal("c should not be due yet")
+			}
+			clock.Advance(time.Hour)
+			for _, want := range []string{"c", "far"} {
+				if got, ok := q.TryPoll(); !ok || got != want {
+					t.Fatalf("TryPoll() = %q %v, want %q", got, ok, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDelayQueueCancel(t *testing.T) {
+	for name, opts := range delayQueueVariants() {
+		t.Run(name, func(t *testing.T) {
+			q, clock := newTestDelayQueue(opts)
+			h1, _ := q.OfferAfter("keep", time.Second)
+			h2, _ := q.OfferAfter("drop", time.Second)
+			if q.Len() != 2 {
+				t.Fatalf("Len() = %d, want 2", q.Len())
+			}
+			if !q.Cancel(h2) || q.Cancel(h2) {
+				t.Error("Cancel() should succeed exactly once")
+			}
+			if q.Len() != 1 {
+				t.Errorf("Len() after Cancel = %d, want 1", q.Len())
+			}
+
+			clock.Advance(time.Second)
+			if got, ok := q.TryPoll(); !ok || got != "keep" {
+				t.Errorf("TryPoll() = %q %v, want keep", got, ok)
+			}
+			if _, ok := q.TryPoll(); ok {
+				t.Error("cancelled item should never be polled")
+			}
+			if q.Cancel(h1) {
+				t.Error("Cancel() after poll should return false")
+			}
+		})
+	}
+}
+
+func TestDelayQueueCancelRemovesFromHeap(t *testing.T) {
+	q, _ := newTestDelayQueue(nil)
+	handles := make([]*DelayHandle[string], 1000)
+	for i := range handles {
+		handles[i], _ = q.OfferAfter(fmt.Sprint(i), time.Hour+time.Duration(i)*time.Second)
+	}
+	// 乱序取消，覆盖从堆中间删除的情况
+	for _, i := range rand.Perm(len(handles)) {
+		if !q.Cancel(handles[i]) {
+			t.Fatalf("Cancel(%d) failed", i)
+		}
+	}
+	backend := q.backend.(*delayHeap[string])
+	if q.Len() != 0 || backend.heap.Size() != 0 {
+		t.Errorf("Len() = %d, heap size = %d, want 0", q.Len(), backend.heap.Size())
+	}
+	if _, ok := backend.nextWake(); ok {
+		t.Error("nextWake() should report nothing after all items are cancelled")
+	}
+}
+
+func TestDelayQueuePollBlocks(t *testing.T) {
+	for name, opts := range delayQueueVariants() {
+		t.Run(name, func(t *testing.T) {
+			q, clock := newTestDelayQueue(opts)
+			got := make(chan string)
+			go func() {
+				v, _ := q.Poll(context.Background())
+				got <- v
+			}()
+
+			// Poll 在空队列上等待，新任务唤醒后改为等待其到期
+			time.Sleep(10 * time.Millisecond)
+			q.OfferAfter("job", 5*time.Second)
+			time.Sleep(10 * time.Millisecond)
+			select {
+			case v := <-got:
+				t.Fatalf("Poll() returned %q before the item was due", v)
+			default:
+			}
+
+			clock.Advance(5 * time.Second)
+			select {
+			case v := <-got:
+				if v != "job" {
+					t.Errorf("Poll() = %q, want job", v)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("Poll() was not woken when the item became due")
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			if _, err := q.Poll(ctx); !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Poll() with expired ctx = %v, want DeadlineExceeded", err)
+			}
+
+			q.Close()
+			if _, err := q.Poll(context.Background()); !errors.Is(err, ErrClosed) {
+				t.Errorf("Poll() after Close = %v, want ErrClosed", err)
+			}
+			if _, err := q.OfferAfter("late", 0); !errors.Is(err, ErrClosed) {
+				t.Errorf("Offer() after Close = %v, want ErrClosed", err)
+			}
+		})
+	}
+}
+
+func TestDelayQueueRealClock(t *testing.T) {
+	q := NewDelayQueue[int]()
+	start := time.Now()
+	q.OfferAfter(1, 30*time.Millisecond)
+	v, err := q.Poll(context.Background())
+	if err != nil || v != 1 {
+		t.Fatalf("Poll() = %d %v", v, err)
+	}
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Errorf("Poll() returned after %v, before the deadline", elapsed)
+	}
+}
+
+// 时间轮与堆在大量随机任务下出队结果一致（按 tick 精度）
+func TestTimingWheelMatchesHeap(t *testing.T) {
+	clock := NewManualClock(testEpoch)
+	wheel := NewDelayQueue[string](WithClock(clock), WithTimingWheel(time.Millisecond, 4))
+	heapQ := NewDelayQueue[string](WithClock(clock))
+
+	type job struct {
+		name string
+		at   time.Duration
+	}
+	var jobs []job
+	var handles []*DelayHandle[string]
+	for i := 0; i < 2000; i++ {
+		j := job{name: fmt.Sprintf("job-%d", i), at: time.Duration(rand.Intn(100000)) * time.Millisecond}
+		h, _ := wheel.Offer(j.name, testEpoch.Add(j.at))
+		hh, _ := heapQ.Offer(j.name, testEpoch.Add(j.at))
+		jobs = append(jobs, j)
+		handles = append(handles, h, hh)
+	}
+	cancelled := map[string]bool{}
+	for i := 0; i < 200; i++ {
+		k := rand.Intn(len(jobs))
+		wheel.Cancel(handles[2*k])
+		heapQ.Cancel(handles[2*k+1])
+		cancelled[jobs[k].name] = true
+	}
+
+	polled := map[string]time.Duration{}
+	heapPolled := map[string]time.Duration{}
+	drain := func() {
+		now := clock.Now().Sub(testEpoch)
+		for name, ok := wheel.TryPoll(); ok; name, ok = wheel.TryPoll() {
+			polled[name] = now
+		}
+		for name, ok := heapQ.TryPoll(); ok; name, ok = heapQ.TryPoll() {
+			heapPolled[name] = now
+		}
+	}
+	for step := 0; step < 120; step++ {
+		clock.Advance(time.Duration(rand.Intn(2000)) * time.Millisecond)
+		drain()
+	}
+	clock.Advance(200 * time.Second)
+	drain()
+
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].at < jobs[j].at })
+	for _, j := range jobs {
+		at, ok := polled[j.name]
+		if cancelled[j.name] {
+			if ok {
+				t.Fatalf("cancelled %s was polled", j.name)
+			}
+			continue
+		}
+		if !ok {
+			t.Fatalf("%s was never polled", j.name)
+		}
+		if at < j.at {
+			t.Fatalf("%s polled at %v, before its deadline %v", j.name, at, j.at)
+		}
+		// 到期时间是整毫秒，tick 为 1ms 时两种实现应在同一次推进后出队
+		if heapPolled[j.name] != at {
+			t.Fatalf("%s polled at %v by wheel but %v by heap", j.name, at, heapPolled[j.name])
+		}
+	}
+	if wheel.Len() != 0 {
+		t.Errorf("Len() = %d, want 0", wheel.Len())
+	}
+}
+
+func BenchmarkDelayQueueOffer(b *testing.B) {
+	for _, bc := range []struct {
+		name string
+		opts []DelayQueueOption
+	}{
+		{"heap", nil},
+		{"wheel", []DelayQueueOption{WithTimingWheel(time.Millisecond, 64)}},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			q := NewDelayQueue[int](bc.opts...)
+			now := time.Now()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				q.Offer(i, now.Add(time.Duration(i%3600000)*time.Millisecond))
+			}
+		})
+	}
+}
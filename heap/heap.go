@@ -2,6 +2,16 @@ package heap
 
 import "errors"
 
+var (
+	ErrEmpty     = errors.New("堆为空")
+	ErrFull      = errors.New("堆已满")
+	ErrKeyExists = errors.New("键已存在")
+	ErrClosed    = errors.New("队列已关闭")
+
+	ErrInvalidHandle    = errors.New("节点不属于该堆")
+	ErrPriorityDecrease = errors.New("新值的优先级低于原值")
+)
+
 // Comparable 定义了一个比较函数类型
 type Comparable[T any] func(a, b T) int
 
@@ -9,7 +19,9 @@ type Comparable[T any] func(a, b T) int
 type Heap[T any] struct {
 	HPDate    []T
 	heapSize  int           // 堆的大小
-	capacity  int           // 堆的容量
+	capacity  int           // 堆的容量（自动扩容模式下为初始容量）
+	growable  bool          // 是否自动扩容
+	maxSize   int           // 自动扩容模式下的上限，<= 0 表示不限制
 	compareFn Comparable[T] // 比较函数
 }
 
@@ -23,6 +35,16 @@ func NewHeap[T any](capacity int, compareFn Comparable[T]) *Heap[T] {
 	}
 }
 
+// NewGrowableHeap 创建自动扩容的堆，maxSize <= 0 表示不限制大小，
+// 否则元素个数达到 maxSize 后 TryInsert 返回 ErrFull
+func NewGrowableHeap[T any](maxSize int, compareFn Comparable[T]) *Heap[T] {
+	return &Heap[T]{
+		growable:  true,
+		maxSize:   maxSize,
+		compareFn: compareFn,
+	}
+}
+
 // MinHeap 创建小根堆的比较函数
 func MinHeap[T any](less func(a, b T) bool) Comparable[T] {
 	return func(a, b T) int {
@@ -82,25 +104,39 @@ func (h *Heap[T]) UpAdjust(childIndex *int) {
 	}
 }
 
-// Insert 插入元素
+// Insert 插入元素，堆已满时丢弃该元素，需要感知丢弃时使用 TryInsert
 func (h *Heap[T]) Insert(value T) {
-	if h.heapSize >= h.capacity {
-		return // 堆已满
+	_ = h.TryInsert(value)
+}
+
+// TryInsert 插入元素，堆已满时返回 ErrFull
+func (h *Heap[T]) TryInsert(value T) error {
+	if h.growable && h.maxSize > 0 && h.heapSize >= h.maxSize {
+		return ErrFull
+	}
+	if h.heapSize >= len(h.HPDate) {
+		if !h.growable {
+			return ErrFull
+		}
+		h.HPDate = append(h.HPDate[:h.heapSize], value)
+		h.HPDate = h.HPDate[:cap(h.HPDate)]
 	}
 	h.HPDate[h.heapSize] = value
 	childIndex := h.heapSize
 	h.heapSize++
 	h.UpAdjust(&childIndex)
+	return nil
 }
 
 // Pop 弹出堆顶元素
 func (h *Heap[T]) Pop() (T, error) {
 	var zero T
 	if h.heapSize == 0 {
-		return zero, errors.New("栈为空") // 堆为空
+		return zero, ErrEmpty
 	}
 	top := h.HPDate[0]
 	h.HPDate[0] = h.HPDate[h.heapSize-1]
+	h.HPDate[h.heapSize-1] = zero // 避免持有已弹出元素的引用
 	h.heapSize--
 	parentIndex := 0
 	h.DownAdjust(&parentIndex)
@@ -111,7 +147,7 @@ func (h *Heap[T]) Pop() (T, error) {
 func (h *Heap[T]) Peek() (T, error) {
 	var zero T
 	if h.heapSize == 0 {
-		return zero, errors.New("栈为空") // 堆为空
+		return zero, ErrEmpty
 	}
 	return h.HPDate[0], nil // 返回堆顶元素
 }
@@ -199,11 +235,28 @@ func NewPriorityQueue[T any](capacity int, compareFn Comparable[T]) *PriorityQue
 	}
 }
 
+// NewGrowablePriorityQueue 创建自动扩容的优先队列，maxSize <= 0 表示不限制大小
+func NewGrowablePriorityQueue[T any](maxSize int, compareFn Comparable[T]) *PriorityQueue[T] {
+	return &PriorityQueue[T]{
+		heap: NewGrowableHeap[T](maxSize, compareFn),
+	}
+}
+
 // Enqueue 入队
 func (pq *PriorityQueue[T]) Enqueue(value T) {
 	pq.heap.Insert(value)
 }
 
+// TryEnqueue 入队，队列已满时返回 ErrFull
+func (pq *PriorityQueue[T]) TryEnqueue(value T) error {
+	return pq.heap.TryInsert(value)
+}
+
+// Len 返回队列中的元素个数
+func (pq *PriorityQueue[T]) Len() int {
+	return pq.heap.Size()
+}
+
 // Dequeue 出队
 func (pq *PriorityQueue[T]) Dequeue() (T, error) {
 	return pq.heap.Pop()
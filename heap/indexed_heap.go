@@ -0,0 +1,191 @@
+package heap
+
+// IndexedHeap 带键索引的 d 叉堆，记录每个键在数组中的位置，
+// 因此 Update/Remove/Contains 可以在 O(log n) 内完成，适合 Dijkstra、定时调度等需要修改优先级的场景
+type IndexedHeap[K comparable, T any] struct {
+	keys      []K
+	values    []T
+	pos       map[K]int     // 键到数组下标的映射
+	d         int           // 每个节点的子节点数
+	compareFn Comparable[T] // 比较函数
+}
+
+// NewIndexedHeap 创建 d 叉索引堆，常用 d 为 2/4/8，d < 2 时按 2 处理
+func NewIndexedHeap[K comparable, T any](d int, compareFn Comparable[T]) *IndexedHeap[K, T] {
+	if d < 2 {
+		d = 2
+	}
+	return &IndexedHeap[K, T]{
+		pos:       make(map[K]int),
+		d:         d,
+		compareFn: compareFn,
+	}
+}
+
+// Insert 插入键值，键已存在时返回 ErrKeyExists
+func (h *IndexedHeap[K, T]) Insert(key K, value T) error {
+	if _, ok := h.pos[key]; ok {
+		return ErrKeyExists
+	}
+	h.keys = append(h.keys, key)
+	h.values = append(h.values, value)
+	i := len(h.keys) - 1
+	h.pos[key] = i
+	h.up(i)
+	return nil
+}
+
+// Update 修改键对应的值并调整位置，键不存在时返回 false
+func (h *IndexedHeap[K, T]) Update(key K, value T) bool {
+	i, ok := h.pos[key]
+	if !ok {
+		return false
+	}
+	h.values[i] = value
+	h.fix(i)
+	return true
+}
+
+// Upsert 键存在时更新，不存在时插入
+func (h *IndexedHeap[K, T]) Upsert(key K, value T) {
+	if !h.Update(key, value) {
+		_ = h.Insert(key, value)
+	}
+}
+
+// Remove 删除指定键并返回其值
+func (h *IndexedHeap[K, T]) Remove(key K) (T, bool) {
+	i, ok := h.pos[key]
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	return h.removeAt(i), true
+}
+
+// Contains 判断键是否在堆中
+func (h *IndexedHeap[K, T]) Contains(key K) bool {
+	_, ok := h.pos[key]
+	return ok
+}
+
+// Get 返回键对应的值
+func (h *IndexedHeap[K, T]) Get(key K) (T, bool) {
+	i, ok := h.pos[key]
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	return h.values[i], true
+}
+
+// Peek 获取堆顶的键值
+func (h *IndexedHeap[K, T]) Peek() (K, T, error) {
+	if len(h.keys) == 0 {
+		var zeroK K
+		var zeroT T
+		return zeroK, zeroT, ErrEmpty
+	}
+	return h.keys[0], h.values[0], nil
+}
+
+// Pop 弹出堆顶的键值
+func (h *IndexedHeap[K, T]) Pop() (K, T, error) {
+	if len(h.keys) == 0 {
+		var zeroK K
+		var zeroT T
+		return zeroK, zeroT, ErrEmpty
+	}
+	key := h.keys[0]
+	return key, h.removeAt(0), nil
+}
+
+// Size 获取堆的大小
+func (h *IndexedHeap[K, T]) Size() int {
+	return len(h.keys)
+}
+
+// IsEmpty 检查堆是否为空
+func (h *IndexedHeap[K, T]) IsEmpty() bool {
+	return len(h.keys) == 0
+}
+
+// Clear 清空堆
+func (h *IndexedHeap[K, T]) Clear() {
+	h.keys = nil
+	h.values = nil
+	h.pos = make(map[K]int)
+}
+
+func (h *IndexedHeap[K, T]) removeAt(i int) T {
+	last := len(h.keys) - 1
+	value := h.values[i]
+	delete(h.pos, h.keys[i])
+	if i != last {
+		h.move(last, i)
+	}
+	var zeroK K
+	var zeroT T
+	h.keys[last], h.values[last] = zeroK, zeroT // 避免持有已删除元素的引用
+	h.keys, h.values = h.keys[:last], h.values[:last]
+	if i != last {
+		h.fix(i)
+	}
+	return value
+}
+
+// fix 元素值变化后向上或向下调整
+func (h *IndexedHeap[K, T]) fix(i int) {
+	if !h.up(i) {
+		h.down(i)
+	}
+}
+
+// up 向上调整，返回是否发生了移动
+func (h *IndexedHeap[K, T]) up(i int) bool {
+	key, value := h.keys[i], h.values[i]
+	start := i
+	for i > 0 {
+		parent := (i - 1) / h.d
+		if h.compareFn(h.values[parent], value) <= 0 {
+			break
+		}
+		h.move(parent, i)
+		i = parent
+	}
+	h.set(i, key, value)
+	return i != start
+}
+
+// down 向下调整，选择 d 个子节点中最优先的一个交换
+func (h *IndexedHeap[K, T]) down(i int) {
+	key, value := h.keys[i], h.values[i]
+	n := len(h.keys)
+	for {
+		first := h.d*i + 1
+		if first >= n {
+			break
+		}
+		best := first
+		for c := first + 1; c < first+h.d && c < n; c++ {
+			if h.compareFn(h.values[c], h.values[best]) < 0 {
+				best = c
+			}
+		}
+		if h.compareFn(value, h.values[best]) <= 0 {
+			break
+		}
+		h.move(best, i)
+		i = best
+	}
+	h.set(i, key, value)
+}
+
+func (h *IndexedHeap[K, T]) move(from, to int) {
+	h.set(to, h.keys[from], h.values[from])
+}
+
+func (h *IndexedHeap[K, T]) set(i int, key K, value T) {
+	h.keys[i], h.values[i] = key, value
+	h.pos[key] = i
+}
@@ -0,0 +1,182 @@
+package heap
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// checkIndexedHeap 校验堆性质以及位置索引是否一致
+func checkIndexedHeap[K comparable, T any](t *testing.T, h *IndexedHeap[K, T]) {
+	t.Helper()
+	if len(h.pos) != len(h.keys) {
+		t.Fatalf("pos size %d != heap size %d", len(h.pos), len(h.keys))
+	}
+	for i, k := range h.keys {
+		if h.pos[k] != i {
+			t.Fatalf("pos[%v] = %d, want %d", k, h.pos[k], i)
+		}
+		if i > 0 && h.compareFn(h.values[(i-1)/h.d], h.values[i]) > 0 {
+			t.Fatalf("heap property violated at %d", i)
+		}
+	}
+}
+
+func TestIndexedHeapBasic(t *testing.T) {
+	h := NewIndexedHeap[string, int](2, MinHeap(intLess))
+	if _, _, err := h.Pop(); err != ErrEmpty {
+		t.Errorf("Pop() on empty heap err = %v, want ErrEmpty", err)
+	}
+
+	h.Insert("a", 5)
+	h.Insert("b", 3)
+	h.Insert("c", 8)
+	if err := h.Insert("a", 1); err != ErrKeyExists {
+		t.Errorf("Insert() duplicate err = %v, want ErrKeyExists", err)
+	}
+	if k, v, _ := h.Peek(); k != "b" || v != 3 {
+		t.Errorf("Peek() = %s:%d, want b:3", k, v)
+	}
+
+	// DecreaseKey
+	if !h.Update("c", 1) {
+		t.Fatal("Update() existing key returned false")
+	}
+	if k, _, _ := h.Peek(); k != "c" {
+		t.Errorf("Peek() after decrease = %s, want c", k)
+	}
+	// IncreaseKey
+	h.Update("c", 10)
+	if k, _, _ := h.Peek(); k != "b" {
+		t.Errorf("Peek() after increase = %s, want b", k)
+	}
+	if h.Update("x", 1) {
+		t.Error("Update() missing key returned true")
+	}
+
+	if v, ok := h.Remove("b"); !ok || v != 3 {
+		t.Errorf("Remove(b) = %d %v, want 3 true", v, ok)
+	}
+	if h.Contains("b") {
+		t.Error("Contains(b) after Remove should be false")
+	}
+	h.Upsert("d", 7)
+	h.Upsert("a", 9)
+
+	var got []string
+	for !h.IsEmpty() {
+		k, _, _ := h.Pop()
+		got = append(got, k)
+	}
+	want := []string{"d", "a", "c"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Pop order = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestIndexedHeapRandomOps(t *testing.T) {
+	for _, d := range []int{2, 4, 8} {
+		h := NewIndexedHeap[int, int](d, MaxHeap(intLess))
+		ref := map[int]int{}
+		for i := 0; i < 2000; i++ {
+			key := rand.Intn(200)
+			switch rand.Intn(4) {
+			case 0, 1:
+				v := rand.Intn(1000)
+				h.Upsert(key, v)
+				ref[key] = v
+			case 2:
+				_, ok := h.Remove(key)
+				_, want := ref[key]
+				if ok != want {
+					t.Fatalf("d=%d Remove(%d) = %v, want %v", d, key, ok, want)
+				}
+				delete(ref, key)
+			case 3:
+				if h.IsEmpty() {
+					continue
+				}
+				k, v, _ := h.Pop()
+				for _, rv := range ref {
+					if rv > v {
+						t.Fatalf("d=%d Pop() = %d, but %d still in heap", d, v, rv)
+					}
+				}
+				delete(ref, k)
+			}
+			checkIndexedHeap(t, h)
+		}
+		if h.Size() != len(ref) {
+			t.Fatalf("d=%d Size() = %d, want %d", d, h.Size(), len(ref))
+		}
+
+		var values []int
+		for _, v := range ref {
+			values = append(values, v)
+		}
+		sort.Sort(sort.Reverse(sort.IntSlice(values)))
+		for _, want := range values {
+			_, v, _ := h.Pop()
+			if v != want {
+				t.Fatalf("d=%d drain Pop() = %d, want %d", d, v, want)
+			}
+		}
+		h.Clear()
+		if !h.IsEmpty() {
+			t.Errorf("d=%d Clear() left %d elements", d, h.Size())
+		}
+	}
+}
+
+type edge struct {
+	to     int
+	weight int
+}
+
+func dijkstra(graph [][]edge, src int, d int) []int {
+	dist := make([]int, len(graph))
+	for i := range dist {
+		dist[i] = math.MaxInt
+	}
+	dist[src] = 0
+	h := NewIndexedHeap[int, int](d, MinHeap(intLess))
+	h.Insert(src, 0)
+	for !h.IsEmpty() {
+		u, du, _ := h.Pop()
+		for _, e := range graph[u] {
+			if nd := du + e.weight; nd < dist[e.to] {
+				dist[e.to] = nd
+				h.Upsert(e.to, nd)
+			}
+		}
+	}
+	return dist
+}
+
+func TestIndexedHeapDijkstra(t *testing.T) {
+	//   0 --4--> 1 --1--> 3
+	//   |        ^        |
+	//   1        2        5
+	//   v        |        v
+	//   2 -------+        4
+	graph := [][]edge{
+		{{1, 4}, {2, 1}},
+		{{3, 1}},
+		{{1, 2}},
+		{{4, 5}},
+		{},
+	}
+	want := []int{0, 3, 1, 4, 9}
+	for _, d := range []int{2, 4, 8} {
+		got := dijkstra(graph, 0, d)
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("d=%d dijkstra() = %v, want %v", d, got, want)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package heap
+
+import "iter"
+
+// mergeCursor 某一路输入的当前元素
+type mergeCursor[T any] struct {
+	value T
+	src   int
+}
+
+// MergeSorted 惰性地归并多路有序输入，每一路须已按 compareFn 排好序（与 HeapSorted 的输出顺序一致）。
+// 内部只保存每一路的当前元素，内存占用为 O(N)，N 为输入路数；相等元素按输入的先后顺序输出。
+// 调用方提前结束遍历时会停止所有输入
+func MergeSorted[T any](compareFn Comparable[T], seqs ...iter.Seq[T]) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		nexts := make([]func() (T, bool), len(seqs))
+		for i, seq := range seqs {
+			next, stop := iter.Pull(seq)
+			defer stop()
+			nexts[i] = next
+		}
+
+		h := NewHeap[mergeCursor[T]](len(seqs), func(a, b mergeCursor[T]) int {
+			if c := compareFn(a.value, b.value); c != 0 {
+				return c
+			}
+			return a.src - b.src
+		})
+		for i, next := range nexts {
+			if v, ok := next(); ok {
+				h.Insert(mergeCursor[T]{value: v, src: i})
+			}
+		}
+
+		for !h.IsEmpty() {
+			top := h.HPDate[0]
+			if !yield(top.value) {
+				return
+			}
+			// 同一路的下一个元素直接替换堆顶，该路结束时才弹出
+			if v, ok := nexts[top.src](); ok {
+				h.HPDate[0] = mergeCursor[T]{value: v, src: top.src}
+				parentIndex := 0
+				h.DownAdjust(&parentIndex)
+			} else {
+				h.Pop()
+			}
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package heap
+
+// PairingNode 配对堆中的节点，作为 DecreaseKey/Remove 的句柄
+type PairingNode[T any] struct {
+	Value   T
+	child   *PairingNode[T] // 最左子节点
+	sibling *PairingNode[T] // 右兄弟
+	prev    *PairingNode[T] // 左兄弟，最左子节点指向父节点
+	owner   *pairingOwner   // 所属堆，Meld 后通过并查集指向合并后的堆
+}
+
+// pairingOwner 标识一个堆，Meld 时把被合并堆的 owner 指向目标堆，使句柄归属的更新为 O(1)
+type pairingOwner struct {
+	parent *pairingOwner
+}
+
+func (o *pairingOwner) find() *pairingOwner {
+	for o.parent != nil {
+		if o.parent.parent != nil {
+			o.parent = o.parent.parent // 路径压缩
+		}
+		o = o.parent
+	}
+	return o
+}
+
+// PairingHeap 配对堆，Insert/Meld 为 O(1)，Pop 均摊 O(log n)，DecreaseKey 均摊 o(log n)。
+// 适合需要频繁合并或修改优先级的场景，例如分片结果的 k 路归并和 Dijkstra
+type PairingHeap[T any] struct {
+	root      *PairingNode[T]
+	size      int
+	owner     *pairingOwner
+	compareFn Comparable[T] // 比较函数
+}
+
+// NewPairingHeap 创建配对堆，比较函数同样使用 MinHeap/MaxHeap 构造
+func NewPairingHeap[T any](compareFn Comparable[T]) *PairingHeap[T] {
+	return &PairingHeap[T]{
+		owner:     &pairingOwner{},
+		compareFn: compareFn,
+	}
+}
+
+// Insert 插入元素并返回节点句柄
+func (h *PairingHeap[T]) Insert(value T) *PairingNode[T] {
+	n := &PairingNode[T]{Value: value, owner: h.owner}
+	h.root = h.link(h.root, n)
+	h.size++
+	return n
+}
+
+// Meld 把 other 的全部元素合并进 h，other 变为空堆，other 的节点句柄此后属于 h
+func (h *PairingHeap[T]) Meld(other *PairingHeap[T]) {
+	if other == nil || other == h || other.root == nil {
+		return
+	}
+	h.root = h.link(h.root, other.root)
+	h.size += other.size
+	other.owner.parent = h.owner
+	other.owner = &pairingOwner{}
+	other.root = nil
+	other.size = 0
+}
+
+// Peek 获取堆顶元素
+func (h *PairingHeap[T]) Peek() (T, error) {
+	if h.root == nil {
+		var zero T
+		return zero, ErrEmpty
+	}
+	return h.root.Value, nil
+}
+
+// Pop 弹出堆顶元素
+func (h *PairingHeap[T]) Pop() (T, error) {
+	if h.root == nil {
+		var zero T
+		return zero, ErrEmpty
+	}
+	top := h.root
+	h.root = h.mergePairs(top.child)
+	if h.root != nil {
+		h.root.prev = nil
+	}
+	h.size--
+	top.child, top.owner = nil, nil
+	return top.Value, nil
+}
+
+// DecreaseKey 提高节点的优先级（小根堆中减小、大根堆中增大），
+// 节点不属于该堆时返回 ErrInvalidHandle，新值优先级更低时返回 ErrPriorityDecrease
+func (h *PairingHeap[T]) DecreaseKey(n *PairingNode[T], value T) error {
+	if !h.owns(n) {
+		return ErrInvalidHandle
+	}
+	if h.compareFn(value, n.Value) > 0 {
+		return ErrPriorityDecrease
+	}
+	n.Value = value
+	if n == h.root {
+		return nil
+	}
+	h.cut(n)
+	h.root = h.link(h.root, n)
+	return nil
+}
+
+// Remove 删除任意节点，节点不属于该堆时返回 ErrInvalidHandle
+func (h *PairingHeap[T]) Remove(n *PairingNode[T]) error {
+	if !h.owns(n) {
+		return ErrInvalidHandle
+	}
+	if n == h.root {
+		_, err := h.Pop()
+		return err
+	}
+	h.cut(n)
+	sub := h.mergePairs(n.child)
+	if sub != nil {
+		sub.prev = nil
+		h.root = h.link(h.root, sub)
+	}
+	n.child, n.owner = nil, nil
+	h.size--
+	return nil
+}
+
+// Contains 判断节点是否仍在堆中
+func (h *PairingHeap[T]) Contains(n *PairingNode[T]) bool {
+	return h.owns(n)
+}
+
+// Size 获取堆的大小
+func (h *PairingHeap[T]) Size() int {
+	return h.size
+}
+
+// IsEmpty 检查堆是否为空
+func (h *PairingHeap[T]) IsEmpty() bool {
+	return h.root == nil
+}
+
+func (h *PairingHeap[T]) owns(n *PairingNode[T]) bool {
+	return n != nil && n.owner != nil && n.owner.find() == h.owner
+}
+
+// link 合并两棵树，优先级低的根成为另一个根的最左子节点
+func (h *PairingHeap[T]) link(a, b *PairingNode[T]) *PairingNode[T] {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	if h.compareFn(b.Value, a.Value) < 0 {
+		a, b = b, a
+	}
+	b.prev = a
+	b.sibling = a.child
+	if a.child != nil {
+		a.child.prev = b
+	}
+	a.child = b
+	a.sibling = nil
+	return a
+}
+
+// cut 把以 n 为根的子树从树中摘下
+func (h *PairingHeap[T]) cut(n *PairingNode[T]) {
+	if n.prev.child == n {
+		n.prev.child = n.sibling // n 是最左子节点，prev 为父节点
+	} else {
+		n.prev.sibling = n.sibling
+	}
+	if n.sibling != nil {
+		n.sibling.prev = n.prev
+	}
+	n.prev, n.sibling = nil, nil
+}
+
+// mergePairs 两趟合并：从左到右两两合并，再从右到左依次合并
+func (h *PairingHeap[T]) mergePairs(first *PairingNode[T]) *PairingNode[T] {
+	if first == nil {
+		return nil
+	}
+	var pairs []*PairingNode[T]
+	for first != nil {
+		a := first
+		b := a.sibling
+		if b == nil {
+			a.prev, a.sibling = nil, nil
+			pairs = append(pairs, a)
+			break
+		}
+		first = b.sibling
+		a.prev, a.sibling = nil, nil
+		b.prev, b.sibling = nil, nil
+		pairs = append(pairs, h.link(a, b))
+	}
+	root := pairs[len(pairs)-1]
+	for i := len(pairs) - 2; i >= 0; i-- {
+		root = h.link(pairs[i], root)
+	}
+	return root
+}
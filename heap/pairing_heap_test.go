@@ -0,0 +1,261 @@
+package heap
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestPairingHeapBasic(t *testing.T) {
+	h := NewPairingHeap[int](MinHeap(intLess))
+	if _, err := h.Pop(); !errors.Is(err, ErrEmpty) {
+		t.Errorf("Pop() on empty heap = %v, want ErrEmpty", err)
+	}
+	nodes := map[int]*PairingNode[int]{}
+	for _, v := range []int{5, 3, 8, 1, 9, 7} {
+		nodes[v] = h.Insert(v)
+	}
+	if top, _ := h.Peek(); top != 1 {
+		t.Errorf("Peek() = %d, want 1", top)
+	}
+
+	if err := h.DecreaseKey(nodes[9], 0); err != nil {
+		t.Fatalf("DecreaseKey() = %v", err)
+	}
+	if top, _ := h.Peek(); top != 0 {
+		t.Errorf("Peek() after DecreaseKey = %d, want 0", top)
+	}
+	if err := h.DecreaseKey(nodes[8], 100); !errors.Is(err, ErrPriorityDecrease) {
+		t.Errorf("DecreaseKey() to a larger value = %v, want ErrPriorityDecrease", err)
+	}
+	if err := h.Remove(nodes[5]); err != nil {
+		t.Fatalf("Remove() = %v", err)
+	}
+	if h.Contains(nodes[5]) || h.Remove(nodes[5]) == nil {
+		t.Error("removed node should no longer belong to the heap")
+	}
+
+	var got []int
+	for !h.IsEmpty() {
+		v, _ := h.Pop()
+		got = append(got, v)
+	}
+	want := []int{0, 1, 3, 7, 8}
+	if len(got) != len(want) {
+		t.Fatalf("popped %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("popped %v, want %v", got, want)
+		}
+	}
+	if err := h.DecreaseKey(nodes[3], -1); !errors.Is(err, ErrInvalidHandle) {
+		t.Errorf("DecreaseKey() on popped node = %v, want ErrInvalidHandle", err)
+	}
+}
+
+func TestPairingHeapMeld(t *testing.T) {
+	a := NewPairingHeap[int](MaxHeap(intLess))
+	b := NewPairingHeap[int](MaxHeap(intLess))
+	a.Insert(1)
+	a.Insert(4)
+	nb := b.Insert(2)
+	b.Insert(3)
+
+	a.Meld(b)
+	if a.Size() != 4 || !b.IsEmpty() || b.Size() != 0 {
+		t.Fatalf("after Meld sizes = %d/%d, want 4/0", a.Size(), b.Size())
+	}
+	// b 的句柄归属 a
+	if b.Contains(nb) || !a.Contains(nb) {
+		t.Fatal("handles of the melded heap should belong to the target heap")
+	}
+	if err := a.DecreaseKey(nb, 10); err != nil {
+		t.Fatalf("DecreaseKey() on melded handle = %v", err)
+	}
+	if top, _ := a.Peek(); top != 10 {
+		t.Errorf("Peek() = %d, want 10", top)
+	}
+
+	// 合并后的空堆仍可继续使用
+	b.Insert(7)
+	c := NewPairingHeap[int](MaxHeap(intLess))
+	c.Meld(b)
+	a.Meld(c)
+	for _, want := range []int{10, 7, 4, 3, 1} {
+		if got, _ := a.Pop(); got != want {
+			t.Fatalf("Pop() = %d, want %d", got, want)
+		}
+	}
+}
+
+func TestPairingHeapRandomOps(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	h := NewPairingHeap[int](MinHeap(intLess))
+	live := map[*PairingNode[int]]bool{}
+	pick := func() *PairingNode[int] {
+		for n := range live {
+			return n
+		}
+		return nil
+	}
+	for i := 0; i < 20000; i++ {
+		switch op := r.Intn(10); {
+		case op < 4:
+			live[h.Insert(r.Intn(1000))] = true
+		case op < 6 && len(live) > 0:
+			n := pick()
+			if err := h.DecreaseKey(n, n.Value-r.Intn(50)); err != nil {
+				t.Fatalf("DecreaseKey() = %v", err)
+			}
+		case op < 7 && len(live) > 0:
+			n := pick()
+			if err := h.Remove(n); err != nil {
+				t.Fatalf("Remove() = %v", err)
+			}
+			delete(live, n)
+		case len(live) > 0:
+			want := math.MaxInt
+			for n := range live {
+				want = min(want, n.Value)
+			}
+			got, err := h.Pop()
+			if err != nil || got != want {
+				t.Fatalf("Pop() = %d %v, want %d", got, err, want)
+			}
+			for n := range live {
+				if !h.Contains(n) {
+					delete(live, n)
+				}
+			}
+		}
+		if h.Size() != len(live) {
+			t.Fatalf("Size() = %d, want %d", h.Size(), len(live))
+		}
+	}
+
+	var rest []int
+	for n := range live {
+		rest = append(rest, n.Value)
+	}
+	sort.Ints(rest)
+	for _, want := range rest {
+		if got, _ := h.Pop(); got != want {
+			t.Fatalf("Pop() = %d, want %d", got, want)
+		}
+	}
+}
+
+func pairingDijkstra(graph [][]edge, src int) []int {
+	dist := make([]int, len(graph))
+	nodes := make([]*PairingNode[[2]int], len(graph))
+	for i := range dist {
+		dist[i] = math.MaxInt
+	}
+	dist[src] = 0
+	h := NewPairingHeap[[2]int](MinHeap(func(a, b [2]int) bool { return a[0] < b[0] }))
+	nodes[src] = h.Insert([2]int{0, src})
+	for !h.IsEmpty() {
+		top, _ := h.Pop()
+		du, u := top[0], top[1]
+		for _, e := range graph[u] {
+			if nd := du + e.weight; nd < dist[e.to] {
+				dist[e.to] = nd
+				if n := nodes[e.to]; h.Contains(n) {
+					h.DecreaseKey(n, [2]int{nd, e.to})
+				} else {
+					nodes[e.to] = h.Insert([2]int{nd, e.to})
+				}
+			}
+		}
+	}
+	return dist
+}
+
+// lazyDijkstra 使用普通 Heap，不支持 DecreaseKey，只能重复插入并跳过过期项
+func lazyDijkstra(graph [][]edge, src int) []int {
+	dist := make([]int, len(graph))
+	for i := range dist {
+		dist[i] = math.MaxInt
+	}
+	dist[src] = 0
+	h := NewGrowableHeap[[2]int](0, MinHeap(func(a, b [2]int) bool { return a[0] < b[0] }))
+	h.Insert([2]int{0, src})
+	for !h.IsEmpty() {
+		top, _ := h.Pop()
+		du, u := top[0], top[1]
+		if du > dist[u] {
+			continue
+		}
+		for _, e := range graph[u] {
+			if nd := du + e.weight; nd < dist[e.to] {
+				dist[e.to] = nd
+				h.Insert([2]int{nd, e.to})
+			}
+		}
+	}
+	return dist
+}
+
+func randomGraph(n, degree int, seed int64) [][]edge {
+	r := rand.New(rand.NewSource(seed))
+	graph := make([][]edge, n)
+	for u := range graph {
+		for i := 0; i < degree; i++ {
+			graph[u] = append(graph[u], edge{to: r.Intn(n), weight: 1 + r.Intn(100)})
+		}
+	}
+	return graph
+}
+
+func TestPairingHeapDijkstra(t *testing.T) {
+	graph := randomGraph(2000, 8, 42)
+	want := lazyDijkstra(graph, 0)
+	for name, got := range map[string][]int{
+		"pairing": pairingDijkstra(graph, 0),
+		"indexed": dijkstra(graph, 0, 4),
+	} {
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("%s dist[%d] = %d, want %d", name, i, got[i], want[i])
+			}
+		}
+	}
+}
+
+func BenchmarkDijkstra(b *testing.B) {
+	graph := randomGraph(10000, 16, 7)
+	b.Run("pairing", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			pairingDijkstra(graph, 0)
+		}
+	})
+	b.Run("indexed-4", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			dijkstra(graph, 0, 4)
+		}
+	})
+	b.Run("heap-lazy", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			lazyDijkstra(graph, 0)
+		}
+	})
+}
+
+func BenchmarkPairingHeapMeld(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		h := NewPairingHeap[int](MinHeap(intLess))
+		for j := 0; j < 64; j++ {
+			o := NewPairingHeap[int](MinHeap(intLess))
+			for k := 0; k < 16; k++ {
+				o.Insert(j*16 + k)
+			}
+			h.Meld(o)
+		}
+		for !h.IsEmpty() {
+			h.Pop()
+		}
+	}
+}
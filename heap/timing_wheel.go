@@ -0,0 +1,145 @@
+package heap
+
+import "time"
+
+// timingWheel 分层时间轮，第 i 层每个槽覆盖 wheelSize^i 个 tick，超出当前最高层范围时按需增加一层。
+// 时间以 tick 为单位计数，任务的到期刻度向上取整，因此不会提前出队，最多延迟一个 tick。
+//
+// 与 Kafka 的实现不同，这里没有后台推进协程：每次 popDue 时按当前时间推进，
+// 并通过 nextWake 告诉调用方下一个非空槽的时间，空转的时间段会被直接跳过。
+type timingWheel[T any] struct {
+	tick   time.Duration
+	size   int64
+	cur    int64                 // 当前刻度
+	levels [][][]*DelayHandle[T] // levels[i][slot]
+	ready  []*DelayHandle[T]     // 已到期等待取出的任务
+}
+
+func newTimingWheel[T any](tick time.Duration, wheelSize int, now time.Time) *timingWheel[T] {
+	if wheelSize < 2 {
+		wheelSize = 64
+	}
+	w := &timingWheel[T]{tick: tick, size: int64(wheelSize)}
+	w.cur = w.floorTick(now)
+	return w
+}
+
+func (w *timingWheel[T]) floorTick(t time.Time) int64 {
+	return t.UnixNano() / int64(w.tick)
+}
+
+func (w *timingWheel[T]) ceilTick(t time.Time) int64 {
+	ns := t.UnixNano()
+	e := ns / int64(w.tick)
+	if ns%int64(w.tick) != 0 {
+		e++
+	}
+	return e
+}
+
+// unit 返回第 level 层每个槽覆盖的 tick 数
+func (w *timingWheel[T]) unit(level int) int64 {
+	u := int64(1)
+	for i := 0; i < level; i++ {
+		u *= w.size
+	}
+	return u
+}
+
+func (w *timingWheel[T]) push(h *DelayHandle[T]) {
+	h.expiration = w.ceilTick(h.at)
+	w.place(h)
+}
+
+// place 把任务放到能容纳其到期刻度的最低一层
+func (w *timingWheel[T]) place(h *DelayHandle[T]) {
+	if h.expiration <= w.cur {
+		w.ready = append(w.ready, h)
+		return
+	}
+	for level := 0; ; level++ {
+		if level == len(w.levels) {
+			w.levels = append(w.levels, make([][]*DelayHandle[T], w.size))
+		}
+		unit := w.unit(level)
+		start := w.cur - w.cur%unit // 本层当前槽的起始刻度
+		if h.expiration < start+unit*w.size {
+			slot := (h.expiration / unit) % w.size
+			w.levels[level][slot] = append(w.levels[level][slot], h)
+			return
+		}
+	}
+}
+
+func (w *timingWheel[T]) popDue(now time.Time) (*DelayHandle[T], bool) {
+	w.advance(w.floorTick(now))
+	if len(w.ready) == 0 {
+		return nil, false
+	}
+	h := w.ready[0]
+	w.ready[0] = nil
+	w.ready = w.ready[1:]
+	return h, true
+}
+
+// advance 依次处理不晚于 target 的非空槽，高层槽中的任务下沉到低层或进入 ready
+func (w *timingWheel[T]) advance(target int64) {
+	for {
+		next, ok := w.nextTick()
+		if !ok || next > target {
+			if target > w.cur {
+				w.cur = target
+			}
+			return
+		}
+		w.cur = next
+		for level := len(w.levels) - 1; level >= 0; level-- {
+			unit := w.unit(level)
+			if w.cur%unit != 0 {
+				continue
+			}
+			slot := (w.cur / unit) % w.size
+			bucket := w.levels[level][slot]
+			w.levels[level][slot] = nil
+			for _, h := range bucket {
+				if h.state == handlePending {
+					w.place(h)
+				}
+			}
+		}
+	}
+}
+
+// nextTick 返回最早的非空槽需要处理的刻度
+func (w *timingWheel[T]) nextTick() (int64, bool) {
+	best, found := int64(0), false
+	for level := range w.levels {
+		unit := w.unit(level)
+		base := w.cur / unit
+		// 本层当前槽已处理过（或其中的任务属于更低层），从下一个槽开始找
+		for b := base + 1; b < base+w.size; b++ {
+			if len(w.levels[level][b%w.size]) == 0 {
+				continue
+			}
+			if t := b * unit; !found || t < best {
+				best, found = t, true
+			}
+			break
+		}
+	}
+	return best, found
+}
+
+// remove 时间轮不记录任务所在位置，取消的任务在所在槽被处理时丢弃，保持 O(1) 取消
+func (w *timingWheel[T]) remove(*DelayHandle[T]) {}
+
+func (w *timingWheel[T]) nextWake() (time.Time, bool) {
+	if len(w.ready) > 0 {
+		return time.Unix(0, w.cur*int64(w.tick)), true
+	}
+	next, ok := w.nextTick()
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(0, next*int64(w.tick)), true
+}
@@ -0,0 +1,74 @@
+package heap
+
+// TopK 保留按 compareFn 排序最靠前的 K 个元素，内部是大小为 K 的反向堆，堆顶为当前第 K 名。
+// compareFn 与 HeapSorted 一致：MaxHeap(less) 保留最大的 K 个，MinHeap(less) 保留最小的 K 个。
+// 已满时比第 K 名更差的元素只需一次比较即被丢弃，内存占用始终为 O(K)。非并发安全
+type TopK[T any] struct {
+	k         int
+	heap      *Heap[T]
+	compareFn Comparable[T]
+}
+
+// NewTopK 创建 TopK，k <= 0 时不保留任何元素
+func NewTopK[T any](k int, compareFn Comparable[T]) *TopK[T] {
+	if k < 0 {
+		k = 0
+	}
+	return &TopK[T]{
+		k: k,
+		heap: NewHeap[T](k, func(a, b T) int {
+			return -compareFn(a, b)
+		}),
+		compareFn: compareFn,
+	}
+}
+
+// Push 尝试加入元素，返回是否进入前 K 名。与第 K 名相同时保留先加入的元素
+func (t *TopK[T]) Push(value T) bool {
+	if t.k == 0 {
+		return false
+	}
+	if t.heap.Size() < t.k {
+		t.heap.Insert(value)
+		return true
+	}
+	if t.compareFn(value, t.heap.HPDate[0]) >= 0 {
+		return false
+	}
+	// 替换堆顶后向下调整，比 Pop + Insert 少一次调整
+	t.heap.HPDate[0] = value
+	parentIndex := 0
+	t.heap.DownAdjust(&parentIndex)
+	return true
+}
+
+// Threshold 返回当前第 K 名，未满 K 个时返回 false。调用方可据此提前跳过计算代价高的候选
+func (t *TopK[T]) Threshold() (T, bool) {
+	if t.k == 0 || t.heap.Size() < t.k {
+		var zero T
+		return zero, false
+	}
+	return t.heap.HPDate[0], true
+}
+
+// Sorted 返回按 compareFn 排好序的结果（第一名在前），不影响已保留的元素
+func (t *TopK[T]) Sorted() []T {
+	items := make([]T, t.heap.Size())
+	copy(items, t.heap.HPDate[:t.heap.Size()])
+	return HeapSorted(items, t.compareFn)
+}
+
+// Len 返回当前保留的元素个数
+func (t *TopK[T]) Len() int {
+	return t.heap.Size()
+}
+
+// K 返回容量
+func (t *TopK[T]) K() int {
+	return t.k
+}
+
+// Reset 清空已保留的元素
+func (t *TopK[T]) Reset() {
+	t.heap.Clear()
+}
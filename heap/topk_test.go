@@ -0,0 +1,165 @@
+package heap
+
+import (
+	"iter"
+	"math/rand"
+	"reflect"
+	"slices"
+	"sort"
+	"testing"
+)
+
+func TestTopK(t *testing.T) {
+	top := NewTopK[int](3, MaxHeap(intLess))
+	for _, v := range []int{5, 1, 9, 3, 7, 9, 2} {
+		top.Push(v)
+	}
+	if got := top.Sorted(); !reflect.DeepEqual(got, []int{9, 9, 7}) {
+		t.Errorf("Sorted() = %v, want [9 9 7]", got)
+	}
+	if th, ok := top.Threshold(); !ok || th != 7 {
+		t.Errorf("Threshold() = %d %v, want 7", th, ok)
+	}
+	if top.Push(7) || top.Push(0) {
+		t.Error("Push() should reject values not better than the threshold")
+	}
+	if !top.Push(8) {
+		t.Error("Push(8) should be admitted")
+	}
+	// Sorted 不影响已保留的元素
+	top.Sorted()
+	if got := top.Sorted(); !reflect.DeepEqual(got, []int{9, 9, 8}) {
+		t.Errorf("Sorted() = %v, want [9 9 8]", got)
+	}
+
+	smallest := NewTopK[int](2, MinHeap(intLess))
+	if _, ok := smallest.Threshold(); ok {
+		t.Error("Threshold() should be unavailable before K elements are pushed")
+	}
+	for _, v := range []int{4, 8, 1, 6} {
+		smallest.Push(v)
+	}
+	if got := smallest.Sorted(); !reflect.DeepEqual(got, []int{1, 4}) {
+		t.Errorf("Sorted() = %v, want [1 4]", got)
+	}
+	smallest.Reset()
+	if smallest.Len() != 0 || smallest.K() != 2 {
+		t.Errorf("after Reset Len() = %d K() = %d", smallest.Len(), smallest.K())
+	}
+
+	if NewTopK[int](0, MaxHeap(intLess)).Push(1) {
+		t.Error("TopK with k=0 should reject everything")
+	}
+}
+
+func TestTopKRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	all := make([]int, 10000)
+	top := NewTopK[int](100, MaxHeap(intLess))
+	for i := range all {
+		all[i] = r.Intn(1000000)
+		top.Push(all[i])
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(all)))
+	if got := top.Sorted(); !reflect.DeepEqual(got, all[:100]) {
+		t.Errorf("Sorted() does not match the 100 largest values")
+	}
+}
+
+type scored struct {
+	name  string
+	score int
+}
+
+func TestMergeSorted(t *testing.T) {
+	byScore := MinHeap(func(a, b scored) bool { return a.score < b.score })
+	a := slices.Values([]scored{{"a1", 1}, {"a3", 3}, {"a5", 5}})
+	b := slices.Values([]scored{{"b1", 1}, {"b2", 2}, {"b6", 6}})
+	empty := slices.Values([]scored(nil))
+	c := slices.Values([]scored{{"c4", 4}})
+
+	var got []string
+	for v := range MergeSorted(byScore, a, empty, b, c) {
+		got = append(got, v.name)
+	}
+	// 分数相同时按输入顺序输出
+	want := []string{"a1", "b1", "b2", "a3", "c4", "a5", "b6"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("MergeSorted() = %v, want %v", got, want)
+	}
+
+	if n := len(slices.Collect(MergeSorted[int](MinHeap(intLess)))); n != 0 {
+		t.Errorf("MergeSorted() with no inputs yielded %d values", n)
+	}
+}
+
+// counting 生成 start, start+step, ... 的无限序列，并记录是否被停止
+func counting(start, step int, stopped *bool) iter.Seq[int] {
+	return func(yield func(int) bool) {
+		defer func() { *stopped = true }()
+		for v := start; ; v += step {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+func TestMergeSortedLazy(t *testing.T) {
+	var stopped [3]bool
+	merged := MergeSorted(MinHeap(intLess),
+		counting(0, 3, &stopped[0]),
+		counting(1, 3, &stopped[1]),
+		counting(2, 3, &stopped[2]),
+	)
+	// 输入是无限序列，只有惰性归并才能提前结束
+	var got []int
+	for v := range merged {
+		if v >= 10 {
+			break
+		}
+		got = append(got, v)
+	}
+	if !reflect.DeepEqual(got, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}) {
+		t.Errorf("MergeSorted() = %v", got)
+	}
+	for i, s := range stopped {
+		if !s {
+			t.Errorf("input %d was not stopped after early break", i)
+		}
+	}
+}
+
+func TestMergeSortedWithTopK(t *testing.T) {
+	// 多个分片各自降序的结果归并后取前 5
+	desc := MaxHeap(intLess)
+	shards := [][]int{{90, 70, 10}, {95, 60}, {80, 75, 74, 20}}
+	var seqs []iter.Seq[int]
+	for _, s := range shards {
+		seqs = append(seqs, slices.Values(s))
+	}
+	top := NewTopK[int](5, desc)
+	for v := range MergeSorted(desc, seqs...) {
+		if !top.Push(v) {
+			break // 输入有序，第一个落选者之后都不会入选
+		}
+	}
+	if got := top.Sorted(); !reflect.DeepEqual(got, []int{95, 90, 80, 75, 74}) {
+		t.Errorf("top 5 = %v", got)
+	}
+}
+
+func BenchmarkTopK(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	data := make([]int, 1<<16)
+	for i := range data {
+		data[i] = r.Int()
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		top := NewTopK[int](100, MaxHeap(intLess))
+		for _, v := range data {
+			top.Push(v)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package list
+
+import "sync"
+
+// DuplicatePolicy 决定 IndexPlugin 遇到重复键时的行为
+type DuplicatePolicy int
+
+const (
+	// DuplicateReplace 允许重复键，索引指向最近插入的节点
+	DuplicateReplace DuplicatePolicy = iota
+	// DuplicateKeepFirst 允许重复键，索引保留已有的节点
+	DuplicateKeepFirst
+	// DuplicateReject 拒绝重复键，AddBack/AddFront/InsertAfter/InsertBefore 返回 nil。
+	// Splice/PushBackList 整体移入的节点无法拒绝，按 DuplicateKeepFirst 处理
+	DuplicateReject
+)
+
+// IndexPlugin 维护键到节点的索引，提供 O(1) 的按键查找。
+// 一个 IndexPlugin 只能用于一个链表，节点插入后不应再修改其 Key
+type IndexPlugin[K comparable, V any] struct {
+	list        *List[K, V]
+	policy      DuplicatePolicy
+	mu          sync.RWMutex // 保护 nodes/dups，始终在链表锁之内获取
+	nodes       map[K]*Node[K, V]
+	dups        map[K]int // 每个键除索引节点外的同键节点数，只记录存在重复的键
+	dupTotal    int
+	onDuplicate func(key K, existing *Node[K, V])
+}
+
+// NewIndexPlugin 创建索引插件，通过 New 传入链表后生效
+func NewIndexPlugin[K comparable, V any](policy DuplicatePolicy) *IndexPlugin[K, V] {
+	return &IndexPlugin[K, V]{
+		policy: policy,
+		nodes:  make(map[K]*Node[K, V]),
+		dups:   make(map[K]int),
+	}
+}
+
+// OnDuplicate 注册重复键回调，existing 为索引中已有的节点。
+// 在链表锁内调用，回调中不能再操作该链表
+func (p *IndexPlugin[K, V]) OnDuplicate(f func(key K, existing *Node[K, V])) {
+	p.onDuplicate = f
+}
+
+func (p *IndexPlugin[K, V]) Attach(l *List[K, V]) {
+	p.list = l
+}
+
+func (p *IndexPlugin[K, V]) allowInsert(key K) bool {
+	if p.policy != DuplicateReject {
+		return true
+	}
+	p.mu.RLock()
+	existing := p.nodes[key]
+	p.mu.RUnlock()
+	if existing == nil {
+		return true
+	}
+	p.reportDuplicate(key, existing)
+	return false
+}
+
+func (p *IndexPlugin[K, V]) OnInsert(n *Node[K, V]) {
+	p.mu.Lock()
+	existing, ok := p.nodes[n.Key]
+	if !ok {
+		p.nodes[n.Key] = n
+		p.mu.Unlock()
+		return
+	}
+	p.dups[n.Key]++
+	p.dupTotal++
+	if p.policy == DuplicateReplace {
+		p.nodes[n.Key] = n
+	}
+	p.mu.Unlock()
+	p.reportDuplicate(n.Key, existing)
+}
+
+func (p *IndexPlugin[K, V]) OnRemove(n *Node[K, V]) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	cur, ok := p.nodes[n.Key]
+	if !ok {
+		return
+	}
+	extra := p.dups[n.Key]
+	if extra == 0 {
+		if cur == n {
+			delete(p.nodes, n.Key)
+		}
+		return
+	}
+	p.dupTotal--
+	if extra == 1 {
+		delete(p.dups, n.Key)
+	} else {
+		p.dups[n.Key] = extra - 1
+	}
+	if cur != n {
+		return
+	}
+	// 被删的是索引节点，从链表中找另一个同键节点顶替
+	if other := p.scan(n.Key); other != nil {
+		p.nodes[n.Key] = other
+		return
+	}
+	// Clear 或整表移出时链表已为空，剩余同键节点随后也会触发删除
+	p.dupTotal -= p.dups[n.Key]
+	delete(p.nodes, n.Key)
+	delete(p.dups, n.Key)
+}
+
+func (p *IndexPlugin[K, V]) OnUpdate(*Node[K, V], V) {}
+
+// scan 线性查找同键节点，DuplicateReplace 从尾部开始以便找到较新的节点
+func (p *IndexPlugin[K, V]) scan(key K) *Node[K, V] {
+	root := p.list.root
+	if p.policy == DuplicateReplace {
+		for n := root.prev; n != root; n = n.prev {
+			if n.Key == key {
+				return n
+			}
+		}
+		return nil
+	}
+	for n := root.next; n != root; n = n.next {
+		if n.Key == key {
+			return n
+		}
+	}
+	return nil
+}
+
+func (p *IndexPlugin[K, V]) reportDuplicate(key K, existing *Node[K, V]) {
+	if p.onDuplicate != nil {
+		p.onDuplicate(key, existing)
+	}
+}
+
+// Get 按键查找节点
+func (p *IndexPlugin[K, V]) Get(key K) (*Node[K, V], bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	n, ok := p.nodes[key]
+	return n, ok
+}
+
+// Contains 判断键是否存在
+func (p *IndexPlugin[K, V]) Contains(key K) bool {
+	_, ok := p.Get(key)
+	return ok
+}
+
+// Len 返回索引中不同键的数量
+func (p *IndexPlugin[K, V]) Len() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return len(p.nodes)
+}
+
+// Duplicates 返回链表中重复键节点的数量（不含每个键的索引节点）
+func (p *IndexPlugin[K, V]) Duplicates() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.dupTotal
+}
+
+// RemoveKey 删除键对应的节点，存在重复键时只删除索引指向的节点
+func (p *IndexPlugin[K, V]) RemoveKey(key K) bool {
+	l := p.list
+	if l.concurrent {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+	}
+	n, ok := p.Get(key)
+	if !ok {
+		return false
+	}
+	l.removeNode(n)
+	return true
+}
+
+// Upsert 键存在时更新索引节点的值，否则在链表尾部插入新节点。
+// 查找和写入在同一次加锁内完成，并发调用不会产生重复键。返回节点以及是否为新插入
+func (p *IndexPlugin[K, V]) Upsert(key K, value V) (*Node[K, V], bool) {
+	l := p.list
+	if l.concurrent {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+	}
+	if n, ok := p.Get(key); ok {
+		l.setValue(n, value)
+		return n, false
+	}
+	n := l.insert(l.root.prev, key, value)
+	return n, n != nil
+}
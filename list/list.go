@@ -3,9 +3,11 @@ package list
 
 import (
 	"fmt"
+	"iter"
 	"reflect"
 	"strings"
 	"sync"
+	"sync/atomic"
 )
 
 // Plugin 定义了链表插件的接口。
@@ -18,16 +20,49 @@ type Plugin[K comparable, V any] interface {
 
 // Node 表示链表中的一个节点。
 type Node[K comparable, V any] struct {
-	prev  *Node[K, V] // 前一个节点
-	next  *Node[K, V] // 后一个节点
-	Key   K           // 节点的键
-	Value V           // 节点的值
-	list  *List[K, V] // 所属链表
+	prev  *Node[K, V]      // 前一个节点
+	next  *Node[K, V]      // 后一个节点
+	Key   K                // 节点的键
+	Value V                // 节点的值
+	owner *listOwner[K, V] // 所属链表，已删除时为 nil
+}
+
+// listOwner 节点到所属链表的间接引用。整表拼接时把源链表的 owner 转发到目标链表，
+// 不需要逐个修改被移动的节点
+type listOwner[K comparable, V any] struct {
+	list    *List[K, V]
+	forward atomic.Pointer[listOwner[K, V]]
+}
+
+// resolve 沿转发链找到当前的 owner，并把经过的 owner 直接指向它（路径压缩），
+// 多次拼接后的查找仍为均摊 O(1)。转发只会指向链上更靠后的 owner，并发压缩不会成环
+func (o *listOwner[K, V]) resolve() *List[K, V] {
+	root := o
+	for f := root.forward.Load(); f != nil; f = root.forward.Load() {
+		root = f
+	}
+	for o != root {
+		next := o.forward.Load()
+		if next != root {
+			o.forward.Store(root)
+		}
+		o = next
+	}
+	return root.list
+}
+
+// list 返回节点所属的链表，已删除时返回 nil
+func (n *Node[K, V]) list() *List[K, V] {
+	if n == nil || n.owner == nil {
+		return nil
+	}
+	return n.owner.resolve()
 }
 
 // Prev 返回前一个节点，若为首节点则返回 nil。
 func (n *Node[K, V]) Prev() *Node[K, V] {
-	if n == nil || n.list == nil || n.prev == n.list.root {
+	l := n.list()
+	if l == nil || n.prev == l.root {
 		return nil
 	}
 	return n.prev
@@ -35,7 +70,8 @@ func (n *Node[K, V]) Prev() *Node[K, V] {
 
 // Next 返回后一个节点，若为尾节点则返回 nil。
 func (n *Node[K, V]) Next() *Node[K, V] {
-	if n == nil || n.list == nil || n.next == n.list.root {
+	l := n.list()
+	if l == nil || n.next == l.root {
 		return nil
 	}
 	return n.next
@@ -43,40 +79,45 @@ func (n *Node[K, V]) Next() *Node[K, V] {
 
 // IsValid 判断该节点是否有效并属于某个链表。
 func (n *Node[K, V]) IsValid() bool {
-	return n != nil && n.list != nil
+	return n.list() != nil
 }
 
 // SetValue 设置节点的新值，并触发更新回调。
 func (n *Node[K, V]) SetValue(val V) bool {
-	if n.list == nil {
-		return false
-	}
-	if reflect.DeepEqual(n.Value, val) {
+	l := n.list()
+	if l == nil {
 		return false
 	}
-	old := n.Value
-	n.Value = val
-	for _, pl := range n.list.plugins {
-		pl.OnUpdate(n, old)
-	}
-	for _, cb := range n.list.onUpdate {
-		cb(n, old)
+	if l.concurrent {
+		l.mu.Lock()
+		defer l.mu.Unlock()
 	}
-	return true
+	return l.setValue(n, val)
 }
 
 // List 表示一个带插件支持的双向链表。
 type List[K comparable, V any] struct {
 	root       *Node[K, V]            // 哨兵节点，形成循环链表结构
 	len        int                    // 链表长度
+	owner      *listOwner[K, V]       // 当前节点共享的所属链表引用
+	id         uint64                 // 同时锁定两个链表时按 id 排序，避免死锁
 	mu         sync.RWMutex           // 并发读写锁
 	concurrent bool                   // 是否启用并发安全
 	plugins    []Plugin[K, V]         // 插件列表
 	onInsert   []func(*Node[K, V])    // 插入回调列表
 	onRemove   []func(*Node[K, V])    // 删除回调列表
 	onUpdate   []func(*Node[K, V], V) // 更新回调列表
+	guards     []insertGuard[K]       // 插入前检查的插件
+	index      *IndexPlugin[K, V]     // 键索引，未使用 IndexPlugin 时为 nil
 }
 
+// insertGuard 由包内插件实现，在创建节点之前决定是否允许插入该键
+type insertGuard[K comparable] interface {
+	allowInsert(key K) bool
+}
+
+var listSeq atomic.Uint64
+
 func New[K comparable, V any](concurrent bool, plugins ...Plugin[K, V]) *List[K, V] {
 	root := &Node[K, V]{}
 	root.prev, root.next = root, root
@@ -84,31 +125,41 @@ func New[K comparable, V any](concurrent bool, plugins ...Plugin[K, V]) *List[K,
 	l := &List[K, V]{
 		root:       root,
 		len:        0,
+		id:         listSeq.Add(1),
 		concurrent: concurrent,
 		plugins:    plugins,
 	}
+	l.owner = &listOwner[K, V]{list: l}
 
 	for _, p := range plugins {
 		p.Attach(l)
+		if g, ok := p.(insertGuard[K]); ok {
+			l.guards = append(l.guards, g)
+		}
+		if idx, ok := p.(*IndexPlugin[K, V]); ok && l.index == nil {
+			l.index = idx
+		}
 	}
 
 	return l
 }
 
-// Clear 删除所有节点，但保留根节点和插件设置
+// Clear 删除所有节点，但保留根节点和插件设置，每个节点都会触发删除事件
 func (l *List[K, V]) Clear() {
 	if l.concurrent {
 		l.mu.Lock()
 		defer l.mu.Unlock()
 	}
-	for n := l.FrontNode(); n != nil; {
-		next := n.Next()
-		n.list = nil
+	first := l.root.next
+	l.root.prev, l.root.next = l.root, l.root
+	l.len = 0
+	for n := first; n != l.root; {
+		next := n.next
+		n.owner = nil
 		n.prev, n.next = nil, nil
+		l.fireRemove(n)
 		n = next
 	}
-	l.root.prev, l.root.next = l.root, l.root
-	l.len = 0
 }
 
 // OnInsert 注册插入事件的回调函数。
@@ -126,80 +177,267 @@ func (l *List[K, V]) OnUpdate(f func(*Node[K, V], V)) {
 	l.onUpdate = append(l.onUpdate, f)
 }
 
-// AddBack 在链表尾部添加一个新节点。
+// AddBack 在链表尾部添加一个新节点，被插件拒绝时返回 nil。
 func (l *List[K, V]) AddBack(key K, value V) *Node[K, V] {
 	if l.concurrent {
 		l.mu.Lock()
 		defer l.mu.Unlock()
 	}
-	n := &Node[K, V]{Key: key, Value: value}
-	l.insertNode(l.root.prev, n)
-	for _, pl := range l.plugins {
-		pl.OnInsert(n)
-	}
-	for _, cb := range l.onInsert {
-		cb(n)
-	}
-	return n
+	return l.insert(l.root.prev, key, value)
 }
 
-// AddFront 在链表头部添加一个新节点。
+// AddFront 在链表头部添加一个新节点，被插件拒绝时返回 nil。
 func (l *List[K, V]) AddFront(key K, value V) *Node[K, V] {
 	if l.concurrent {
 		l.mu.Lock()
 		defer l.mu.Unlock()
 	}
-	n := &Node[K, V]{Key: key, Value: value}
-	l.insertNode(l.root, n)
-	for _, pl := range l.plugins {
-		pl.OnInsert(n)
-	}
-	for _, cb := range l.onInsert {
-		cb(n)
-	}
-	return n
+	return l.insert(l.root, key, value)
 }
 
 // InsertAfter 在指定节点之后插入新节点。
 func (l *List[K, V]) InsertAfter(at *Node[K, V], key K, value V) *Node[K, V] {
-	if at == nil || at.list != l {
+	if at == nil || at.list() != l {
 		return nil
 	}
 	if l.concurrent {
 		l.mu.Lock()
 		defer l.mu.Unlock()
 	}
-	n := &Node[K, V]{Key: key, Value: value}
-	l.insertNode(at, n)
-	for _, pl := range l.plugins {
-		pl.OnInsert(n)
-	}
-	for _, cb := range l.onInsert {
-		cb(n)
-	}
-	return n
+	return l.insert(at, key, value)
 }
 
 // Remove 从链表中删除指定节点。
 func (l *List[K, V]) Remove(n *Node[K, V]) bool {
-	if n == nil || n.list != l {
+	if n == nil || n.list() != l {
+		return false
+	}
+	if l.concurrent {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+	}
+	l.removeNode(n)
+	return true
+}
+
+// InsertBefore 在指定节点之前插入新节点。
+func (l *List[K, V]) InsertBefore(at *Node[K, V], key K, value V) *Node[K, V] {
+	if at == nil || at.list() != l {
+		return nil
+	}
+	if l.concurrent {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+	}
+	return l.insert(at.prev, key, value)
+}
+
+// MoveToFront 将节点移动到链表头部，位置发生变化时触发 OnUpdate(n, n.Value)。
+func (l *List[K, V]) MoveToFront(n *Node[K, V]) bool {
+	if n == nil || n.list() != l {
+		return false
+	}
+	if l.concurrent {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+	}
+	l.moveAfter(n, l.root)
+	return true
+}
+
+// MoveToBack 将节点移动到链表尾部。
+func (l *List[K, V]) MoveToBack(n *Node[K, V]) bool {
+	if n == nil || n.list() != l {
+		return false
+	}
+	if l.concurrent {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+	}
+	l.moveAfter(n, l.root.prev)
+	return true
+}
+
+// MoveBefore 将节点移动到 mark 之前，两者都必须属于该链表。
+func (l *List[K, V]) MoveBefore(n, mark *Node[K, V]) bool {
+	if n == nil || mark == nil || n.list() != l || mark.list() != l {
+		return false
+	}
+	if l.concurrent {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+	}
+	if n != mark {
+		l.moveAfter(n, mark.prev)
+	}
+	return true
+}
+
+// MoveAfter 将节点移动到 mark 之后，两者都必须属于该链表。
+func (l *List[K, V]) MoveAfter(n, mark *Node[K, V]) bool {
+	if n == nil || mark == nil || n.list() != l || mark.list() != l {
 		return false
 	}
 	if l.concurrent {
 		l.mu.Lock()
 		defer l.mu.Unlock()
 	}
+	if n != mark {
+		l.moveAfter(n, mark)
+	}
+	return true
+}
+
+// moveAfter 将 n 移动到 at 之后，位置变化时触发更新事件。
+func (l *List[K, V]) moveAfter(n, at *Node[K, V]) {
+	if n == at || n.prev == at {
+		return
+	}
 	n.prev.next = n.next
 	n.next.prev = n.prev
-	n.list = nil
-	l.len--
+	n.next = at.next
+	at.next.prev = n
+	at.next = n
+	n.prev = at
+	l.fireUpdate(n, n.Value)
+}
+
+// PushBackList 将 other 的全部节点移动到链表尾部，other 变为空链表。
+// 节点本身被移动而非复制，已有的 *Node 在移动后属于该链表。
+func (l *List[K, V]) PushBackList(other *List[K, V]) bool {
+	if other == nil || other == l {
+		return false
+	}
+	unlock := lockPair(l, other)
+	defer unlock()
+	l.splice(l.root.prev, other)
+	return true
+}
+
+// Splice 将 other 的全部节点移动到 at 之后，other 变为空链表。
+// 链接调整为 O(1)；若任一链表注册了插件或回调，会对每个节点在 other 上触发 OnRemove、在该链表上触发 OnInsert。
+func (l *List[K, V]) Splice(at *Node[K, V], other *List[K, V]) bool {
+	if other == nil || other == l || at == nil || at.list() != l {
+		return false
+	}
+	unlock := lockPair(l, other)
+	defer unlock()
+	l.splice(at, other)
+	return true
+}
+
+func (l *List[K, V]) splice(at *Node[K, V], other *List[K, V]) {
+	if other.len == 0 {
+		return
+	}
+	first, last, moved := other.root.next, other.root.prev, other.len
+	other.root.next, other.root.prev = other.root, other.root
+	other.len = 0
+	// 原有节点通过旧 owner 转发到该链表，other 换用新的 owner
+	other.owner.forward.Store(l.owner)
+	other.owner = &listOwner[K, V]{list: other}
+
+	last.next = at.next
+	at.next.prev = last
+	at.next = first
+	first.prev = at
+	l.len += moved
+
+	if !other.hasEvents() && !l.hasEvents() {
+		return
+	}
+	for n := first; ; n = n.next {
+		other.fireRemove(n)
+		l.fireInsert(n)
+		if n == last {
+			break
+		}
+	}
+}
+
+// lockPair 按 id 顺序锁定两个链表，返回解锁函数。
+func lockPair[K comparable, V any](a, b *List[K, V]) func() {
+	if b.id < a.id {
+		a, b = b, a
+	}
+	if a.concurrent {
+		a.mu.Lock()
+	}
+	if b.concurrent {
+		b.mu.Lock()
+	}
+	return func() {
+		if b.concurrent {
+			b.mu.Unlock()
+		}
+		if a.concurrent {
+			a.mu.Unlock()
+		}
+	}
+}
+
+func (l *List[K, V]) hasEvents() bool {
+	return len(l.plugins) > 0 || len(l.onInsert) > 0 || len(l.onRemove) > 0 || len(l.onUpdate) > 0
+}
+
+func (l *List[K, V]) fireInsert(n *Node[K, V]) {
+	for _, pl := range l.plugins {
+		pl.OnInsert(n)
+	}
+	for _, cb := range l.onInsert {
+		cb(n)
+	}
+}
+
+func (l *List[K, V]) fireRemove(n *Node[K, V]) {
 	for _, pl := range l.plugins {
 		pl.OnRemove(n)
 	}
 	for _, cb := range l.onRemove {
 		cb(n)
 	}
+}
+
+func (l *List[K, V]) fireUpdate(n *Node[K, V], old V) {
+	for _, pl := range l.plugins {
+		pl.OnUpdate(n, old)
+	}
+	for _, cb := range l.onUpdate {
+		cb(n, old)
+	}
+}
+
+// insert 在 at 之后创建并插入新节点，任一插件拒绝该键时返回 nil。
+func (l *List[K, V]) insert(at *Node[K, V], key K, value V) *Node[K, V] {
+	for _, g := range l.guards {
+		if !g.allowInsert(key) {
+			return nil
+		}
+	}
+	n := &Node[K, V]{Key: key, Value: value}
+	l.insertNode(at, n)
+	l.fireInsert(n)
+	return n
+}
+
+// removeNode 摘除节点并触发删除事件，调用方负责加锁。
+func (l *List[K, V]) removeNode(n *Node[K, V]) {
+	n.prev.next = n.next
+	n.next.prev = n.prev
+	n.owner = nil
+	l.len--
+	l.fireRemove(n)
 	n.prev, n.next = nil, nil
+}
+
+// setValue 更新节点的值，值未变化时返回 false，调用方负责加锁。
+func (l *List[K, V]) setValue(n *Node[K, V], val V) bool {
+	if reflect.DeepEqual(n.Value, val) {
+		return false
+	}
+	old := n.Value
+	n.Value = val
+	l.fireUpdate(n, old)
 	return true
 }
 
@@ -209,7 +447,7 @@ func (l *List[K, V]) insertNode(at, n *Node[K, V]) {
 	at.next.prev = n
 	at.next = n
 	n.prev = at
-	n.list = l
+	n.owner = l.owner
 	l.len++
 }
 
@@ -259,6 +497,32 @@ func (l *List[K, V]) Traversal(visitor func(key K, value V) bool) {
 	}
 }
 
+// All 返回从头到尾的迭代器，循环体中可以删除当前节点。
+func (l *List[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for n := l.FrontNode(); n != nil; {
+			next := n.Next()
+			if !yield(n.Key, n.Value) {
+				return
+			}
+			n = next
+		}
+	}
+}
+
+// Backward 返回从尾到头的迭代器，循环体中可以删除当前节点。
+func (l *List[K, V]) Backward() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for n := l.BackNode(); n != nil; {
+			prev := n.Prev()
+			if !yield(n.Key, n.Value) {
+				return
+			}
+			n = prev
+		}
+	}
+}
+
 // Find 返回第一个满足 predicate 的节点
 func (l *List[K, V]) Find(pred func(key K, value V) bool) *Node[K, V] {
 	for n := l.FrontNode(); n != nil; n = n.Next() {
@@ -269,8 +533,11 @@ func (l *List[K, V]) Find(pred func(key K, value V) bool) *Node[K, V] {
 	return nil
 }
 
-// Contains 判断是否存在某个键
+// Contains 判断是否存在某个键，使用了 IndexPlugin 时为 O(1)
 func (l *List[K, V]) Contains(key K) bool {
+	if l.index != nil {
+		return l.index.Contains(key)
+	}
 	return l.Find(func(k K, _ V) bool { return k == key }) != nil
 }
 
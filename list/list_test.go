@@ -170,3 +170,396 @@ func TestClear(t *testing.T) {
 		t.Errorf("Root node not reset correctly")
 	}
 }
+
+func keysOf[K comparable, V any](l *List[K, V]) []K {
+	var keys []K
+	for k := range l.All() {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func TestInsertBeforeAndMove(t *testing.T) {
+	mock := &mockPlugin[int, string]{}
+	l := New[int, string](false, mock)
+	n1 := l.AddBack(1, "a")
+	n3 := l.AddBack(3, "c")
+	n2 := l.InsertBefore(n3, 2, "b")
+	n0 := l.InsertBefore(n1, 0, "z")
+	if got := fmt.Sprint(keysOf(l)); got != "[0 1 2 3]" {
+		t.Fatalf("keys = %s, want [0 1 2 3]", got)
+	}
+	if len(mock.inserted) != 4 {
+		t.Errorf("OnInsert fired %d times, want 4", len(mock.inserted))
+	}
+
+	steps := []struct {
+		op   func() bool
+		want string
+	}{
+		{func() bool { return l.MoveToFront(n3) }, "[3 0 1 2]"},
+		{func() bool { return l.MoveToBack(n0) }, "[3 1 2 0]"},
+		{func() bool { return l.MoveBefore(n2, n1) }, "[3 2 1 0]"},
+		{func() bool { return l.MoveAfter(n3, n0) }, "[2 1 0 3]"},
+	}
+	for i, step := range steps {
+		if !step.op() {
+			t.Fatalf("step %d returned false", i)
+		}
+		if got := fmt.Sprint(keysOf(l)); got != step.want {
+			t.Fatalf("step %d keys = %s, want %s", i, got, step.want)
+		}
+	}
+	if len(mock.updated) != len(steps) || mock.updates[0] != "c" {
+		t.Errorf("OnUpdate fired %d times with %v, want one per move with the current value", len(mock.updated), mock.updates)
+	}
+
+	// 已在目标位置时不触发事件
+	l.MoveToFront(n2)
+	l.MoveAfter(n3, n3)
+	l.MoveBefore(n1, n0)
+	if len(mock.updated) != len(steps) {
+		t.Errorf("no-op moves should not fire OnUpdate")
+	}
+
+	other := New[int, string](false)
+	foreign := other.AddBack(9, "x")
+	if l.MoveToFront(foreign) || l.MoveBefore(n1, foreign) || l.InsertBefore(foreign, 5, "y") != nil {
+		t.Error("operations with nodes of another list should fail")
+	}
+	if l.Len() != 4 {
+		t.Errorf("Len() = %d, want 4", l.Len())
+	}
+}
+
+func TestSplice(t *testing.T) {
+	a := New[int, string](false)
+	b := New[int, string](false)
+	a.AddBack(1, "a")
+	a2 := a.AddBack(2, "b")
+	b1 := b.AddBack(10, "x")
+	b.AddBack(11, "y")
+
+	if !a.PushBackList(b) {
+		t.Fatal("PushBackList() = false")
+	}
+	if got := fmt.Sprint(keysOf(a)); got != "[1 2 10 11]" || a.Len() != 4 {
+		t.Fatalf("after PushBackList keys = %s len = %d", got, a.Len())
+	}
+	if b.Len() != 0 || b.FrontNode() != nil {
+		t.Errorf("source list should be empty")
+	}
+	// 被移动的节点属于目标链表
+	if b.Remove(b1) || !a.MoveToFront(b1) {
+		t.Fatal("moved node should belong to the destination list")
+	}
+
+	// 源链表清空后仍可使用，且不影响已移动的节点
+	b.AddBack(20, "p")
+	b.AddBack(21, "q")
+	if !a.Splice(a2, b) {
+		t.Fatal("Splice() = false")
+	}
+	if got := fmt.Sprint(keysOf(a)); got != "[10 1 2 20 21 11]" {
+		t.Fatalf("after Splice keys = %s", got)
+	}
+
+	// 多次拼接后节点的归属仍然正确
+	c := New[int, string](false)
+	c.AddBack(100, "c")
+	c.PushBackList(a)
+	if !c.Remove(b1) || c.Len() != 6 {
+		t.Fatalf("node should follow chained splices, Len() = %d", c.Len())
+	}
+	if got := fmt.Sprint(keysOf(c)); got != "[100 1 2 20 21 11]" {
+		t.Fatalf("keys = %s", got)
+	}
+	if a.PushBackList(a) || a.Splice(a2, c) {
+		t.Error("splicing a list into itself or at a foreign node should fail")
+	}
+}
+
+func TestSplicePathCompression(t *testing.T) {
+	l := New[int, string](false)
+	n := l.AddBack(1, "a")
+	for i := 0; i < 100; i++ {
+		next := New[int, string](false)
+		next.AddBack(i+10, "b")
+		next.PushBackList(l)
+		l = next
+	}
+	if n.list() != l || l.Len() != 101 {
+		t.Fatalf("node should belong to the last list, Len() = %d", l.Len())
+	}
+	// 查找后节点的 owner 直接转发到当前 owner
+	if f := n.owner.forward.Load(); f != l.owner {
+		t.Errorf("owner chain was not compressed")
+	}
+
+	// 压缩后继续拼接，归属仍然正确
+	last := New[int, string](false)
+	last.PushBackList(l)
+	if !last.MoveToFront(n) || l.Remove(n) || last.FrontNode() != n {
+		t.Error("node should follow splices after compression")
+	}
+	if !last.Remove(n) || last.Len() != 100 {
+		t.Errorf("Remove() after splices, Len() = %d", last.Len())
+	}
+}
+
+func TestSpliceEvents(t *testing.T) {
+	src := &mockPlugin[int, int]{}
+	dst := &mockPlugin[int, int]{}
+	a := New[int, int](false, dst)
+	b := New[int, int](false, src)
+	a.AddBack(1, 1)
+	b.AddBack(2, 2)
+	b.AddBack(3, 3)
+	src.inserted = nil
+
+	a.PushBackList(b)
+	if len(src.removed) != 2 || len(dst.inserted) != 3 {
+		t.Fatalf("OnRemove on source = %d, OnInsert on destination = %d", len(src.removed), len(dst.inserted))
+	}
+	for _, n := range src.removed {
+		if !n.IsValid() {
+			t.Error("nodes reported by OnRemove during a splice should already belong to the destination")
+		}
+	}
+}
+
+func TestIterators(t *testing.T) {
+	l := New[int, string](true)
+	for i := 1; i <= 5; i++ {
+		l.AddBack(i, fmt.Sprint(i))
+	}
+	var backward []int
+	for k, v := range l.Backward() {
+		if fmt.Sprint(k) != v {
+			t.Errorf("value mismatch at %d", k)
+		}
+		backward = append(backward, k)
+	}
+	if fmt.Sprint(backward) != "[5 4 3 2 1]" {
+		t.Errorf("Backward() = %v", backward)
+	}
+
+	// 提前结束
+	var first []int
+	for k := range l.All() {
+		if k > 2 {
+			break
+		}
+		first = append(first, k)
+	}
+	if fmt.Sprint(first) != "[1 2]" {
+		t.Errorf("All() with break = %v", first)
+	}
+
+	// 循环中删除当前节点
+	for k := range l.All() {
+		if k%2 == 0 {
+			l.Remove(l.Find(func(key int, _ string) bool { return key == k }))
+		}
+	}
+	if got := fmt.Sprint(keysOf(l)); got != "[1 3 5]" {
+		t.Errorf("keys after removal = %s", got)
+	}
+}
+
+func TestConcurrentSplice(t *testing.T) {
+	a := New[int, int](true)
+	b := New[int, int](true)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				a.AddBack(i*1000+j, j)
+				b.PushBackList(a)
+			}
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				b.AddBack(-(i*1000 + j + 1), j)
+				a.PushBackList(b)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if total := a.Len() + b.Len(); total != 1600 {
+		t.Errorf("total Len() = %d, want 1600", total)
+	}
+	if len(keysOf(a)) != a.Len() || len(keysOf(b)) != b.Len() {
+		t.Error("Len() does not match traversal")
+	}
+}
+
+// checkIndex 检查索引与链表内容一致：每个键都能查到同键的有效节点，重复计数正确
+func checkIndex[K comparable, V any](t *testing.T, l *List[K, V], idx *IndexPlugin[K, V]) {
+	t.Helper()
+	distinct := map[K]bool{}
+	for k := range l.All() {
+		distinct[k] = true
+		n, ok := idx.Get(k)
+		if !ok || !n.IsValid() || n.Key != k || n.list() != l {
+			t.Fatalf("index entry for %v is stale", k)
+		}
+	}
+	if idx.Len() != len(distinct) {
+		t.Fatalf("index Len() = %d, want %d", idx.Len(), len(distinct))
+	}
+	if want := l.Len() - len(distinct); idx.Duplicates() != want {
+		t.Fatalf("Duplicates() = %d, want %d", idx.Duplicates(), want)
+	}
+}
+
+func TestIndexPlugin(t *testing.T) {
+	idx := NewIndexPlugin[string, int](DuplicateReject)
+	l := New[string, int](false, idx)
+	a := l.AddBack("a", 1)
+	l.AddBack("b", 2)
+
+	if n, ok := idx.Get("a"); !ok || n != a {
+		t.Fatalf("Get(a) = %v, %v", n, ok)
+	}
+	if !l.Contains("b") || l.Contains("c") {
+		t.Error("Contains should use the index")
+	}
+
+	// 已存在的键更新值并触发 OnUpdate，不存在的键追加到尾部
+	var updated []int
+	l.OnUpdate(func(_ *Node[string, int], old int) { updated = append(updated, old) })
+	if n, inserted := idx.Upsert("a", 10); inserted || n != a || a.Value != 10 {
+		t.Errorf("Upsert existing key: inserted=%v value=%d", inserted, a.Value)
+	}
+	if n, inserted := idx.Upsert("c", 3); !inserted || n != l.BackNode() {
+		t.Error("Upsert new key should append")
+	}
+	if fmt.Sprint(updated) != "[1]" {
+		t.Errorf("OnUpdate old values = %v", updated)
+	}
+
+	if !idx.RemoveKey("b") || idx.RemoveKey("b") || l.Contains("b") {
+		t.Error("RemoveKey failed")
+	}
+	a.SetValue(11)
+	if n, _ := idx.Get("a"); n.Value != 11 {
+		t.Error("index should see SetValue")
+	}
+	checkIndex(t, l, idx)
+
+	l.Clear()
+	if idx.Len() != 0 || l.Contains("a") {
+		t.Error("Clear should empty the index")
+	}
+	if a.IsValid() {
+		t.Error("cleared node should be invalid")
+	}
+}
+
+func TestIndexPluginDuplicatePolicy(t *testing.T) {
+	t.Run("reject", func(t *testing.T) {
+		idx := NewIndexPlugin[int, string](DuplicateReject)
+		var seen []int
+		idx.OnDuplicate(func(key int, _ *Node[int, string]) { seen = append(seen, key) })
+		l := New[int, string](false, idx)
+		first := l.AddBack(1, "a")
+		if l.AddBack(1, "b") != nil || l.AddFront(1, "c") != nil || l.InsertAfter(first, 1, "d") != nil {
+			t.Error("duplicate inserts should be rejected")
+		}
+		if l.Len() != 1 || fmt.Sprint(seen) != "[1 1 1]" {
+			t.Errorf("Len() = %d, duplicates seen = %v", l.Len(), seen)
+		}
+
+		// 整表移入的重复键无法拒绝，只能检测
+		other := New[int, string](false)
+		other.AddBack(1, "e")
+		other.AddBack(2, "f")
+		l.PushBackList(other)
+		if n, _ := idx.Get(1); n != first {
+			t.Error("spliced duplicate should keep the first node")
+		}
+		if idx.Duplicates() != 1 || len(seen) != 4 {
+			t.Errorf("Duplicates() = %d, seen = %v", idx.Duplicates(), seen)
+		}
+		checkIndex(t, l, idx)
+	})
+
+	t.Run("replace", func(t *testing.T) {
+		idx := NewIndexPlugin[int, string](DuplicateReplace)
+		l := New[int, string](false, idx)
+		a := l.AddBack(1, "a")
+		b := l.AddBack(1, "b")
+		c := l.AddBack(1, "c")
+		if n, _ := idx.Get(1); n != c {
+			t.Error("index should point to the latest node")
+		}
+		l.Remove(c)
+		if n, _ := idx.Get(1); n != b {
+			t.Error("removing the indexed node should fall back to another duplicate")
+		}
+		checkIndex(t, l, idx)
+		l.Remove(a)
+		l.Remove(b)
+		if idx.Contains(1) || idx.Duplicates() != 0 {
+			t.Error("index should be empty")
+		}
+	})
+
+	t.Run("keep first", func(t *testing.T) {
+		idx := NewIndexPlugin[int, string](DuplicateKeepFirst)
+		l := New[int, string](false, idx)
+		a := l.AddBack(1, "a")
+		l.AddBack(1, "b")
+		l.AddBack(2, "c")
+		if n, _ := idx.Get(1); n != a {
+			t.Error("index should keep the first node")
+		}
+		checkIndex(t, l, idx)
+
+		// 整表移出后源链表的索引清空，目标链表的索引包含全部节点
+		dstIdx := NewIndexPlugin[int, string](DuplicateKeepFirst)
+		dst := New[int, string](false, dstIdx)
+		dst.PushBackList(l)
+		if idx.Len() != 0 || idx.Duplicates() != 0 {
+			t.Errorf("source index not emptied: Len=%d Duplicates=%d", idx.Len(), idx.Duplicates())
+		}
+		checkIndex(t, dst, dstIdx)
+		dst.Clear()
+		if dstIdx.Len() != 0 || dstIdx.Duplicates() != 0 {
+			t.Error("Clear should reset duplicates")
+		}
+	})
+}
+
+func TestIndexPluginConcurrent(t *testing.T) {
+	idx := NewIndexPlugin[int, int](DuplicateReject)
+	l := New[int, int](true, idx)
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				key := (g*7 + i) % 64
+				switch i % 4 {
+				case 0, 1:
+					idx.Upsert(key, i)
+				case 2:
+					idx.RemoveKey(key)
+				case 3:
+					l.AddBack(key, i)
+				}
+				l.Contains(key)
+			}
+		}(g)
+	}
+	wg.Wait()
+	checkIndex(t, l, idx)
+	if idx.Duplicates() != 0 {
+		t.Errorf("reject policy produced %d duplicates", idx.Duplicates())
+	}
+}
@@ -0,0 +1,340 @@
+package lockfreelist_test
+
+import (
+	"fmt"
+	"math/rand"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/trancecho/ragnarok/lockfreelist"
+)
+
+type opKind int
+
+const (
+	opAddFront opKind = iota
+	opAddBack
+	opRemove
+	opFront
+	opBack
+	opContains
+)
+
+// historyOp 一次操作的调用区间和结果，call/ret 来自全局递增的逻辑时钟
+type historyOp struct {
+	kind      opKind
+	key       int
+	ok        bool // Remove/Contains 的结果
+	out       int  // Front/Back 返回的键，-1 表示空
+	call, ret int64
+}
+
+func (o historyOp) String() string {
+	names := []string{"AddFront", "AddBack", "Remove", "Front", "Back", "Contains"}
+	return fmt.Sprintf("%s(%d)=%v/%d@[%d,%d]", names[o.kind], o.key, o.ok, o.out, o.call, o.ret)
+}
+
+// applyModel 在顺序模型（键的有序切片）上执行操作，返回新状态以及结果是否与实际一致
+func applyModel(state []int, o historyOp) ([]int, bool) {
+	idx := -1
+	for i, k := range state {
+		if k == o.key {
+			idx = i
+		}
+	}
+	switch o.kind {
+	case opAddFront:
+		return append([]int{o.key}, state...), true
+	case opAddBack:
+		return append(append([]int{}, state...), o.key), true
+	case opRemove:
+		if idx < 0 {
+			return state, !o.ok
+		}
+		if !o.ok {
+			return state, false
+		}
+		next := append([]int{}, state[:idx]...)
+		return append(next, state[idx+1:]...), true
+	case opFront:
+		if len(state) == 0 {
+			return state, o.out == -1
+		}
+		return state, o.out == state[0]
+	case opBack:
+		if len(state) == 0 {
+			return state, o.out == -1
+		}
+		return state, o.out == state[len(state)-1]
+	case opContains:
+		return state, o.ok == (idx >= 0)
+	}
+	return state, false
+}
+
+// linearizable 使用 Wing & Gong 的回溯搜索判断历史是否存在合法的线性化顺序，
+// 用 (已完成操作集合, 模型状态) 做记忆化剪枝
+func linearizable(initial []int, history []historyOp) bool {
+	seen := map[string]bool{}
+	var search func(done uint64, state []int) bool
+	search = func(done uint64, state []int) bool {
+		if done == 1<<len(history)-1 {
+			return true
+		}
+		memo := fmt.Sprint(done, state)
+		if seen[memo] {
+			return false
+		}
+		seen[memo] = true
+
+		// 尚未完成的操作中最早的返回时间，调用晚于它的操作不能排在前面
+		minRet := int64(1 << 62)
+		for i, o := range history {
+			if done&(1<<i) == 0 && o.ret < minRet {
+				minRet = o.ret
+			}
+		}
+		for i, o := range history {
+			if done&(1<<i) != 0 || o.call > minRet {
+				continue
+			}
+			if next, ok := applyModel(state, o); ok && search(done|1<<i, next) {
+				return true
+			}
+		}
+		return false
+	}
+	return search(0, initial)
+}
+
+func TestLinearizabilityChecker(t *testing.T) {
+	// 两个不重叠的操作：AddFront(1) 完成之后 Front 却返回空，不可线性化
+	bad := []historyOp{
+		{kind: opAddFront, key: 1, call: 1, ret: 2},
+		{kind: opFront, out: -1, call: 3, ret: 4},
+	}
+	assert.False(t, linearizable(nil, bad))
+	// 重叠时可以把 Front 排在 AddFront 之前
+	bad[1].call = 1
+	assert.True(t, linearizable(nil, bad))
+}
+
+func runLinearizabilityRound(t *testing.T, newList func() *lockfreelist.List[int, int], r *rand.Rand) {
+	const goroutines, opsPerGoroutine = 4, 4
+	l := newList()
+	initial := []int{0, 1, 2}
+	shared := make([]*lockfreelist.Node[int, int], len(initial))
+	for i, k := range initial {
+		shared[i] = l.AddBack(k, k)
+	}
+
+	var clock atomic.Int64
+	histories := make([][]historyOp, goroutines)
+	seeds := make([]int64, goroutines)
+	for g := range seeds {
+		seeds[g] = r.Int63()
+	}
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			gr := rand.New(rand.NewSource(seeds[g]))
+			own := []*lockfreelist.Node[int, int]{}
+			for i := 0; i < opsPerGoroutine; i++ {
+				o := historyOp{kind: opKind(gr.Intn(6)), out: -1}
+				// 每个新键全局唯一，模型中的键即可代表节点
+				key := 100 + g*opsPerGoroutine + i
+				var target *lockfreelist.Node[int, int]
+				if o.kind == opRemove || o.kind == opContains {
+					if len(own) > 0 && gr.Intn(2) == 0 {
+						target = own[gr.Intn(len(own))]
+					} else {
+						target = shared[gr.Intn(len(shared))]
+					}
+					o.key = target.Key()
+				}
+
+				o.call = clock.Add(1)
+				switch o.kind {
+				case opAddFront:
+					o.key = key
+					own = append(own, l.AddFront(key, key))
+				case opAddBack:
+					o.key = key
+					own = append(own, l.AddBack(key, key))
+				case opRemove:
+					o.ok = l.Remove(target)
+				case opFront:
+					if n := l.FrontNode(); n != nil {
+						o.out = n.Key()
+					}
+				case opBack:
+					if n := l.BackNode(); n != nil {
+						o.out = n.Key()
+					}
+				case opContains:
+					o.ok = l.Contains(o.key)
+				}
+				o.ret = clock.Add(1)
+				histories[g] = append(histories[g], o)
+				runtime.Gosched()
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	var history []historyOp
+	for _, h := range histories {
+		history = append(history, h...)
+	}
+	if !linearizable(initial, history) {
+		sort.Slice(history, func(i, j int) bool { return history[i].call < history[j].call })
+		var sb strings.Builder
+		for _, o := range history {
+			sb.WriteString(o.String() + "\n")
+		}
+		t.Fatalf("history is not linearizable:\n%s", sb.String())
+	}
+}
+
+func TestLinearizability(t *testing.T) {
+	variants := map[string]func() *lockfreelist.List[int, int]{
+		"plain":   lockfreelist.NewConcurrentList[int, int],
+		"indexed": lockfreelist.NewIndexedList[int, int],
+	}
+	rounds := 500
+	if testing.Short() {
+		rounds = 50
+	}
+	for name, newList := range variants {
+		t.Run(name, func(t *testing.T) {
+			r := rand.New(rand.NewSource(1))
+			for i := 0; i < rounds; i++ {
+				runLinearizabilityRound(t, newList, r)
+			}
+		})
+	}
+}
+
+// 高并发插入、删除、遍历后，正反两个方向的链一致且与成功操作的结果相符
+func TestConcurrentStructure(t *testing.T) {
+	l := lockfreelist.NewConcurrentList[int, int]()
+	const goroutines, ops = 8, 2000
+
+	var shared []*lockfreelist.Node[int, int]
+	for i := 0; i < 100; i++ {
+		shared = append(shared, l.AddBack(-i-1, 0))
+	}
+
+	added := make([][]int, goroutines)
+	removed := make([][]int, goroutines)
+	stop := make(chan struct{})
+	var readers sync.WaitGroup
+	readers.Add(1)
+	go func() {
+		defer readers.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			for n := l.FrontNode(); n != nil; n = n.Next() {
+			}
+			for n := l.BackNode(); n != nil; n = n.Prev() {
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			r := rand.New(rand.NewSource(int64(g)))
+			var own []*lockfreelist.Node[int, int]
+			for i := 0; i < ops; i++ {
+				key := g*ops + i
+				switch r.Intn(4) {
+				case 0:
+					own = append(own, l.AddFront(key, key))
+					added[g] = append(added[g], key)
+				case 1:
+					own = append(own, l.AddBack(key, key))
+					added[g] = append(added[g], key)
+				case 2:
+					if len(own) > 0 {
+						k := r.Intn(len(own))
+						if l.Remove(own[k]) {
+							removed[g] = append(removed[g], own[k].Key())
+						}
+						own = append(own[:k], own[k+1:]...)
+					}
+				case 3:
+					n := shared[r.Intn(len(shared))]
+					if l.Remove(n) {
+						removed[g] = append(removed[g], n.Key())
+					}
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	close(stop)
+	readers.Wait()
+
+	want := map[int]bool{}
+	for _, n := range shared {
+		want[n.Key()] = true
+	}
+	for g := 0; g < goroutines; g++ {
+		for _, k := range added[g] {
+			want[k] = true
+		}
+	}
+	for g := 0; g < goroutines; g++ {
+		for _, k := range removed[g] {
+			assert.True(t, want[k], "key %d removed twice", k)
+			delete(want, k)
+		}
+	}
+
+	var forward, backward []int
+	for n := l.FrontNode(); n != nil; n = n.Next() {
+		forward = append(forward, n.Key())
+	}
+	for n := l.BackNode(); n != nil; n = n.Prev() {
+		backward = append(backward, n.Key())
+	}
+	assert.Equal(t, len(want), l.Len())
+	assert.Len(t, forward, len(want))
+	for i := range backward {
+		assert.Equal(t, forward[len(forward)-1-i], backward[i], "backward traversal differs at %d", i)
+	}
+	for _, k := range forward {
+		assert.True(t, want[k], "unexpected key %d", k)
+	}
+}
+
+func BenchmarkConcurrentAddRemove(b *testing.B) {
+	l := lockfreelist.NewConcurrentList[int, int]()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			var n *lockfreelist.Node[int, int]
+			if i%2 == 0 {
+				n = l.AddFront(i, i)
+			} else {
+				n = l.AddBack(i, i)
+			}
+			l.Remove(n)
+			i++
+		}
+	})
+}
@@ -27,15 +27,40 @@ type Plugin[K comparable, V any] interface {
 	Capabilities() PluginCap          // Capabilities 插件能力标识
 }
 
+// link 是带删除标记的指针，对应论文中的 <p, d>。Go 无法在指针低位打标记，
+// 因此每次修改都分配新的不可变 link，通过 CAS 整个 link 指针实现对 <p, d> 的原子更新
+type link[K comparable, V any] struct {
+	p *element[K, V]
+	d bool // 所在节点已被逻辑删除
+}
+
 // element 是内部节点结构
 type element[K comparable, V any] struct {
 	key   K
-	value atomic.Value
-	next  atomic.Pointer[element[K, V]]
-	prev  atomic.Pointer[element[K, V]]
+	value atomic.Pointer[V]
+	next  atomic.Pointer[link[K, V]]
+	prev  atomic.Pointer[link[K, V]] // 只是提示，可能滞后，需要时由 helpInsert 修正
 	list  *List[K, V]
 }
 
+func newLink[K comparable, V any](p *element[K, V], d bool) *link[K, V] {
+	return &link[K, V]{p: p, d: d}
+}
+
+// casLink 当 addr 当前为 <p, d> 时将其替换为 <np, nd>
+func casLink[K comparable, V any](addr *atomic.Pointer[link[K, V]], p *element[K, V], d bool, np *element[K, V], nd bool) bool {
+	cur := addr.Load()
+	if cur.p != p || cur.d != d {
+		return false
+	}
+	return addr.CompareAndSwap(cur, newLink(np, nd))
+}
+
+// deleted 判断节点是否已被逻辑删除，next 被标记即为删除的线性化点
+func (e *element[K, V]) deleted() bool {
+	return e.next.Load().d
+}
+
 // Node 是外部使用的节点
 type Node[K comparable, V any] struct {
 	element *element[K, V]
@@ -61,21 +86,26 @@ func (n *Node[K, V]) Value() V {
 		var zero V
 		return zero
 	}
-	return val.(V)
+	return *val
 }
 
-// SetValue 设置节点值并触发更新事件
+// SetValue 设置节点值并触发更新事件，节点已被移除时返回 false
 func (n *Node[K, V]) SetValue(v V) bool {
-	if n == nil || n.element == nil || n.element.list == nil {
+	if !n.IsValid() {
 		return false
 	}
 
-	oldVal := n.Value()
-	if reflect.DeepEqual(oldVal, v) {
-		return false
+	var oldVal V
+	for {
+		old := n.element.value.Load()
+		oldVal = *old
+		if reflect.DeepEqual(oldVal, v) {
+			return false
+		}
+		if n.element.value.CompareAndSwap(old, &v) {
+			break
+		}
 	}
-
-	n.element.value.Store(v)
 	list := n.element.list
 
 	// 如果链表有 LRU 能力，直接移动节点到头部
@@ -93,41 +123,35 @@ func (n *Node[K, V]) SetValue(v V) bool {
 	return true
 }
 
-// Prev 返回前驱节点
+// Prev 返回前驱节点，已被移除的节点从其后继继续向前查找
 func (n *Node[K, V]) Prev() *Node[K, V] {
-	if n == nil || n.element == nil {
-		return nil
-	}
-	prev := n.element.prev.Load()
-	if prev == nil || prev == n.element.list.sentinel {
+	if n == nil || n.element == nil || n.element.list == nil {
 		return nil
 	}
-	return &Node[K, V]{element: prev}
+	return n.element.list.wrap(n.element.list.prevOf(n.element))
 }
 
-// Next 返回后继节点
+// Next 返回后继节点，已被移除的节点仍可沿原来的后继继续遍历
 func (n *Node[K, V]) Next() *Node[K, V] {
-	if n == nil || n.element == nil {
-		return nil
-	}
-	next := n.element.next.Load()
-	if next == nil || next == n.element.list.sentinel {
+	if n == nil || n.element == nil || n.element.list == nil {
 		return nil
 	}
-	return &Node[K, V]{element: next}
+	return n.element.list.wrap(n.element.list.nextOf(n.element))
 }
 
-// IsValid 检查节点是否有效
+// IsValid 检查节点是否有效（属于某个链表且未被移除）
 func (n *Node[K, V]) IsValid() bool {
-	return n != nil && n.element != nil && n.element.list != nil
+	return n != nil && n.element != nil && n.element.list != nil && !n.element.deleted()
 }
 
-// List 是无锁双向链表实现
+// List 是无锁双向链表实现，基于 Sundell–Tsigas 算法：
+//   - next 指针构成权威的链，插入以 CAS 前驱的 next 为线性化点；
+//   - 删除先标记节点自身的 next（线性化点），再标记 prev，最后由任意线程协助摘除；
+//   - prev 指针只是提示，由 helpInsert 在需要时修正。
 type List[K comparable, V any] struct {
-	head     atomic.Pointer[element[K, V]]
-	tail     atomic.Pointer[element[K, V]]
-	sentinel *element[K, V]
-	len      atomic.Int32
+	head *element[K, V] // 头哨兵
+	tail *element[K, V] // 尾哨兵
+	len  atomic.Int32
 
 	plugins  []Plugin[K, V]
 	onInsert []func(*Node[K, V])
@@ -161,13 +185,15 @@ func (b *ListBuilder[K, V]) Build() *List[K, V] {
 
 // New 创建新链表实例
 func New[K comparable, V any](plugins ...Plugin[K, V]) *List[K, V] {
-	s := &element[K, V]{}
 	l := &List[K, V]{
-		sentinel: s,
-		index:    make(map[K]*element[K, V]),
+		index: make(map[K]*element[K, V]),
 	}
-	l.head.Store(nil)
-	l.tail.Store(nil)
+	l.head = &element[K, V]{list: l}
+	l.tail = &element[K, V]{list: l}
+	l.head.prev.Store(newLink[K, V](nil, false))
+	l.head.next.Store(newLink(l.tail, false))
+	l.tail.prev.Store(newLink(l.head, false))
+	l.tail.next.Store(newLink[K, V](nil, false))
 
 	for _, p := range plugins {
 		p.Attach(l)
@@ -186,122 +212,105 @@ func (l *List[K, V]) HasCapability(cap PluginCap) bool {
 	return false
 }
 
-// AddFront 在链表头部添加节点
-func (l *List[K, V]) AddFront(key K, val V) *Node[K, V] {
+func (l *List[K, V]) newElement(key K, val V) *element[K, V] {
 	e := &element[K, V]{key: key, list: l}
-	e.value.Store(val)
+	e.value.Store(&val)
+	return e
+}
 
-	if l.HasCapability(CapIndexed) {
-		l.indexLock.Lock()
-		l.index[key] = e
-		l.indexLock.Unlock()
+func (l *List[K, V]) wrap(e *element[K, V]) *Node[K, V] {
+	if e == nil {
+		return nil
 	}
+	return &Node[K, V]{element: e}
+}
 
+// AddFront 在链表头部添加节点
+func (l *List[K, V]) AddFront(key K, val V) *Node[K, V] {
+	e := l.newElement(key, val)
+	prev := l.head
 	for {
-		head := l.head.Load()
-		e.next.Store(head)
-		if head != nil {
-			head.prev.Store(e)
-		}
-		if l.head.CompareAndSwap(head, e) {
-			if l.tail.Load() == nil {
-				l.tail.Store(e)
-			}
-			l.len.Add(1)
-			n := &Node[K, V]{element: e}
-			for _, p := range l.plugins {
-				p.OnInsert(n)
-			}
-			for _, cb := range l.onInsert {
-				cb(n)
-			}
-			return n
+		next := prev.next.Load() // 头哨兵不会被删除，next 不会被标记
+		e.prev.Store(newLink(prev, false))
+		e.next.Store(newLink(next.p, false))
+		if prev.next.CompareAndSwap(next, newLink(e, false)) {
+			l.pushCommon(e, next.p)
+			break
 		}
 	}
+	return l.inserted(e)
 }
 
 // AddBack 在链表尾部添加节点
 func (l *List[K, V]) AddBack(key K, val V) *Node[K, V] {
-	e := &element[K, V]{key: key, list: l}
-	e.value.Store(val)
+	e := l.newElement(key, val)
+	next := l.tail
+	prev := next.prev.Load().p
+	for {
+		pn := prev.next.Load()
+		if pn.p != next || pn.d {
+			// tail.prev 滞后或前驱正在被删除，先修正再重试
+			prev = l.helpInsert(prev, next)
+			continue
+		}
+		e.prev.Store(newLink(prev, false))
+		e.next.Store(newLink(next, false))
+		if prev.next.CompareAndSwap(pn, newLink(e, false)) {
+			l.pushCommon(e, next)
+			break
+		}
+	}
+	return l.inserted(e)
+}
 
-	if l.HasCapability(CapIndexed) {
-		l.indexLock.Lock()
-		l.index[key] = e
-		l.indexLock.Unlock()
+func (l *List[K, V]) inserted(e *element[K, V]) *Node[K, V] {
+	l.len.Add(1)
+	n := &Node[K, V]{element: e}
+	for _, p := range l.plugins {
+		p.OnInsert(n)
 	}
+	for _, cb := range l.onInsert {
+		cb(n)
+	}
+	return n
+}
 
+// pushCommon 插入后把后继的 prev 指向新节点
+func (l *List[K, V]) pushCommon(e, next *element[K, V]) {
 	for {
-		tail := l.tail.Load()
-		e.prev.Store(tail)
-		if tail != nil {
-			tail.next.Store(e)
+		link1 := next.prev.Load()
+		en := e.next.Load()
+		if link1.d || en.p != next || en.d {
+			return
 		}
-		if l.tail.CompareAndSwap(tail, e) {
-			if l.head.Load() == nil {
-				l.head.Store(e)
-			}
-			l.len.Add(1)
-			n := &Node[K, V]{element: e}
-			for _, p := range l.plugins {
-				p.OnInsert(n)
+		if next.prev.CompareAndSwap(link1, newLink(e, false)) {
+			if e.prev.Load().d {
+				l.helpInsert(e, next)
 			}
-			for _, cb := range l.onInsert {
-				cb(n)
-			}
-			return n
+			return
 		}
 	}
 }
 
-// Remove 移除指定节点（乐观无锁并发）
+// Remove 移除指定节点，节点不属于该链表或已被移除时返回 false
 func (l *List[K, V]) Remove(n *Node[K, V]) bool {
 	if n == nil || n.element == nil || n.element.list != l {
 		return false
 	}
-
 	elem := n.element
-
 	for {
-		prev := elem.prev.Load()
-		next := elem.next.Load()
-
-		// 尝试断开前驱和当前节点的连接
-		if prev != nil {
-			if !prev.next.CompareAndSwap(elem, next) {
-				continue // 失败重试
-			}
-		} else {
-			// 说明是头部节点
-			if !l.head.CompareAndSwap(elem, next) {
-				continue // 失败重试
-			}
+		link1 := elem.next.Load()
+		if link1.d {
+			return false
 		}
-
-		// 尝试断开后继和当前节点的连接
-		if next != nil {
-			if !next.prev.CompareAndSwap(elem, prev) {
-				continue // 失败重试
-			}
-		} else {
-			// 是尾部节点
-			if !l.tail.CompareAndSwap(elem, prev) {
-				continue // 失败重试
-			}
+		if elem.next.CompareAndSwap(link1, newLink(link1.p, true)) {
+			l.markPrev(elem)
+			l.helpInsert(elem.prev.Load().p, link1.p) // 协助摘除并修正后继的 prev
+			break
 		}
-
-		break // 成功退出
-	}
-
-	if l.HasCapability(CapIndexed) {
-		l.indexLock.Lock()
-		delete(l.index, elem.key)
-		l.indexLock.Unlock()
 	}
 
 	l.len.Add(-1)
-	elem.list = nil
-
 	for _, p := range l.plugins {
 		p.OnRemove(n)
 	}
@@ -311,6 +320,95 @@ func (l *List[K, V]) Remove(n *Node[K, V]) bool {
 	return true
 }
 
+// markPrev 标记节点的 prev，防止之后有人再把它当作 prev 使用
+func (l *List[K, V]) markPrev(e *element[K, V]) {
+	for {
+		link1 := e.prev.Load()
+		if link1.d || e.prev.CompareAndSwap(link1, newLink(link1.p, true)) {
+			return
+		}
+	}
+}
+
+// helpInsert 从 prev 向后找到 node 的真正前驱并修正 node.prev，途中摘除已标记删除的节点，
+// 返回找到的前驱
+func (l *List[K, V]) helpInsert(prev, node *element[K, V]) *element[K, V] {
+	var last *element[K, V]
+	for {
+		if node.prev.Load().d || prev == l.tail {
+			return prev // node 自身正在被删除，无需修正
+		}
+		pn := prev.next.Load()
+		if pn.d {
+			// prev 已被删除
+			if last != nil {
+				l.markPrev(prev)
+				casLink(&last.next, prev, false, pn.p, false)
+				prev, last = last, nil
+			} else {
+				prev = prev.prev.Load().p
+			}
+			continue
+		}
+		if pn.p != node {
+			last, prev = prev, pn.p
+			continue
+		}
+		link1 := node.prev.Load()
+		if link1.d {
+			return prev
+		}
+		if node.prev.CompareAndSwap(link1, newLink(prev, false)) {
+			if prev.prev.Load().d {
+				continue
+			}
+			return prev
+		}
+	}
+}
+
+// nextOf 返回 cursor 之后第一个未删除的节点，途中协助摘除已删除的节点，到达末尾时返回 nil
+func (l *List[K, V]) nextOf(cursor *element[K, V]) *element[K, V] {
+	for cursor != l.tail {
+		cn := cursor.next.Load()
+		next := cn.p
+		d := next.next.Load().d
+		if d && !cn.d {
+			l.markPrev(next)
+			casLink(&cursor.next, next, false, next.next.Load().p, false)
+			continue
+		}
+		cursor = next
+		if cursor != l.tail && !d {
+			return cursor
+		}
+	}
+	return nil
+}
+
+// prevOf 返回 cursor 之前第一个未删除的节点，到达开头时返回 nil
+func (l *List[K, V]) prevOf(cursor *element[K, V]) *element[K, V] {
+	for cursor != l.head {
+		if cursor != l.tail && cursor.deleted() {
+			// 已删除的节点不再可靠，先前进到一个有效节点
+			if cursor = l.nextOf(cursor); cursor == nil {
+				cursor = l.tail
+			}
+			continue
+		}
+		prev := cursor.prev.Load().p
+		pn := prev.next.Load()
+		if pn.p == cursor && !pn.d {
+			if prev == l.head {
+				return nil
+			}
+			return prev
+		}
+		l.helpInsert(prev, cursor)
+	}
+	return nil
+}
+
 // OnInsert 注册插入回调
 func (l *List[K, V]) OnInsert(f func(*Node[K, V])) {
 	l.onInsert = append(l.onInsert, f)
@@ -338,30 +436,19 @@ func (l *List[K, V]) IsEmpty() bool {
 
 // FrontNode 获取链表头节点
 func (l *List[K, V]) FrontNode() *Node[K, V] {
-	e := l.head.Load()
-	if e == nil || e == l.sentinel {
-		return nil
-	}
-	return &Node[K, V]{element: e}
+	return l.wrap(l.nextOf(l.head))
 }
 
 // BackNode 获取链表尾节点
 func (l *List[K, V]) BackNode() *Node[K, V] {
-	e := l.tail.Load()
-	if e == nil || e == l.sentinel {
-		return nil
-	}
-	return &Node[K, V]{element: e}
+	return l.wrap(l.prevOf(l.tail))
 }
 
-// Traversal 遍历链表(非线程安全)
+// Traversal 遍历链表，并发修改时不会看到已删除的节点，但可能看到遍历开始后插入的节点
 func (l *List[K, V]) Traversal(visitor func(K, V) bool) {
-	for e := l.head.Load(); e != nil && e != l.sentinel; e = e.next.Load() {
-		val := e.value.Load()
-		if val != nil {
-			if !visitor(e.key, val.(V)) {
-				break
-			}
+	for e := l.nextOf(l.head); e != nil; e = l.nextOf(e) {
+		if !visitor(e.key, *e.value.Load()) {
+			break
 		}
 	}
 }
@@ -372,13 +459,13 @@ func (l *List[K, V]) Find(key K) *Node[K, V] {
 		l.indexLock.RLock()
 		e, ok := l.index[key]
 		l.indexLock.RUnlock()
-		if ok && e.list != nil {
+		if ok && !e.deleted() {
 			return &Node[K, V]{element: e}
 		}
 		return nil
 	}
 
-	for e := l.head.Load(); e != nil && e != l.sentinel; e = e.next.Load() {
+	for e := l.nextOf(l.head); e != nil; e = l.nextOf(e) {
 		if e.key == key {
 			return &Node[K, V]{element: e}
 		}
@@ -393,23 +480,19 @@ func (l *List[K, V]) Contains(key K) bool {
 
 // Clear 清空链表
 func (l *List[K, V]) Clear() {
-	for {
-		e := l.head.Load()
-		if e == nil || e == l.sentinel {
-			break
-		}
-		l.Remove(&Node[K, V]{element: e})
+	for n := l.FrontNode(); n != nil; n = l.FrontNode() {
+		l.Remove(n)
 	}
 }
 
-// MoveToFront 移动节点到链表头部
+// MoveToFront 移动节点到链表头部，实现为移除后重新插入，n 会指向新插入的节点
 func (l *List[K, V]) MoveToFront(n *Node[K, V]) bool {
 	if n == nil || n.element == nil || n.element.list != l {
 		return false
 	}
 
 	// 如果已经是头部节点，不需要移动
-	if l.head.Load() == n.element {
+	if l.nextOf(l.head) == n.element {
 		return true
 	}
 
@@ -472,7 +555,7 @@ func (p *IndexPlugin[K, V]) OnInsert(n *Node[K, V]) {
 	list.index[n.Key()] = n.element
 }
 
-// OnRemove 在移除节点时，从索引中删除该节点
+// OnRemove 在移除节点时，从索引中删除该节点（键已指向更新的节点时保留）
 func (p *IndexPlugin[K, V]) OnRemove(n *Node[K, V]) {
 	if n == nil || n.element == nil || n.element.list == nil {
 		return
@@ -480,7 +563,7 @@ func (p *IndexPlugin[K, V]) OnRemove(n *Node[K, V]) {
 	list := n.element.list
 	list.indexLock.Lock()
 	defer list.indexLock.Unlock()
-	if list.index != nil {
+	if list.index[n.Key()] == n.element {
 		delete(list.index, n.Key())
 	}
 }
@@ -0,0 +1,152 @@
+package lockfreelist
+
+import "sync/atomic"
+
+// 关于 ABA：Queue 和 Stack 每次入队/入栈都分配新节点且从不复用，
+// 只要还有协程持有旧节点的指针，GC 就不会回收它，同一地址不会以新节点的身份重新出现，
+// 因此 CAS 比较指针不会遇到 ABA 问题，无需像 C/C++ 实现那样使用带版本号的指针或危险指针。
+
+type queueNode[T any] struct {
+	value T
+	next  atomic.Pointer[queueNode[T]]
+}
+
+// Queue Michael–Scott 无锁无界队列，支持多生产者多消费者
+type Queue[T any] struct {
+	head atomic.Pointer[queueNode[T]] // 哑节点，head.next 为队首
+	tail atomic.Pointer[queueNode[T]]
+	len  atomic.Int64
+}
+
+// NewQueue 创建无锁队列
+func NewQueue[T any]() *Queue[T] {
+	q := &Queue[T]{}
+	dummy := &queueNode[T]{}
+	q.head.Store(dummy)
+	q.tail.Store(dummy)
+	return q
+}
+
+// Enqueue 入队
+func (q *Queue[T]) Enqueue(v T) {
+	n := &queueNode[T]{value: v}
+	for {
+		tail := q.tail.Load()
+		next := tail.next.Load()
+		if tail != q.tail.Load() {
+			continue
+		}
+		if next != nil {
+			// tail 落后，协助推进后重试
+			q.tail.CompareAndSwap(tail, next)
+			continue
+		}
+		if tail.next.CompareAndSwap(nil, n) {
+			q.tail.CompareAndSwap(tail, n)
+			q.len.Add(1)
+			return
+		}
+	}
+}
+
+// Dequeue 出队，队列为空时返回 false
+func (q *Queue[T]) Dequeue() (T, bool) {
+	for {
+		head := q.head.Load()
+		tail := q.tail.Load()
+		next := head.next.Load()
+		if head != q.head.Load() {
+			continue
+		}
+		if next == nil {
+			var zero T
+			return zero, false
+		}
+		if head == tail {
+			q.tail.CompareAndSwap(tail, next)
+			continue
+		}
+		if q.head.CompareAndSwap(head, next) {
+			// next 成为新的哑节点，值已取出，清空以免持有引用
+			v := next.value
+			var zero T
+			next.value = zero
+			q.len.Add(-1)
+			return v, true
+		}
+	}
+}
+
+// Len 返回队列长度，并发修改时为近似值
+func (q *Queue[T]) Len() int {
+	return int(max(q.len.Load(), 0))
+}
+
+// IsEmpty 检查队列是否为空
+func (q *Queue[T]) IsEmpty() bool {
+	return q.head.Load().next.Load() == nil
+}
+
+type stackNode[T any] struct {
+	value T
+	next  *stackNode[T] // 入栈后不再修改
+}
+
+// Stack Treiber 无锁栈，支持多生产者多消费者
+type Stack[T any] struct {
+	top atomic.Pointer[stackNode[T]]
+	len atomic.Int64
+}
+
+// NewStack 创建无锁栈
+func NewStack[T any]() *Stack[T] {
+	return &Stack[T]{}
+}
+
+// Push 入栈
+func (s *Stack[T]) Push(v T) {
+	n := &stackNode[T]{value: v}
+	for {
+		top := s.top.Load()
+		n.next = top
+		if s.top.CompareAndSwap(top, n) {
+			s.len.Add(1)
+			return
+		}
+	}
+}
+
+// Pop 出栈，栈为空时返回 false
+func (s *Stack[T]) Pop() (T, bool) {
+	for {
+		top := s.top.Load()
+		if top == nil {
+			var zero T
+			return zero, false
+		}
+		if s.top.CompareAndSwap(top, top.next) {
+			s.len.Add(-1)
+			return top.value, true
+		}
+	}
+}
+
+// Peek 查看栈顶元素，栈为空时返回 false
+func (s *Stack[T]) Peek() (T, bool) {
+	top := s.top.Load()
+	if top == nil {
+		var zero T
+		return zero, false
+	}
+	return top.value, true
+}
+
+// Len 返回栈的大小，并发修改时为近似值
+func (s *Stack[T]) Len() int {
+	return int(max(s.len.Load(), 0))
+}
+
+// IsEmpty 检查栈是否为空
+func (s *Stack[T]) IsEmpty() bool {
+	return s.top.Load() == nil
+}
@@ -0,0 +1,199 @@
+package lockfreelist_test
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/trancecho/ragnarok/lockfreelist"
+)
+
+func TestQueue(t *testing.T) {
+	q := lockfreelist.NewQueue[int]()
+	_, ok := q.Dequeue()
+	assert.False(t, ok)
+	assert.True(t, q.IsEmpty())
+
+	for i := 0; i < 5; i++ {
+		q.Enqueue(i)
+	}
+	assert.Equal(t, 5, q.Len())
+	for i := 0; i < 5; i++ {
+		v, ok := q.Dequeue()
+		assert.True(t, ok)
+		assert.Equal(t, i, v)
+	}
+	assert.True(t, q.IsEmpty())
+	assert.Equal(t, 0, q.Len())
+}
+
+func TestStack(t *testing.T) {
+	s := lockfreelist.NewStack[string]()
+	_, ok := s.Pop()
+	assert.False(t, ok)
+
+	s.Push("a")
+	s.Push("b")
+	top, _ := s.Peek()
+	assert.Equal(t, "b", top)
+	assert.Equal(t, 2, s.Len())
+	v, _ := s.Pop()
+	assert.Equal(t, "b", v)
+	v, _ = s.Pop()
+	assert.Equal(t, "a", v)
+	assert.True(t, s.IsEmpty())
+}
+
+func TestRingQueue(t *testing.T) {
+	q := lockfreelist.NewRingQueue[int](3)
+	assert.Equal(t, 4, q.Cap())
+	for i := 0; i < 4; i++ {
+		assert.True(t, q.TryEnqueue(i))
+	}
+	assert.False(t, q.TryEnqueue(4), "queue should be full")
+	assert.Equal(t, 4, q.Len())
+
+	// 多轮绕回后仍然保持 FIFO
+	for round := 0; round < 3; round++ {
+		for i := 0; i < 4; i++ {
+			v, ok := q.TryDequeue()
+			assert.True(t, ok)
+			assert.Equal(t, round*4+i, v)
+			assert.True(t, q.TryEnqueue((round+1)*4+i))
+		}
+	}
+	for i := 0; i < 4; i++ {
+		q.TryDequeue()
+	}
+	_, ok := q.TryDequeue()
+	assert.False(t, ok)
+}
+
+// checkMPMC 多个生产者写入互不相同的值，检查每个值恰好被取出一次，且同一生产者的值保持先后顺序
+func checkMPMC(t *testing.T, fifo bool, enqueue func(int), dequeue func() (int, bool)) {
+	const producers, consumers, perProducer = 4, 4, 5000
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			for i := 0; i < perProducer; i++ {
+				enqueue(p*perProducer + i)
+			}
+		}(p)
+	}
+
+	var taken atomic.Int64
+	results := make([][]int, consumers)
+	var cwg sync.WaitGroup
+	for c := 0; c < consumers; c++ {
+		cwg.Add(1)
+		go func(c int) {
+			defer cwg.Done()
+			for taken.Load() < producers*perProducer {
+				v, ok := dequeue()
+				if !ok {
+					runtime.Gosched()
+					continue
+				}
+				taken.Add(1)
+				results[c] = append(results[c], v)
+			}
+		}(c)
+	}
+	wg.Wait()
+	cwg.Wait()
+
+	seen := make([]bool, producers*perProducer)
+	for _, r := range results {
+		last := make([]int, producers)
+		for i := range last {
+			last[i] = -1
+		}
+		for _, v := range r {
+			assert.False(t, seen[v], "value %d dequeued twice", v)
+			seen[v] = true
+			p := v / perProducer
+			if fifo {
+				// 单个消费者看到的同一生产者的值必须递增
+				assert.Greater(t, v, last[p], "FIFO order violated for producer %d", p)
+			}
+			last[p] = v
+		}
+	}
+	for v, ok := range seen {
+		if !ok {
+			t.Fatalf("value %d was lost", v)
+		}
+	}
+}
+
+func TestQueueMPMC(t *testing.T) {
+	q := lockfreelist.NewQueue[int]()
+	checkMPMC(t, true, q.Enqueue, q.Dequeue)
+	assert.True(t, q.IsEmpty())
+}
+
+func TestStackMPMC(t *testing.T) {
+	s := lockfreelist.NewStack[int]()
+	checkMPMC(t, false, s.Push, s.Pop)
+	assert.True(t, s.IsEmpty())
+}
+
+func TestRingQueueMPMC(t *testing.T) {
+	q := lockfreelist.NewRingQueue[int](64)
+	enqueue := func(v int) {
+		for !q.TryEnqueue(v) {
+			runtime.Gosched()
+		}
+	}
+	checkMPMC(t, true, enqueue, q.TryDequeue)
+	assert.Equal(t, 0, q.Len())
+}
+
+// 以下基准对比无锁队列与带缓冲 channel 在多生产者多消费者下的吞吐
+func BenchmarkMPMC(b *testing.B) {
+	b.Run("chan", func(b *testing.B) {
+		ch := make(chan int, 1024)
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				ch <- 1
+				<-ch
+			}
+		})
+	})
+	b.Run("ring", func(b *testing.B) {
+		q := lockfreelist.NewRingQueue[int](1024)
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				for !q.TryEnqueue(1) {
+				}
+				for {
+					if _, ok := q.TryDequeue(); ok {
+						break
+					}
+				}
+			}
+		})
+	})
+	b.Run("queue", func(b *testing.B) {
+		q := lockfreelist.NewQueue[int]()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				q.Enqueue(1)
+				q.Dequeue()
+			}
+		})
+	})
+	b.Run("stack", func(b *testing.B) {
+		s := lockfreelist.NewStack[int]()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				s.Push(1)
+				s.Pop()
+			}
+		})
+	})
+}
@@ -0,0 +1,101 @@
+package lockfreelist
+
+import "sync/atomic"
+
+// cacheLinePad 避免入队和出队位置落在同一缓存行上造成伪共享
+type cacheLinePad [64]byte
+
+type ringCell[T any] struct {
+	seq   atomic.Uint64 // 序号，决定该槽位当前可写还是可读
+	value T
+}
+
+// RingQueue Vyukov 有界多生产者多消费者队列，容量固定且不分配内存，适合低延迟场景。
+// 每个槽位的序号等于 pos 时可写，等于 pos+1 时可读，读完后置为 pos+容量供下一轮写入
+type RingQueue[T any] struct {
+	_       cacheLinePad
+	cells   []ringCell[T]
+	mask    uint64
+	_       cacheLinePad
+	enqueue atomic.Uint64
+	_       cacheLinePad
+	dequeue atomic.Uint64
+	_       cacheLinePad
+}
+
+// NewRingQueue 创建有界队列，容量向上取整为 2 的幂（至少为 2）
+func NewRingQueue[T any](capacity int) *RingQueue[T] {
+	size := 2
+	for size < capacity {
+		size *= 2
+	}
+	q := &RingQueue[T]{
+		cells: make([]ringCell[T], size),
+		mask:  uint64(size - 1),
+	}
+	for i := range q.cells {
+		q.cells[i].seq.Store(uint64(i))
+	}
+	return q
+}
+
+// TryEnqueue 入队，队列已满时返回 false
+func (q *RingQueue[T]) TryEnqueue(v T) bool {
+	pos := q.enqueue.Load()
+	for {
+		cell := &q.cells[pos&q.mask]
+		seq := cell.seq.Load()
+		switch diff := int64(seq) - int64(pos); {
+		case diff == 0:
+			if q.enqueue.CompareAndSwap(pos, pos+1) {
+				cell.value = v
+				cell.seq.Store(pos + 1)
+				return true
+			}
+			pos = q.enqueue.Load()
+		case diff < 0:
+			return false // 该槽位上一轮的数据还未被取走
+		default:
+			pos = q.enqueue.Load() // 被其他生产者抢先
+		}
+	}
+}
+
+// TryDequeue 出队，队列为空时返回 false
+func (q *RingQueue[T]) TryDequeue() (T, bool) {
+	pos := q.dequeue.Load()
+	for {
+		cell := &q.cells[pos&q.mask]
+		seq := cell.seq.Load()
+		switch diff := int64(seq) - int64(pos+1); {
+		case diff == 0:
+			if q.dequeue.CompareAndSwap(pos, pos+1) {
+				v := cell.value
+				var zero T
+				cell.value = zero
+				cell.seq.Store(pos + q.mask + 1)
+				return v, true
+			}
+			pos = q.dequeue.Load()
+		case diff < 0:
+			var zero T
+			return zero, false
+		default:
+			pos = q.dequeue.Load()
+		}
+	}
+}
+
+// Len 返回队列中的元素个数，并发修改时为近似值
+func (q *RingQueue[T]) Len() int {
+	enq, deq := q.enqueue.Load(), q.dequeue.Load()
+	if enq < deq {
+		return 0
+	}
+	return int(enq - deq)
+}
+
+// Cap 返回容量
+func (q *RingQueue[T]) Cap() int {
+	return len(q.cells)
+}
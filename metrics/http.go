@@ -0,0 +1,37 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler 以 Prometheus 文本格式输出全部指标，可直接挂载到 /metrics
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_, _ = r.WriteTo(w)
+	})
+}
+
+// Middleware 记录每个路由的请求数和延迟：
+// http_requests_total{method,route,status} 与 http_request_duration_seconds{method,route}。
+// route 使用路由模板（c.FullPath()），未匹配任何路由的请求记为 "unmatched"，避免路径参数导致标签膨胀
+func Middleware(r *Registry) gin.HandlerFunc {
+	requests := r.CounterVec("http_requests_total", "HTTP 请求数", "method", "route", "status")
+	latency := r.HistogramVec("http_request_duration_seconds", "HTTP 请求处理耗时（秒）", "method", "route")
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		method := c.Request.Method
+		requests.With(method, route, strconv.Itoa(c.Writer.Status())).Inc()
+		latency.With(method, route).Observe(time.Since(start).Seconds())
+	}
+}
@@ -0,0 +1,226 @@
+package metrics
+
+import (
+	"bufio"
+	"math"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/trancecho/ragnarok/rollingwindows"
+)
+
+// atomicFloat 用 CAS 实现的原子浮点数
+type atomicFloat struct {
+	bits atomic.Uint64
+}
+
+func (f *atomicFloat) add(v float64) {
+	for {
+		old := f.bits.Load()
+		if f.bits.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+v)) {
+			return
+		}
+	}
+}
+
+func (f *atomicFloat) store(v float64) {
+	f.bits.Store(math.Float64bits(v))
+}
+
+func (f *atomicFloat) load() float64 {
+	return math.Float64frombits(f.bits.Load())
+}
+
+// windowSum 返回窗口内的和与次数
+func windowSum(rw *rollingwindows.RollingWindow) (sum float64, count int64) {
+	rw.Reduce(func(b *rollingwindows.Bucket) {
+		sum += b.Sum
+		count += b.Count
+	})
+	return sum, count
+}
+
+// Counter 单调递增的计数器，暴露累计值，窗口用于计算近期速率
+type Counter struct {
+	total  atomicFloat
+	win    *rollingwindows.RollingWindow
+	window float64 // 窗口长度（秒）
+}
+
+// Inc 加一
+func (c *Counter) Inc() {
+	c.Add(1)
+}
+
+// Add 增加 v，v 必须非负
+func (c *Counter) Add(v float64) {
+	if v < 0 {
+		panic("metrics: counter cannot decrease")
+	}
+	c.total.add(v)
+	c.win.Add(v)
+}
+
+// Value 返回累计值
+func (c *Counter) Value() float64 {
+	return c.total.load()
+}
+
+// Rate 返回窗口内每秒的平均增量，如 QPS
+func (c *Counter) Rate() float64 {
+	sum, _ := windowSum(c.win)
+	return sum / c.window
+}
+
+func (c *Counter) write(w *bufio.Writer, name, labels string) {
+	writeSample(w, name, labels, "", c.Value())
+}
+
+// Gauge 可增可减的仪表盘，暴露当前值，窗口记录每次设置的值用于计算近期均值
+type Gauge struct {
+	value atomicFloat
+	win   *rollingwindows.RollingWindow
+}
+
+// Set 设置当前值
+func (g *Gauge) Set(v float64) {
+	g.value.store(v)
+	g.win.Add(v)
+}
+
+// Add 在当前值上增加 delta（可为负）
+func (g *Gauge) Add(delta float64) {
+	g.value.add(delta)
+	g.win.Add(g.value.load())
+}
+
+// Value 返回当前值
+func (g *Gauge) Value() float64 {
+	return g.value.load()
+}
+
+// Mean 返回窗口内设置过的值的均值，窗口内没有设置时返回当前值
+func (g *Gauge) Mean() float64 {
+	sum, count := windowSum(g.win)
+	if count == 0 {
+		return g.Value()
+	}
+	return sum / float64(count)
+}
+
+func (g *Gauge) write(w *bufio.Writer, name, labels string) {
+	writeSample(w, name, labels, "", g.Value())
+}
+
+// Histogram 记录取值分布，以 Prometheus summary 暴露：分位数来自窗口，_sum/_count 为累计值
+type Histogram struct {
+	count     atomic.Int64
+	sum       atomicFloat
+	win       *rollingwindows.RollingWindow
+	quantiles []float64
+}
+
+// Observe 记录一个取值
+func (h *Histogram) Observe(v float64) {
+	h.count.Add(1)
+	h.sum.add(v)
+	h.win.Add(v)
+}
+
+// Quantile 返回窗口内分位数 q（0~1）的估计值
+func (h *Histogram) Quantile(q float64) float64 {
+	return h.win.Percentile(q)
+}
+
+// Count 返回累计记录次数
+func (h *Histogram) Count() int64 {
+	return h.count.Load()
+}
+
+// Sum 返回累计取值之和
+func (h *Histogram) Sum() float64 {
+	return h.sum.load()
+}
+
+func (h *Histogram) write(w *bufio.Writer, name, labels string) {
+	merged := h.win.Histogram()
+	for _, q := range h.quantiles {
+		v := math.NaN() // 窗口内没有数据时按 Prometheus 惯例输出 NaN
+		if merged.Count() > 0 {
+			v = merged.Quantile(q)
+		}
+		writeSample(w, name, labels, `quantile="`+strconv.FormatFloat(q, 'g', -1, 64)+`"`, v)
+	}
+	writeSample(w, name+"_sum", labels, "", h.Sum())
+	writeSample(w, name+"_count", labels, "", float64(h.Count()))
+}
+
+// CounterVec 按标签区分的一组计数器
+type CounterVec struct {
+	f *family
+}
+
+// With 返回标签取值对应的计数器，不存在时创建，取值顺序与注册时的标签名一致
+func (v *CounterVec) With(values ...string) *Counter {
+	return v.f.get(values).(*Counter)
+}
+
+// GaugeVec 按标签区分的一组仪表盘
+type GaugeVec struct {
+	f *family
+}
+
+// With 返回标签取值对应的仪表盘
+func (v *GaugeVec) With(values ...string) *Gauge {
+	return v.f.get(values).(*Gauge)
+}
+
+// HistogramVec 按标签区分的一组直方图
+type HistogramVec struct {
+	f *family
+}
+
+// With 返回标签取值对应的直方图
+func (v *HistogramVec) With(values ...string) *Histogram {
+	return v.f.get(values).(*Histogram)
+}
+
+// Counter 注册无标签的计数器，同名同类型时返回已有的
+func (r *Registry) Counter(name, help string) *Counter {
+	return r.CounterVec(name, help).With()
+}
+
+// CounterVec 注册带标签的计数器
+func (r *Registry) CounterVec(name, help string, labels ...string) *CounterVec {
+	window := float64(r.opts.size) * r.opts.interval.Seconds()
+	return &CounterVec{r.register(name, help, kindCounter, labels, func() collector {
+		return &Counter{win: r.newWindow(), window: window}
+	})}
+}
+
+// Gauge 注册无标签的仪表盘
+func (r *Registry) Gauge(name, help string) *Gauge {
+	return r.GaugeVec(name, help).With()
+}
+
+// GaugeVec 注册带标签的仪表盘
+func (r *Registry) GaugeVec(name, help string, labels ...string) *GaugeVec {
+	return &GaugeVec{r.register(name, help, kindGauge, labels, func() collector {
+		return &Gauge{win: r.newWindow()}
+	})}
+}
+
+// Histogram 注册无标签的直方图
+func (r *Registry) Histogram(name, help string) *Histogram {
+	return r.HistogramVec(name, help).With()
+}
+
+// HistogramVec 注册带标签的直方图
+func (r *Registry) HistogramVec(name, help string, labels ...string) *HistogramVec {
+	return &HistogramVec{r.register(name, help, kindHistogram, labels, func() collector {
+		return &Histogram{
+			win:       r.newWindow(rollingwindows.WithHistogram(r.opts.layout)),
+			quantiles: r.opts.quantiles,
+		}
+	})}
+}
@@ -0,0 +1,207 @@
+package metrics
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trancecho/ragnarok/rollingwindows"
+)
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newTestRegistry(opts ...Option) (*Registry, *fakeClock) {
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	return NewRegistry(append([]Option{WithClock(clock.Now)}, opts...)...), clock
+}
+
+func expose(r *Registry) string {
+	var sb strings.Builder
+	r.WriteTo(&sb)
+	return sb.String()
+}
+
+func TestCounterRate(t *testing.T) {
+	r, clock := newTestRegistry(WithWindow(10, time.Second))
+	c := r.Counter("jobs_total", "")
+	for i := 0; i < 10; i++ {
+		c.Add(3)
+		clock.Advance(time.Second)
+	}
+	if c.Value() != 30 || c.Rate() != 2.7 {
+		t.Errorf("value = %v, rate = %v", c.Value(), c.Rate())
+	}
+	// 窗口滑过后速率归零，累计值不变
+	clock.Advance(20 * time.Second)
+	if c.Value() != 30 || c.Rate() != 0 {
+		t.Errorf("after idle value = %v, rate = %v", c.Value(), c.Rate())
+	}
+	if r.Counter("jobs_total", "") != c {
+		t.Error("registering the same counter should return the existing one")
+	}
+}
+
+func TestGauge(t *testing.T) {
+	r, clock := newTestRegistry()
+	g := r.Gauge("queue_depth", "")
+	g.Set(10)
+	g.Add(-4)
+	if g.Value() != 6 || g.Mean() != 8 {
+		t.Errorf("value = %v, mean = %v", g.Value(), g.Mean())
+	}
+	clock.Advance(time.Minute)
+	if g.Mean() != 6 {
+		t.Errorf("mean of empty window = %v", g.Mean())
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	r, clock := newTestRegistry()
+	h := r.Histogram("latency_seconds", "")
+	for i := 1; i <= 100; i++ {
+		h.Observe(float64(i) / 1000)
+	}
+	if p := h.Quantile(0.99); math.Abs(p-0.099)/0.099 > 1.0/16 {
+		t.Errorf("p99 = %v", p)
+	}
+	clock.Advance(time.Minute)
+	h.Observe(1)
+	if h.Quantile(0.5) != 1 || h.Count() != 101 {
+		t.Errorf("p50 = %v, count = %d", h.Quantile(0.5), h.Count())
+	}
+}
+
+func TestExposition(t *testing.T) {
+	r, clock := newTestRegistry(WithQuantiles(0.5), WithLayout(rollingwindows.FixedBuckets(1, 2, 3)))
+	r.CounterVec("http_requests_total", "Requests.\nSecond line", "code", "path").With("200", `/a"b\`).Add(3)
+	r.CounterVec("http_requests_total", "Requests.\nSecond line", "code", "path").With("500", "/").Inc()
+	r.Gauge("temperature", "").Set(-1.5)
+	h := r.Histogram("size", "Sizes")
+	r.Histogram("empty", "")
+	for _, v := range []float64{1, 2, 3} {
+		h.Observe(v)
+	}
+
+	want := `# TYPE empty summary
+empty{quantile="0.5"} NaN
+empty_sum 0
+empty_count 0
+# HELP http_requests_total Requests.\nSecond line
+# TYPE http_requests_total counter
+http_requests_total{code="200",path="/a\"b\\"} 3
+http_requests_total{code="500",path="/"} 1
+# HELP size Sizes
+# TYPE size summary
+size{quantile="0.5"} 1.5
+size_sum 6
+size_count 3
+# TYPE temperature gauge
+temperature -1.5
+`
+	if got := expose(r); got != want {
+		t.Errorf("exposition:\n%s\nwant:\n%s", got, want)
+	}
+
+	// 分位数只反映窗口内的数据
+	clock.Advance(time.Minute)
+	if got := expose(r); !strings.Contains(got, `size{quantile="0.5"} NaN`) || !strings.Contains(got, "size_count 3") {
+		t.Errorf("after window:\n%s", got)
+	}
+}
+
+func TestRegisterConflicts(t *testing.T) {
+	r := NewRegistry()
+	r.CounterVec("x", "", "a")
+	for name, fn := range map[string]func(){
+		"type":         func() { r.Gauge("x", "") },
+		"labels":       func() { r.CounterVec("x", "", "b") },
+		"metric name":  func() { r.Counter("1x", "") },
+		"label name":   func() { r.CounterVec("y", "", "quantile") },
+		"label values": func() { r.CounterVec("x", "", "a").With("1", "2") },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: expected panic", name)
+				}
+			}()
+			fn()
+		}()
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	reg := NewRegistry()
+	r := gin.New()
+	r.Use(Middleware(reg))
+	r.GET("/users/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/metrics", gin.WrapH(reg.Handler()))
+
+	for _, path := range []string{"/users/1", "/users/2", "/nope"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := w.Body.String()
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Errorf("content type = %s", w.Header().Get("Content-Type"))
+	}
+	for _, line := range []string{
+		`http_requests_total{method="GET",route="/users/:id",status="200"} 2`,
+		`http_requests_total{method="GET",route="unmatched",status="404"} 1`,
+		`http_request_duration_seconds_count{method="GET",route="/users/:id"} 2`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("missing %q in:\n%s", line, body)
+		}
+	}
+}
+
+func TestConcurrentMetrics(t *testing.T) {
+	r := NewRegistry()
+	vec := r.CounterVec("ops_total", "", "worker")
+	h := r.Histogram("op_seconds", "")
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				vec.With(string(rune('a' + g%4))).Inc()
+				h.Observe(float64(i))
+				if i%100 == 0 {
+					expose(r)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	var total float64
+	for _, w := range []string{"a", "b", "c", "d"} {
+		total += vec.With(w).Value()
+	}
+	if total != 4000 || h.Count() != 4000 {
+		t.Errorf("total = %v, histogram count = %d", total, h.Count())
+	}
+}
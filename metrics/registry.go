@@ -0,0 +1,287 @@
+// Package metrics 提供基于 rollingwindows.RollingWindow 的指标注册表，
+// 支持计数器、仪表盘和直方图，以 Prometheus 文本格式暴露，并提供记录路由延迟与状态码的 gin 中间件。
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/trancecho/ragnarok/rollingwindows"
+)
+
+var (
+	metricNameRe = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	labelNameRe  = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+type kind int
+
+const (
+	kindCounter kind = iota
+	kindGauge
+	kindHistogram
+)
+
+// typeName 直方图的窗口分位数对应 Prometheus 的 summary 类型
+func (k kind) typeName() string {
+	switch k {
+	case kindCounter:
+		return "counter"
+	case kindGauge:
+		return "gauge"
+	}
+	return "summary"
+}
+
+// collector 单个带标签取值的指标
+type collector interface {
+	write(w *bufio.Writer, name, labels string)
+}
+
+type child struct {
+	values []string
+	c      collector
+}
+
+// family 同名指标，按标签取值区分子指标
+type family struct {
+	name     string
+	help     string
+	kind     kind
+	labels   []string
+	newChild func() collector
+
+	mu       sync.RWMutex
+	children map[string]*child
+}
+
+func (f *family) get(values []string) collector {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", f.name, len(f.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+	f.mu.RLock()
+	ch, ok := f.children[key]
+	f.mu.RUnlock()
+	if ok {
+		return ch.c
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if ch, ok = f.children[key]; ok {
+		return ch.c
+	}
+	ch = &child{values: slices.Clone(values), c: f.newChild()}
+	f.children[key] = ch
+	return ch.c
+}
+
+type options struct {
+	size      int
+	interval  time.Duration
+	now       func() time.Time
+	quantiles []float64
+	layout    rollingwindows.Layout
+}
+
+// Option 注册表配置项
+type Option func(*options)
+
+// WithWindow 设置指标的统计窗口：size 个桶，每个桶 interval，默认 10 × 1s
+func WithWindow(size int, interval time.Duration) Option {
+	return func(o *options) {
+		o.size = size
+		o.interval = interval
+	}
+}
+
+// WithClock 替换 time.Now，主要用于测试
+func WithClock(now func() time.Time) Option {
+	return func(o *options) {
+		o.now = now
+	}
+}
+
+// WithQuantiles 设置直方图暴露的分位数，默认 0.5、0.9、0.99
+func WithQuantiles(qs ...float64) Option {
+	return func(o *options) {
+		o.quantiles = qs
+	}
+}
+
+// WithLayout 设置直方图的桶布局，默认 LogLinearBuckets(1e-6, 1e6, 16)，相对误差 1/16
+func WithLayout(layout rollingwindows.Layout) Option {
+	return func(o *options) {
+		o.layout = layout
+	}
+}
+
+// Registry 指标注册表，并发安全
+type Registry struct {
+	opts     *options
+	mu       sync.RWMutex
+	families map[string]*family
+}
+
+// DefaultRegistry 默认注册表
+var DefaultRegistry = NewRegistry()
+
+// NewRegistry 创建注册表
+func NewRegistry(opts ...Option) *Registry {
+	o := &options{
+		size:      10,
+		interval:  time.Second,
+		now:       time.Now,
+		quantiles: []float64{0.5, 0.9, 0.99},
+		layout:    rollingwindows.LogLinearBuckets(1e-6, 1e6, 16),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return &Registry{opts: o, families: make(map[string]*family)}
+}
+
+func (r *Registry) newWindow(extra ...rollingwindows.RollingWindowOption) *rollingwindows.RollingWindow {
+	opts := append([]rollingwindows.RollingWindowOption{rollingwindows.WithClock(r.opts.now)}, extra...)
+	return rollingwindows.NewRollingWindow(r.opts.size, r.opts.interval, opts...)
+}
+
+// register 返回同名指标；已存在但类型或标签不同时 panic
+func (r *Registry) register(name, help string, k kind, labels []string, newChild func() collector) *family {
+	if !metricNameRe.MatchString(name) {
+		panic("metrics: invalid metric name " + strconv.Quote(name))
+	}
+	for _, l := range labels {
+		if !labelNameRe.MatchString(l) || l == "quantile" {
+			panic("metrics: invalid label name " + strconv.Quote(l))
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if f, ok := r.families[name]; ok {
+		if f.kind != k || !slices.Equal(f.labels, labels) {
+			panic("metrics: " + name + " already registered with a different type or labels")
+		}
+		return f
+	}
+	f := &family{
+		name:     name,
+		help:     help,
+		kind:     k,
+		labels:   slices.Clone(labels),
+		newChild: newChild,
+		children: make(map[string]*child),
+	}
+	r.families[name] = f
+	return f
+}
+
+// WriteTo 以 Prometheus 文本格式写出全部指标，按名称和标签取值排序
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.RLock()
+	families := make([]*family, 0, len(r.families))
+	for _, f := range r.families {
+		families = append(families, f)
+	}
+	r.mu.RUnlock()
+	slices.SortFunc(families, func(a, b *family) int { return strings.Compare(a.name, b.name) })
+
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	for _, f := range families {
+		f.mu.RLock()
+		children := make([]*child, 0, len(f.children))
+		for _, ch := range f.children {
+			children = append(children, ch)
+		}
+		f.mu.RUnlock()
+		if len(children) == 0 {
+			continue
+		}
+		slices.SortFunc(children, func(a, b *child) int { return slices.Compare(a.values, b.values) })
+
+		if f.help != "" {
+			fmt.Fprintf(bw, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+		}
+		fmt.Fprintf(bw, "# TYPE %s %s\n", f.name, f.kind.typeName())
+		for _, ch := range children {
+			ch.c.write(bw, f.name, formatLabels(f.labels, ch.values))
+		}
+	}
+	err := bw.Flush()
+	return cw.n, err
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
+
+// formatLabels 返回不带花括号的标签列表，如 method="GET",route="/ping"
+func formatLabels(names, values []string) string {
+	var sb strings.Builder
+	for i, n := range names {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(n)
+		sb.WriteString(`="`)
+		sb.WriteString(labelEscaper.Replace(values[i]))
+		sb.WriteByte('"')
+	}
+	return sb.String()
+}
+
+// writeSample 写出一行样本，extra 为追加的标签（如分位数）
+func writeSample(w *bufio.Writer, name, labels, extra string, v float64) {
+	w.WriteString(name)
+	if labels != "" || extra != "" {
+		w.WriteByte('{')
+		w.WriteString(labels)
+		if labels != "" && extra != "" {
+			w.WriteByte(',')
+		}
+		w.WriteString(extra)
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(v))
+	w.WriteByte('\n')
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
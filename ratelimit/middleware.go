@@ -0,0 +1,62 @@
+package ratelimit
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trancecho/ragnarok/util"
+)
+
+type middlewareOptions struct {
+	keyFunc func(c *gin.Context) string
+}
+
+// MiddlewareOption 中间件配置项
+type MiddlewareOption func(*middlewareOptions)
+
+// WithKeyFunc 自定义限流键，默认 KeyByUserOrIP
+func WithKeyFunc(f func(c *gin.Context) string) MiddlewareOption {
+	return func(o *middlewareOptions) {
+		o.keyFunc = f
+	}
+}
+
+// KeyByUserOrIP 已登录时按 util.Uid 限流，否则按客户端 IP 限流
+func KeyByUserOrIP(c *gin.Context) string {
+	if uid := util.Uid(c); uid != 0 {
+		return "uid:" + strconv.FormatUint(uint64(uid), 10)
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// Middleware 限流中间件。被拒绝时返回 429，响应体与 util.ClientError 格式一致，
+// 并设置 Retry-After（秒）和 X-RateLimit-Limit/X-RateLimit-Remaining 头。
+// 限流器出错（如 Redis 不可用）时放行请求，错误记录到 c.Errors
+func Middleware(l Limiter, opts ...MiddlewareOption) gin.HandlerFunc {
+	o := &middlewareOptions{keyFunc: KeyByUserOrIP}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return func(c *gin.Context) {
+		res, err := l.AllowN(c.Request.Context(), o.keyFunc(c), 1)
+		if err != nil {
+			_ = c.Error(err)
+			c.Next()
+			return
+		}
+		c.Header("X-RateLimit-Limit", strconv.FormatInt(res.Limit, 10))
+		c.Header("X-RateLimit-Remaining", strconv.FormatInt(res.Remaining, 10))
+		if res.Allowed {
+			c.Next()
+			return
+		}
+		retry := max(1, int64(math.Ceil(res.RetryAfter.Seconds())))
+		c.Header("Retry-After", strconv.FormatInt(retry, 10))
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+			"err_code": util.TooManyRequestsError,
+			"message":  "请求过于频繁，请稍后再试",
+		})
+	}
+}
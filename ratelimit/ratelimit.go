@@ -0,0 +1,99 @@
+// Package ratelimit 提供按键限流：基于 RollingWindow 的滑动窗口、支持突发的令牌桶、
+// 基于 Redis Lua 脚本（GCRA）的分布式限流，以及对应的 gin 中间件。
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/trancecho/ragnarok/cache"
+)
+
+// ErrExceedsLimit 单次请求的许可数超过限流器容量，永远无法满足
+var ErrExceedsLimit = errors.New("ratelimit: n exceeds limiter capacity")
+
+// Result 一次限流判定的结果
+type Result struct {
+	Allowed    bool          // 是否放行
+	Limit      int64         // 容量：窗口内上限或桶的突发容量
+	Remaining  int64         // 放行后剩余的许可数
+	RetryAfter time.Duration // 被拒绝时建议的等待时间
+}
+
+// Limiter 按键限流
+type Limiter interface {
+	// AllowN 尝试为 key 获取 n 个许可
+	AllowN(ctx context.Context, key string, n int) (Result, error)
+}
+
+// Allow 为 key 获取一个许可
+func Allow(ctx context.Context, l Limiter, key string) (Result, error) {
+	return l.AllowN(ctx, key, 1)
+}
+
+type options struct {
+	maxKeys int
+	buckets int
+	prefix  string
+}
+
+// Option 限流器配置项
+type Option func(*options)
+
+// WithMaxKeys 进程内限流器最多保留的键数，超出后按 LRU 淘汰最久未访问的键，默认 100000
+func WithMaxKeys(n int) Option {
+	return func(o *options) {
+		o.maxKeys = n
+	}
+}
+
+// WithBuckets 滑动窗口划分的桶数，越多越精确，默认 10
+func WithBuckets(n int) Option {
+	return func(o *options) {
+		o.buckets = n
+	}
+}
+
+// WithPrefix Redis 键前缀，默认 "ratelimit:"
+func WithPrefix(prefix string) Option {
+	return func(o *options) {
+		o.prefix = prefix
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{maxKeys: 100000, buckets: 10, prefix: "ratelimit:"}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// store 进程内每个键的限流状态，用 LRU 缓存限制内存
+type store[T any] struct {
+	mu     sync.Mutex // 只在未命中时用于避免重复创建
+	states *cache.Cache[string, T]
+	create func() T
+}
+
+func newStore[T any](maxKeys int, create func() T) *store[T] {
+	// 每个分片各自淘汰，键数较少时使用单个分片以保证严格的 LRU
+	shards := max(1, min(16, maxKeys/1024))
+	return &store[T]{states: cache.New[string, T](maxKeys, cache.WithShards(shards)), create: create}
+}
+
+func (s *store[T]) get(key string) T {
+	if v, ok := s.states.Get(key); ok {
+		return v
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if v, ok := s.states.Peek(key); ok {
+		return v
+	}
+	v := s.create()
+	s.states.Set(key, v)
+	return v
+}
@@ -0,0 +1,227 @@
+package ratelimit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+var ctx = context.Background()
+
+func TestSlidingWindow(t *testing.T) {
+	l := NewSlidingWindow(5, 200*time.Millisecond, WithBuckets(4))
+	for i := 0; i < 5; i++ {
+		res, err := l.Allow(ctx, "a")
+		if err != nil || !res.Allowed || res.Remaining != int64(4-i) {
+			t.Fatalf("request %d: %+v %v", i, res, err)
+		}
+	}
+	res, _ := l.Allow(ctx, "a")
+	if res.Allowed || res.RetryAfter <= 0 || res.RetryAfter > 200*time.Millisecond {
+		t.Fatalf("6th request: %+v", res)
+	}
+	if res, _ := l.Allow(ctx, "b"); !res.Allowed {
+		t.Error("keys should be limited independently")
+	}
+	if _, err := l.AllowN(ctx, "a", 6); !errors.Is(err, ErrExceedsLimit) {
+		t.Errorf("AllowN over limit returned %v", err)
+	}
+
+	// 窗口滑过之后恢复
+	time.Sleep(res.RetryAfter + 20*time.Millisecond)
+	if res, _ := l.Allow(ctx, "a"); !res.Allowed {
+		t.Errorf("should allow after RetryAfter: %+v", res)
+	}
+}
+
+func TestSlidingWindowConcurrent(t *testing.T) {
+	l := NewSlidingWindow(100, time.Hour)
+	var allowed atomic.Int64
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				if res, _ := l.Allow(ctx, "k"); res.Allowed {
+					allowed.Add(1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if allowed.Load() != 100 {
+		t.Errorf("allowed %d, want exactly 100", allowed.Load())
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	l := NewTokenBucket(10, 3)
+	now := time.Now()
+	l.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		if res, _ := l.Allow(ctx, "a"); !res.Allowed {
+			t.Fatalf("burst request %d rejected", i)
+		}
+	}
+	res, _ := l.Allow(ctx, "a")
+	if res.Allowed || res.RetryAfter != 100*time.Millisecond {
+		t.Fatalf("empty bucket: %+v", res)
+	}
+	now = now.Add(100 * time.Millisecond)
+	if res, _ := l.Allow(ctx, "a"); !res.Allowed || res.Remaining != 0 {
+		t.Fatalf("refilled token: %+v", res)
+	}
+
+	// 长时间空闲后最多积累 burst 个令牌
+	now = now.Add(time.Hour)
+	if res, _ := l.AllowN(ctx, "a", 3); !res.Allowed || res.Remaining != 0 {
+		t.Fatalf("refill should cap at burst: %+v", res)
+	}
+	if _, err := l.AllowN(ctx, "a", 4); !errors.Is(err, ErrExceedsLimit) {
+		t.Errorf("AllowN over burst returned %v", err)
+	}
+}
+
+func TestMaxKeys(t *testing.T) {
+	l := NewTokenBucket(1, 1, WithMaxKeys(2))
+	l.Allow(ctx, "a")
+	l.Allow(ctx, "b")
+	l.Allow(ctx, "c") // 淘汰 a，a 重新获得满桶
+	if res, _ := l.Allow(ctx, "a"); !res.Allowed {
+		t.Error("evicted key should start with a full bucket")
+	}
+	if l.store.states.Len() != 2 {
+		t.Errorf("store holds %d keys", l.store.states.Len())
+	}
+}
+
+func TestRedisGCRA(t *testing.T) {
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		addr = "127.0.0.1:6379"
+	}
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	defer client.Close()
+	if err := client.Ping(ctx).Err(); err != nil {
+		t.Skipf("redis not available at %s: %v", addr, err)
+	}
+
+	l := NewRedisGCRA(client, 10, time.Second, 3, WithPrefix(fmt.Sprintf("ratelimit-test:%d:", time.Now().UnixNano())))
+	for i := 0; i < 3; i++ {
+		res, err := l.Allow(ctx, "a")
+		if err != nil || !res.Allowed || res.Remaining != int64(2-i) {
+			t.Fatalf("burst request %d: %+v %v", i, res, err)
+		}
+	}
+	res, err := l.Allow(ctx, "a")
+	if err != nil || res.Allowed || res.RetryAfter <= 0 || res.RetryAfter > 100*time.Millisecond {
+		t.Fatalf("over burst: %+v %v", res, err)
+	}
+	time.Sleep(res.RetryAfter)
+	if res, _ := l.Allow(ctx, "a"); !res.Allowed {
+		t.Errorf("should allow after RetryAfter: %+v", res)
+	}
+}
+
+func TestSlidingWindowInvalid(t *testing.T) {
+	for _, window := range []time.Duration{0, -time.Second, 9} { // 默认 10 个桶，9ns 的桶间隔截断为 0
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("NewSlidingWindow(window=%v) should panic", window)
+				}
+			}()
+			NewSlidingWindow(10, window)
+		}()
+	}
+	NewSlidingWindow(10, 10) // 每桶恰好 1ns
+}
+
+func TestRedisGCRAInvalid(t *testing.T) {
+	for _, c := range []struct {
+		limit  int64
+		period time.Duration
+		burst  int
+	}{
+		{0, time.Second, 1},
+		{10, time.Second, 0},
+		{2_000_000, time.Second, 1}, // 发放间隔 500ns，按微秒截断为 0
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("NewRedisGCRA(%d, %v, %d) should panic", c.limit, c.period, c.burst)
+				}
+			}()
+			NewRedisGCRA(nil, c.limit, c.period, c.burst)
+		}()
+	}
+	NewRedisGCRA(nil, 1_000_000, time.Second, 1) // 恰好 1µs
+}
+
+type failingLimiter struct{}
+
+func (failingLimiter) AllowN(context.Context, string, int) (Result, error) {
+	return Result{}, errors.New("redis down")
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	newRouter := func(l Limiter) *gin.Engine {
+		r := gin.New()
+		r.Use(func(c *gin.Context) {
+			if c.GetHeader("X-Uid") != "" {
+				c.Set("uid", uint(7))
+			}
+		})
+		r.Use(Middleware(l))
+		r.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+		return r
+	}
+	do := func(r *gin.Engine, uid bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		if uid {
+			req.Header.Set("X-Uid", "1")
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	r := newRouter(NewTokenBucket(0.5, 2))
+	do(r, false)
+	if w := do(r, false); w.Code != http.StatusOK || w.Header().Get("X-RateLimit-Remaining") != "0" {
+		t.Fatalf("second request: %d %v", w.Code, w.Header())
+	}
+	w := do(r, false)
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "2" {
+		t.Fatalf("limited request: %d %v", w.Code, w.Header())
+	}
+	var body map[string]string
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body["err_code"] != "Error.TooManyRequests" || body["message"] == "" {
+		t.Errorf("body = %s", w.Body.String())
+	}
+	// 登录用户按 uid 限流，不受同 IP 的匿名请求影响
+	if w := do(r, true); w.Code != http.StatusOK {
+		t.Errorf("uid request limited by ip bucket: %d", w.Code)
+	}
+
+	// 限流器出错时放行
+	if w := do(newRouter(failingLimiter{}), false); w.Code != http.StatusOK {
+		t.Errorf("failing limiter should fail open: %d", w.Code)
+	}
+}
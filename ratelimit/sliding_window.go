@@ -0,0 +1,76 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/trancecho/ragnarok/rollingwindows"
+)
+
+// SlidingWindow 滑动窗口限流，每个键在任意一个窗口内最多放行 limit 个许可。
+// 窗口被划分为若干桶，过期按桶粒度进行
+type SlidingWindow struct {
+	limit    int64
+	interval time.Duration
+	store    *store[*slidingState]
+}
+
+type slidingState struct {
+	mu sync.Mutex // 保证统计和累加之间没有其他请求插入
+	rw *rollingwindows.RollingWindow
+}
+
+// NewSlidingWindow 创建滑动窗口限流器，window 内最多 limit 个许可，window 小于桶数纳秒时 panic
+func NewSlidingWindow(limit int64, window time.Duration, opts ...Option) *SlidingWindow {
+	o := newOptions(opts)
+	interval := window / time.Duration(o.buckets)
+	if interval <= 0 {
+		panic("window/buckets must be greater than 0")
+	}
+	return &SlidingWindow{
+		limit:    limit,
+		interval: interval,
+		store: newStore(o.maxKeys, func() *slidingState {
+			return &slidingState{rw: rollingwindows.NewRollingWindow(o.buckets, interval)}
+		}),
+	}
+}
+
+// Allow 为 key 获取一个许可
+func (l *SlidingWindow) Allow(ctx context.Context, key string) (Result, error) {
+	return l.AllowN(ctx, key, 1)
+}
+
+// AllowN 为 key 获取 n 个许可，被拒绝时 RetryAfter 为最早能放行的桶过期时间的上界
+func (l *SlidingWindow) AllowN(_ context.Context, key string, n int) (Result, error) {
+	if int64(n) > l.limit {
+		return Result{Limit: l.limit}, ErrExceedsLimit
+	}
+	s := l.store.get(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var used float64
+	var sums []float64 // 从旧到新
+	s.rw.Reduce(func(b *rollingwindows.Bucket) {
+		used += b.Sum
+		sums = append(sums, b.Sum)
+	})
+	if int64(used)+int64(n) <= l.limit {
+		s.rw.Add(float64(n))
+		return Result{Allowed: true, Limit: l.limit, Remaining: l.limit - int64(used) - int64(n)}, nil
+	}
+
+	// 从最旧的桶开始累计，直到释放出足够的许可
+	need := used + float64(n) - float64(l.limit)
+	retry := time.Duration(len(sums)) * l.interval
+	var freed float64
+	for i, sum := range sums {
+		if freed += sum; freed >= need {
+			retry = time.Duration(i+1) * l.interval
+			break
+		}
+	}
+	return Result{Limit: l.limit, Remaining: max(0, l.limit-int64(used)), RetryAfter: retry}, nil
+}
@@ -0,0 +1,66 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// TokenBucket 令牌桶限流，每个键的桶以 rate 个每秒的速度补充令牌，最多积累 burst 个
+type TokenBucket struct {
+	rate  float64
+	burst int64
+	store *store[*tokenState]
+	now   func() time.Time
+}
+
+type tokenState struct {
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewTokenBucket 创建令牌桶限流器，新键的桶是满的，可以立即突发 burst 个请求
+func NewTokenBucket(rate float64, burst int, opts ...Option) *TokenBucket {
+	if rate <= 0 || burst < 1 {
+		panic("rate and burst must be greater than 0")
+	}
+	o := newOptions(opts)
+	l := &TokenBucket{rate: rate, burst: int64(burst), now: time.Now}
+	l.store = newStore(o.maxKeys, func() *tokenState {
+		return &tokenState{tokens: float64(burst), last: l.now()}
+	})
+	return l
+}
+
+// Allow 为 key 获取一个令牌
+func (l *TokenBucket) Allow(ctx context.Context, key string) (Result, error) {
+	return l.AllowN(ctx, key, 1)
+}
+
+// AllowN 为 key 获取 n 个令牌，不足时不消耗并返回补足所需的时间
+func (l *TokenBucket) AllowN(_ context.Context, key string, n int) (Result, error) {
+	if int64(n) > l.burst {
+		return Result{Limit: l.burst}, ErrExceedsLimit
+	}
+	s := l.store.get(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := l.now()
+	if elapsed := now.Sub(s.last); elapsed > 0 {
+		s.tokens = math.Min(float64(l.burst), s.tokens+elapsed.Seconds()*l.rate)
+		s.last = now
+	}
+	if s.tokens >= float64(n) {
+		s.tokens -= float64(n)
+		return Result{Allowed: true, Limit: l.burst, Remaining: int64(s.tokens)}, nil
+	}
+	wait := (float64(n) - s.tokens) / l.rate
+	return Result{
+		Limit:      l.burst,
+		Remaining:  int64(s.tokens),
+		RetryAfter: time.Duration(math.Ceil(wait * float64(time.Second))),
+	}, nil
+}
@@ -0,0 +1,56 @@
+package rlog
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// clickHouseLog ClickHouse 日志表结构
+type clickHouseLog struct {
+	Time    time.Time `gorm:"column:time;type:DateTime64(3)"`
+	Level   string    `gorm:"column:level;type:LowCardinality(String)"`
+	Logger  string    `gorm:"column:logger;type:LowCardinality(String)"`
+	Message string    `gorm:"column:message;type:String"`
+	Fields  string    `gorm:"column:fields;type:String"`
+}
+
+// clickHouseTableOptions 按天分区，按级别和时间排序
+const clickHouseTableOptions = "ENGINE=MergeTree() PARTITION BY toYYYYMMDD(time) ORDER BY (level, time)"
+
+// ClickHouseSink 通过 util.InitClickHouse 返回的 gorm 连接批量写入 ClickHouse
+type ClickHouseSink struct {
+	db    *gorm.DB
+	table string
+}
+
+// NewClickHouseSink 创建 ClickHouse Sink，table 为空时使用 rlogs，表不存在时自动创建
+func NewClickHouseSink(db *gorm.DB, table string) (*ClickHouseSink, error) {
+	if table == "" {
+		table = "rlogs"
+	}
+	if err := db.Table(table).Set("gorm:table_options", clickHouseTableOptions).AutoMigrate(&clickHouseLog{}); err != nil {
+		return nil, err
+	}
+	return &ClickHouseSink{db: db, table: table}, nil
+}
+
+// WriteBatch 实现 Sink，一批日志一次 INSERT
+func (s *ClickHouseSink) WriteBatch(ctx context.Context, entries []Entry) error {
+	rows := make([]clickHouseLog, 0, len(entries))
+	for _, e := range entries {
+		fields, err := marshalFields(e.Fields)
+		if err != nil {
+			return err
+		}
+		rows = append(rows, clickHouseLog{
+			Time:    e.Time,
+			Level:   e.Level.String(),
+			Logger:  e.Logger,
+			Message: e.Message,
+			Fields:  string(fields),
+		})
+	}
+	return s.db.WithContext(ctx).Table(s.table).Create(&rows).Error
+}
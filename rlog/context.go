@@ -0,0 +1,155 @@
+package rlog
+
+import (
+	"context"
+	"encoding/hex"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"github.com/trancecho/ragnarok/util"
+)
+
+// RequestIDHeader 请求 ID 的请求头与响应头
+const RequestIDHeader = "X-Request-ID"
+
+// 日志字段名
+const (
+	RequestIDKey = "request_id"
+	UidKey       = "uid"
+	TraceIDKey   = "trace_id"
+	SpanIDKey    = "span_id"
+)
+
+// sentryTagKeys 上报 Sentry 时额外设为 tag 的字段
+var sentryTagKeys = []string{RequestIDKey, TraceIDKey}
+
+type requestIDKey struct{}
+
+type uidKey struct{}
+
+// WithRequestID 返回携带请求 ID 的 context
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID 返回 context 中的请求 ID，不存在时返回空串
+func RequestID(ctx context.Context) string {
+	if c, ok := ctx.(*gin.Context); ok {
+		if id := c.GetString(RequestIDKey); id != "" {
+			return id
+		}
+		if c.Request == nil {
+			return ""
+		}
+		ctx = c.Request.Context()
+	}
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// WithUid 返回携带用户 ID 的 context，gin.Context 中由鉴权中间件设置的 uid 无需再调用
+func WithUid(ctx context.Context, uid uint) context.Context {
+	return context.WithValue(ctx, uidKey{}, uid)
+}
+
+// Ctx 用默认日志器返回带有 ctx 中 request_id、uid、trace_id、span_id 的子日志器，
+// ctx 可以是 *gin.Context 或 c.Request.Context()
+func Ctx(ctx context.Context) *Instance {
+	return Default().Ctx(ctx)
+}
+
+// Ctx 返回带有 ctx 中 request_id、uid、trace_id、span_id 的子日志器
+func (l *Instance) Ctx(ctx context.Context) *Instance {
+	if ctx == nil {
+		return l
+	}
+	var fields []zap.Field
+	if id := RequestID(ctx); id != "" {
+		fields = append(fields, zap.String(RequestIDKey, id))
+	}
+
+	var uid uint
+	if c, ok := ctx.(*gin.Context); ok {
+		uid = util.Uid(c)
+		if c.Request != nil {
+			ctx = c.Request.Context()
+		}
+	}
+	if uid == 0 {
+		uid, _ = ctx.Value(uidKey{}).(uint)
+	}
+	if uid != 0 {
+		fields = append(fields, zap.Uint(UidKey, uid))
+	}
+
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		fields = append(fields, zap.String(TraceIDKey, sc.TraceID().String()), zap.String(SpanIDKey, sc.SpanID().String()))
+	}
+	return l.With(fields...)
+}
+
+// RequestIDMiddleware 为每个请求分配请求 ID：沿用合法的 X-Request-ID 请求头，否则生成 UUIDv7，
+// 写入 c.Request.Context() 与 gin.Context 并在响应头中返回。
+// 请求头中带有 W3C traceparent 且上游没有创建 span 时，解析出 trace_id 和 span_id 供 Ctx 使用
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = util.GenerateUUIDv7()
+		}
+
+		ctx := WithRequestID(c.Request.Context(), id)
+		if !trace.SpanContextFromContext(ctx).IsValid() {
+			if sc, ok := parseTraceparent(c.GetHeader("traceparent")); ok {
+				ctx = trace.ContextWithRemoteSpanContext(ctx, sc)
+			}
+		}
+		c.Request = c.Request.WithContext(ctx)
+		c.Set(RequestIDKey, id)
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+// validRequestID 只接受长度有限的可见 ASCII，避免日志注入
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// parseTraceparent 解析 W3C traceparent：version-traceid-spanid-flags
+func parseTraceparent(h string) (trace.SpanContext, bool) {
+	parts := strings.Split(strings.TrimSpace(h), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || len(parts[3]) != 2 {
+		return trace.SpanContext{}, false
+	}
+	traceID, err := trace.TraceIDFromHex(parts[1])
+	if err != nil {
+		return trace.SpanContext{}, false
+	}
+	spanID, err := trace.SpanIDFromHex(parts[2])
+	if err != nil {
+		return trace.SpanContext{}, false
+	}
+	flags, err := hex.DecodeString(parts[3])
+	if err != nil {
+		return trace.SpanContext{}, false
+	}
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.TraceFlags(flags[0]),
+		Remote:     true,
+	})
+	return sc, sc.IsValid()
+}
@@ -0,0 +1,162 @@
+package rlog
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// observe 把全局 logger 替换为可观察的 logger
+func observe(t *testing.T) *observer.ObservedLogs {
+	t.Helper()
+	core, logs := observer.New(DebugLevel)
+	old := SetDefault(&Instance{
+		zl:  zap.New(core, zap.AddCaller(), zap.AddCallerSkip(2)),
+		out: &outputs{minLevel: FatalLevel + 1},
+	})
+	t.Cleanup(func() { SetDefault(old) })
+	return logs
+}
+
+func serve(r *gin.Engine, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+	for k, v := range header {
+		req.Header.Set(k, v[0])
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestRequestIDMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logs := observe(t)
+
+	r := gin.New()
+	r.Use(RequestIDMiddleware(), func(c *gin.Context) {
+		c.Set("uid", uint(42))
+	})
+	r.GET("/ping", func(c *gin.Context) {
+		Ctx(c).Infoln("from gin", String("k", "v"))
+		Ctx(c.Request.Context()).Warnln("from request")
+		c.String(http.StatusOK, RequestID(c))
+	})
+
+	w := serve(r, nil)
+	id := w.Header().Get(RequestIDHeader)
+	if len(id) != 36 || id[14] != '7' {
+		t.Fatalf("expected generated UUIDv7, got %q", id)
+	}
+	if w.Body.String() != id {
+		t.Errorf("RequestID(c) = %q, want %q", w.Body.String(), id)
+	}
+
+	entries := logs.TakeAll()
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 log entries, got %d", len(entries))
+	}
+	m := entries[0].ContextMap()
+	if m[RequestIDKey] != id || m[UidKey] != uint64(42) || m["k"] != "v" {
+		t.Errorf("gin context fields = %v", m)
+	}
+	if _, ok := m[TraceIDKey]; ok {
+		t.Errorf("unexpected trace id without traceparent: %v", m)
+	}
+	if !strings.HasSuffix(entries[0].Caller.File, "context_test.go") {
+		t.Errorf("caller = %s, want context_test.go", entries[0].Caller.File)
+	}
+	// 鉴权中间件只写 gin.Context，request context 中只有请求 ID
+	m = entries[1].ContextMap()
+	if m[RequestIDKey] != id {
+		t.Errorf("request context fields = %v", m)
+	}
+	if _, ok := m[UidKey]; ok {
+		t.Errorf("unexpected uid in request context: %v", m)
+	}
+}
+
+func TestRequestIDHeader(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(RequestIDMiddleware())
+	r.GET("/ping", func(c *gin.Context) {})
+
+	w := serve(r, http.Header{RequestIDHeader: {"upstream-id-1"}})
+	if got := w.Header().Get(RequestIDHeader); got != "upstream-id-1" {
+		t.Errorf("incoming id not preserved: %q", got)
+	}
+
+	for _, bad := range []string{"has space", "line\nbreak", strings.Repeat("a", 129)} {
+		w = serve(r, http.Header{RequestIDHeader: {bad}})
+		if got := w.Header().Get(RequestIDHeader); got == bad || len(got) != 36 {
+			t.Errorf("invalid id %q should be replaced, got %q", bad, got)
+		}
+	}
+}
+
+func TestTraceparent(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logs := observe(t)
+
+	r := gin.New()
+	r.Use(RequestIDMiddleware())
+	r.GET("/ping", func(c *gin.Context) {
+		Ctx(c).Errln("boom")
+	})
+	serve(r, http.Header{"Traceparent": {"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}})
+
+	entries := logs.TakeAll()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 log entry, got %d", len(entries))
+	}
+	m := entries[0].ContextMap()
+	if m[TraceIDKey] != "4bf92f3577b34da6a3ce929d0e0e4736" || m[SpanIDKey] != "00f067aa0ba902b7" {
+		t.Errorf("trace fields = %v", m)
+	}
+
+	for _, bad := range []string{
+		"",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-zz-01",
+	} {
+		if _, ok := parseTraceparent(bad); ok {
+			t.Errorf("parseTraceparent(%q) should fail", bad)
+		}
+	}
+}
+
+func TestCtxWith(t *testing.T) {
+	logs := observe(t)
+
+	ctx := WithUid(WithRequestID(context.Background(), "req-1"), 7)
+	l := Ctx(ctx).With(String("module", "auth"))
+	l.Debugln("a")
+	l.Println("b", Int("n", 1))
+	Ctx(context.Background()).Infoln("c")
+
+	entries := logs.TakeAll()
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 log entries, got %d", len(entries))
+	}
+	if entries[0].Level != zapcore.DebugLevel || entries[1].Level != zapcore.InfoLevel {
+		t.Errorf("levels = %v, %v", entries[0].Level, entries[1].Level)
+	}
+	m := entries[1].ContextMap()
+	if m[RequestIDKey] != "req-1" || m[UidKey] != uint64(7) || m["module"] != "auth" || m["n"] != int64(1) {
+		t.Errorf("fields = %v", m)
+	}
+	if len(entries[2].Context) != 0 {
+		t.Errorf("background context should add no fields: %v", entries[2].Context)
+	}
+	if len(l.Fields()) != 3 {
+		t.Errorf("Fields() = %v", l.Fields())
+	}
+}
@@ -0,0 +1,313 @@
+package rlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/trancecho/ragnarok/util"
+)
+
+// SamplingConfig 采样配置：每个 Tick 内同级别同消息的日志先输出 Initial 条，之后每 Thereafter 条输出一条。
+// 只对不高于 MaxLevel 的日志生效，只影响控制台和文件，不影响 Sink
+type SamplingConfig struct {
+	Initial    int           `json:"initial"`    // 默认 100
+	Thereafter int           `json:"thereafter"` // 默认 100
+	Tick       time.Duration `json:"tick"`       // 默认 1s
+	MaxLevel   string        `json:"max_level"`  // 默认 debug
+}
+
+// LevelState 日志器当前的级别配置，也是级别接口的请求与响应体
+type LevelState struct {
+	Console Level            `json:"console"`
+	File    Level            `json:"file"`
+	Names   map[string]Level `json:"names,omitempty"` // 按日志器名称覆盖，对名称及其子名称生效
+}
+
+// nameLevels 按名称覆盖的级别，写时复制
+type nameLevels struct {
+	m   map[string]Level
+	min Level // m 中的最低级别，m 为空时为 FatalLevel + 1
+}
+
+// levelControl 控制台、文件的可变级别以及按名称覆盖的级别
+type levelControl struct {
+	console zap.AtomicLevel
+	file    zap.AtomicLevel
+	mu      sync.Mutex // 串行化对 names 的修改
+	names   atomic.Pointer[nameLevels]
+}
+
+func newLevelControl(console, file Level) *levelControl {
+	lc := &levelControl{console: zap.NewAtomicLevelAt(console), file: zap.NewAtomicLevelAt(file)}
+	lc.names.Store(&nameLevels{min: FatalLevel + 1})
+	return lc
+}
+
+// effective 返回 name 的级别：最长匹配的覆盖级别，没有覆盖时为 base
+func (lc *levelControl) effective(name string, base Level) Level {
+	nl := lc.names.Load()
+	if len(nl.m) == 0 {
+		return base
+	}
+	for name != "" {
+		if l, ok := nl.m[name]; ok {
+			return l
+		}
+		i := strings.LastIndexByte(name, '.')
+		if i < 0 {
+			break
+		}
+		name = name[:i]
+	}
+	return base
+}
+
+// update 修改按名称覆盖的级别，level 为 nil 时删除
+func (lc *levelControl) update(changes map[string]*Level) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	m := maps.Clone(lc.names.Load().m)
+	if m == nil {
+		m = make(map[string]Level)
+	}
+	for name, l := range changes {
+		if l == nil {
+			delete(m, name)
+		} else {
+			m[name] = *l
+		}
+	}
+	nl := &nameLevels{m: m, min: FatalLevel + 1}
+	for _, l := range m {
+		nl.min = min(nl.min, l)
+	}
+	lc.names.Store(nl)
+}
+
+func (lc *levelControl) state() LevelState {
+	return LevelState{
+		Console: lc.console.Level(),
+		File:    lc.file.Level(),
+		Names:   maps.Clone(lc.names.Load().m),
+	}
+}
+
+// levelCore 用可变级别和按名称覆盖的级别过滤，内部 core 本身不过滤
+type levelCore struct {
+	zapcore.Core
+	base zap.AtomicLevel
+	lc   *levelControl
+}
+
+func (c *levelCore) Enabled(l Level) bool {
+	return l >= min(c.base.Level(), c.lc.names.Load().min)
+}
+
+// Level 实现 zapcore.LevelOf 使用的接口
+func (c *levelCore) Level() Level {
+	return min(c.base.Level(), c.lc.names.Load().min)
+}
+
+func (c *levelCore) With(fields []zap.Field) zapcore.Core {
+	return &levelCore{Core: c.Core.With(fields), base: c.base, lc: c.lc}
+}
+
+func (c *levelCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if ent.Level < c.lc.effective(ent.LoggerName, c.base.Level()) {
+		return ce
+	}
+	return ce.AddCore(ent, c.Core)
+}
+
+// sampledCore 不高于 maxLevel 的日志经过采样，其余直接输出
+type sampledCore struct {
+	zapcore.Core
+	sampled  zapcore.Core
+	maxLevel Level
+}
+
+// newSampledCore maxLevel 为解析后的 cfg.MaxLevel
+func newSampledCore(core zapcore.Core, cfg SamplingConfig, maxLevel Level) zapcore.Core {
+	if cfg.Initial <= 0 {
+		cfg.Initial = 100
+	}
+	if cfg.Thereafter <= 0 {
+		cfg.Thereafter = 100
+	}
+	if cfg.Tick <= 0 {
+		cfg.Tick = time.Second
+	}
+	return &sampledCore{
+		Core:     core,
+		sampled:  zapcore.NewSamplerWithOptions(core, cfg.Tick, cfg.Initial, cfg.Thereafter),
+		maxLevel: maxLevel,
+	}
+}
+
+func (c *sampledCore) With(fields []zap.Field) zapcore.Core {
+	return &sampledCore{Core: c.Core.With(fields), sampled: c.sampled.With(fields), maxLevel: c.maxLevel}
+}
+
+func (c *sampledCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if ent.Level <= c.maxLevel {
+		return c.sampled.Check(ent, ce)
+	}
+	return c.Core.Check(ent, ce)
+}
+
+// parseLevel 解析级别名称，为空时返回 def
+func parseLevel(s string, def Level) (Level, error) {
+	if s == "" {
+		return def, nil
+	}
+	l, err := zapcore.ParseLevel(s)
+	if err != nil {
+		return def, fmt.Errorf("rlog: %w", err)
+	}
+	return l, nil
+}
+
+// parseNameLevels 解析按名称覆盖的级别
+func parseNameLevels(levels map[string]string) (map[string]*Level, error) {
+	out := make(map[string]*Level, len(levels))
+	for name, s := range levels {
+		l, err := zapcore.ParseLevel(s)
+		if err != nil {
+			return nil, fmt.Errorf("rlog: level of %q: %w", name, err)
+		}
+		out[name] = &l
+	}
+	return out, nil
+}
+
+// SetConsoleLevel 修改控制台级别
+func (l *Instance) SetConsoleLevel(level Level) {
+	l.out.levels.console.SetLevel(level)
+}
+
+// SetFileLevel 修改文件级别
+func (l *Instance) SetFileLevel(level Level) {
+	l.out.levels.file.SetLevel(level)
+}
+
+// SetNameLevel 覆盖名称为 name 及其子名称（name.xxx）的日志器在控制台和文件中的级别
+func (l *Instance) SetNameLevel(name string, level Level) {
+	l.out.levels.update(map[string]*Level{name: &level})
+}
+
+// ResetNameLevel 删除 name 的级别覆盖
+func (l *Instance) ResetNameLevel(name string) {
+	l.out.levels.update(map[string]*Level{name: nil})
+}
+
+// Levels 返回当前的级别配置
+func (l *Instance) Levels() LevelState {
+	return l.out.levels.state()
+}
+
+// levelRequest 修改级别的请求体，省略的字段保持不变，names 中值为 null 的名称删除覆盖
+type levelRequest struct {
+	Console *Level            `json:"console"`
+	File    *Level            `json:"file"`
+	Names   map[string]*Level `json:"names"`
+}
+
+// LevelHandler 查看和修改默认日志器级别的 HTTP 接口：
+// GET 返回 LevelState；PUT 提交 {"console":"debug","names":{"order":"debug","pay":null}}，返回修改后的 LevelState。
+// 接口可以修改线上日志量，注册时应加上鉴权，gin 中使用 gin.WrapH(rlog.LevelHandler())
+func LevelHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Default().serveLevels(w, r)
+	})
+}
+
+// LevelHandler 与包级 LevelHandler 相同，作用于 l
+func (l *Instance) LevelHandler() http.Handler {
+	return http.HandlerFunc(l.serveLevels)
+}
+
+func (l *Instance) serveLevels(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut, http.MethodPost:
+		var req levelRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10)).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{
+				"err_code": util.QueryParamError,
+				"message":  "invalid level request: " + err.Error(),
+			})
+			return
+		}
+		if req.Console != nil {
+			l.SetConsoleLevel(*req.Console)
+		}
+		if req.File != nil {
+			l.SetFileLevel(*req.File)
+		}
+		if len(req.Names) > 0 {
+			l.out.levels.update(req.Names)
+		}
+	default:
+		w.Header().Set("Allow", "GET, PUT, POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(l.Levels())
+}
+
+// ReloadLevels 从 viper 读取 rlog.level、rlog.file_level、rlog.levels 并应用到默认日志器，
+// 未设置的项保持不变，rlog.levels 会整体替换按名称覆盖的级别；任一项无效时不做任何修改
+func ReloadLevels() error {
+	l := Default()
+	st := l.Levels()
+	console, err := parseLevel(viper.GetString("rlog.level"), st.Console)
+	if err != nil {
+		return err
+	}
+	file, err := parseLevel(viper.GetString("rlog.file_level"), st.File)
+	if err != nil {
+		return err
+	}
+	var changes map[string]*Level
+	if viper.IsSet("rlog.levels") {
+		if changes, err = parseNameLevels(viper.GetStringMapString("rlog.levels")); err != nil {
+			return err
+		}
+		for name := range st.Names {
+			if _, ok := changes[name]; !ok {
+				changes[name] = nil
+			}
+		}
+	}
+
+	l.SetConsoleLevel(console)
+	l.SetFileLevel(file)
+	if len(changes) > 0 {
+		l.out.levels.update(changes)
+	}
+	return nil
+}
+
+// WatchLevels 监听配置文件变化并调用 ReloadLevels。
+// viper 只保留一个 OnConfigChange 回调，项目已有回调时应在其中调用 ReloadLevels 而不是使用本函数
+func WatchLevels() {
+	viper.OnConfigChange(func(_ fsnotify.Event) {
+		if err := ReloadLevels(); err != nil {
+			Errln("failed to reload log levels", Err(err))
+		}
+	})
+	viper.WatchConfig()
+}
@@ -0,0 +1,192 @@
+package rlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func newBufferLogger(t *testing.T, cfg Config) (*Instance, *bytes.Buffer) {
+	t.Helper()
+	var buf bytes.Buffer
+	l, err := New(cfg, WithConsole(&buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	return l, &buf
+}
+
+func TestRuntimeLevel(t *testing.T) {
+	l, buf := newBufferLogger(t, Config{Mode: "prod"})
+
+	l.Debugln("hidden")
+	l.SetConsoleLevel(DebugLevel)
+	l.Debugln("shown")
+	l.SetConsoleLevel(ErrorLevel)
+	l.Warnln("hidden too")
+
+	out := buf.String()
+	if strings.Contains(out, "hidden") || !strings.Contains(out, "shown") {
+		t.Errorf("console output = %q", out)
+	}
+	if st := l.Levels(); st.Console != ErrorLevel || st.File != DebugLevel {
+		t.Errorf("levels = %+v", st)
+	}
+}
+
+func TestNameLevel(t *testing.T) {
+	l, buf := newBufferLogger(t, Config{Mode: "prod", Levels: map[string]string{"noisy": "error"}})
+
+	order := l.Named("order")
+	pay := order.Named("pay")
+	l.SetNameLevel("order", DebugLevel)
+	pay.Debugln("pay debug")
+	order.Debugln("order debug")
+	l.Named("orders").Debugln("other debug")
+	l.Debugln("root debug")
+	l.Named("noisy").Warnln("noisy warn")
+	l.Named("noisy").Errln("noisy error")
+
+	out := buf.String()
+	for _, want := range []string{"pay debug", "order debug", "noisy error"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in %q", want, out)
+		}
+	}
+	for _, unwanted := range []string{"other debug", "root debug", "noisy warn"} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("unexpected %q in %q", unwanted, out)
+		}
+	}
+
+	l.ResetNameLevel("order")
+	buf.Reset()
+	pay.Debugln("after reset")
+	if buf.Len() != 0 {
+		t.Errorf("override should be removed: %q", buf.String())
+	}
+	if names := l.Levels().Names; len(names) != 1 || names["noisy"] != ErrorLevel {
+		t.Errorf("names = %v", names)
+	}
+}
+
+func TestFileLevel(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "app.log")
+	l, _ := newBufferLogger(t, Config{Mode: "dev", EnableFile: true, LogFile: filename, FileLevel: "warn"})
+
+	l.Infoln("info")
+	l.SetFileLevel(InfoLevel)
+	l.Infoln("info after change")
+	l.Close()
+
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(b)), "\n"); len(lines) != 1 || !strings.Contains(lines[0], "info after change") {
+		t.Errorf("file = %q", b)
+	}
+}
+
+func TestInvalidLevelConfig(t *testing.T) {
+	for _, cfg := range []Config{
+		{Level: "verbose"},
+		{FileLevel: "loud"},
+		{Levels: map[string]string{"order": "x"}},
+		{Sampling: &SamplingConfig{MaxLevel: "x"}},
+	} {
+		if _, err := New(cfg); err == nil {
+			t.Errorf("New(%+v) should fail", cfg)
+		}
+	}
+}
+
+func TestSampling(t *testing.T) {
+	l, buf := newBufferLogger(t, Config{Mode: "dev", Sampling: &SamplingConfig{Initial: 2, Thereafter: 1000, Tick: time.Minute}})
+
+	for i := 0; i < 10; i++ {
+		l.Debugln("hot path")
+		l.Infoln("important")
+	}
+	out := buf.String()
+	if n := strings.Count(out, "hot path"); n != 2 {
+		t.Errorf("sampled debug lines = %d, want 2", n)
+	}
+	if n := strings.Count(out, "important"); n != 10 {
+		t.Errorf("info lines = %d, want 10", n)
+	}
+}
+
+func TestLevelHandler(t *testing.T) {
+	l, _ := newBufferLogger(t, Config{Mode: "prod"})
+	h := l.LevelHandler()
+
+	do := func(method, body string) (*httptest.ResponseRecorder, LevelState) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(method, "/log/level", strings.NewReader(body)))
+		var st LevelState
+		if w.Code == http.StatusOK {
+			if err := json.Unmarshal(w.Body.Bytes(), &st); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return w, st
+	}
+
+	if w, st := do(http.MethodGet, ""); w.Code != http.StatusOK || st.Console != InfoLevel {
+		t.Errorf("GET = %d %+v", w.Code, st)
+	}
+	w, st := do(http.MethodPut, `{"console":"debug","names":{"order":"debug","pay":"error"}}`)
+	if w.Code != http.StatusOK || st.Console != DebugLevel || st.File != DebugLevel || len(st.Names) != 2 {
+		t.Errorf("PUT = %d %s", w.Code, w.Body)
+	}
+	w, st = do(http.MethodPut, `{"names":{"order":null}}`)
+	if w.Code != http.StatusOK || len(st.Names) != 1 || st.Names["pay"] != ErrorLevel {
+		t.Errorf("PUT delete = %d %s", w.Code, w.Body)
+	}
+	if w, _ := do(http.MethodPut, `{"console":"verbose"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("invalid level = %d, want 400", w.Code)
+	}
+	if l.Levels().Console != DebugLevel {
+		t.Error("invalid request should not change levels")
+	}
+	if w, _ := do(http.MethodDelete, ""); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("DELETE = %d, want 405", w.Code)
+	}
+}
+
+func TestReloadLevels(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	l, _ := newBufferLogger(t, Config{Mode: "prod", Levels: map[string]string{"old": "debug"}})
+	old := SetDefault(l)
+	defer SetDefault(old)
+
+	viper.Set("rlog.level", "warn")
+	viper.Set("rlog.levels", map[string]any{"order": "debug"})
+	if err := ReloadLevels(); err != nil {
+		t.Fatal(err)
+	}
+	st := l.Levels()
+	if st.Console != WarnLevel || st.File != DebugLevel || len(st.Names) != 1 || st.Names["order"] != DebugLevel {
+		t.Errorf("levels = %+v", st)
+	}
+
+	viper.Set("rlog.level", "error")
+	viper.Set("rlog.file_level", "nope")
+	if err := ReloadLevels(); err == nil {
+		t.Error("invalid file level should fail")
+	}
+	if l.Levels().Console != WarnLevel {
+		t.Error("failed reload should not change levels")
+	}
+}
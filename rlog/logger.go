@@ -0,0 +1,355 @@
+package rlog
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gorm.io/gorm"
+)
+
+// Instance 日志器实例，With、Named、Ctx 派生的子日志器与根日志器共享文件和 Sink
+type Instance struct {
+	zl     *zap.Logger // 跳过日志方法和 log 两层，使 caller 指向业务代码
+	name   string
+	fields []zap.Field // With 添加的字段，zap 已通过 zl.With 持有，这里供 Sink 和 Sentry 使用
+	out    *outputs
+}
+
+// outputs 根日志器创建的输出
+type outputs struct {
+	sentry     bool
+	redactor   *Redactor
+	levels     *levelControl
+	sinks      []*LogWriter
+	minLevel   Level // 所有 Sink 的最低级别
+	file       *rotateWriter
+	stopReopen func()
+	closeOnce  sync.Once
+	closeErr   error
+}
+
+type options struct {
+	db      *gorm.DB
+	console zapcore.WriteSyncer
+}
+
+// Option New 的配置项
+type Option func(*options)
+
+// WithDB 设置 EnableMysql 使用的数据库连接
+func WithDB(db *gorm.DB) Option {
+	return func(o *options) {
+		o.db = db
+	}
+}
+
+// WithConsole 替换控制台输出，默认 os.Stdout
+func WithConsole(w io.Writer) Option {
+	return func(o *options) {
+		o.console = zapcore.AddSync(w)
+	}
+}
+
+// New 按配置创建日志器，不会修改包级默认日志器，需要时调用 SetDefault
+func New(cfg Config, opts ...Option) (*Instance, error) {
+	o := &options{console: zapcore.AddSync(os.Stdout)}
+	for _, opt := range opts {
+		opt(o)
+	}
+	// 控制台默认 dev 模式全打印，prod 模式只打印 info 及以上；文件默认全打印
+	consoleLevel := InfoLevel
+	if cfg.Mode == "dev" {
+		consoleLevel = DebugLevel
+	}
+	consoleLevel, err := parseLevel(cfg.Level, consoleLevel)
+	if err != nil {
+		return nil, err
+	}
+	fileLevel, err := parseLevel(cfg.FileLevel, DebugLevel)
+	if err != nil {
+		return nil, err
+	}
+	nameLevels, err := parseNameLevels(cfg.Levels)
+	if err != nil {
+		return nil, err
+	}
+	var samplingLevel Level
+	if cfg.Sampling != nil {
+		if samplingLevel, err = parseLevel(cfg.Sampling.MaxLevel, DebugLevel); err != nil {
+			return nil, err
+		}
+	}
+	out := &outputs{levels: newLevelControl(consoleLevel, fileLevel), redactor: cfg.Redactor}
+	out.levels.update(nameLevels)
+	if out.redactor == nil {
+		out.redactor = DefaultRedactor()
+	}
+
+	// 初始化 Sentry
+	if cfg.EnableSentry && cfg.SentryDSN != "" {
+		if err := sentry.Init(sentry.ClientOptions{Dsn: cfg.SentryDSN}); err != nil {
+			return nil, fmt.Errorf("rlog: init sentry: %w", err)
+		}
+		out.sentry = true
+	}
+
+	sinkCfgs := slices.Clone(cfg.Sinks)
+	if cfg.EnableMysql && o.db != nil {
+		mysqlSink, err := NewMySQLSink(o.db)
+		if err != nil {
+			return nil, fmt.Errorf("rlog: migrate rlog table: %w", err)
+		}
+		sinkCfgs = append(sinkCfgs, SinkConfig{Name: "mysql", Sink: mysqlSink})
+	}
+
+	// 各 core 的级别由 levelCore 控制，可在运行时修改
+	cores := []zapcore.Core{&levelCore{
+		Core: newRedactCore(zapcore.NewCore(newConsoleEncoder(), o.console, DebugLevel), out.redactor),
+		base: out.levels.console,
+		lc:   out.levels,
+	}}
+
+	// 文件输出 - 全打印
+	if cfg.EnableFile && cfg.LogFile != "" {
+		w, err := newRotateWriter(cfg.LogFile, cfg.Rotate, nil)
+		if err != nil {
+			return nil, err
+		}
+		out.file = w
+		cores = append(cores, &levelCore{
+			Core: newRedactCore(zapcore.NewCore(newFileEncoder(), w, DebugLevel), out.redactor),
+			base: out.levels.file,
+			lc:   out.levels,
+		})
+	}
+
+	core := zapcore.NewTee(cores...)
+	if cfg.Sampling != nil {
+		core = newSampledCore(core, *cfg.Sampling, samplingLevel)
+	}
+	zl := zap.New(core, zap.AddCaller(), zap.AddCallerSkip(2), zap.AddStacktrace(ErrorLevel))
+	// 写入器和信号处理的错误日志没有有意义的 caller
+	internal := zl.WithOptions(zap.WithCaller(false))
+
+	// 初始化异步日志写入器
+	out.minLevel = FatalLevel + 1
+	for _, sc := range sinkCfgs {
+		if sc.Sink == nil {
+			continue
+		}
+		if sc.Redactor == nil {
+			sc.Redactor = out.redactor
+		}
+		w := newLogWriter(sc)
+		w.errLog = internal
+		w.Start()
+		out.sinks = append(out.sinks, w)
+		out.minLevel = min(out.minLevel, w.cfg.Level)
+	}
+	if out.file != nil {
+		out.stopReopen = watchReopen(out.file, internal)
+	}
+	return &Instance{zl: zl, out: out}, nil
+}
+
+// newNop 返回丢弃所有日志的日志器，作为 Init 之前的默认日志器
+func newNop() *Instance {
+	return &Instance{zl: zap.NewNop(), out: &outputs{levels: newLevelControl(InfoLevel, DebugLevel), minLevel: FatalLevel + 1}}
+}
+
+// With 返回追加了字段的子日志器
+func (l *Instance) With(fields ...zap.Field) *Instance {
+	if len(fields) == 0 {
+		return l
+	}
+	c := *l
+	c.zl = l.zl.With(fields...)
+	c.fields = l.merge(fields)
+	return &c
+}
+
+// Named 返回带名称的子日志器，多次调用以 . 连接，名称写入日志的 logger 字段
+func (l *Instance) Named(name string) *Instance {
+	c := *l
+	c.zl = l.zl.Named(name)
+	if l.name == "" {
+		c.name = name
+	} else {
+		c.name = l.name + "." + name
+	}
+	return &c
+}
+
+// Fields 返回 With 添加的字段
+func (l *Instance) Fields() []zap.Field {
+	return l.fields
+}
+
+// Zap 返回底层的 zap.Logger
+func (l *Instance) Zap() *zap.Logger {
+	return l.zl.WithOptions(zap.AddCallerSkip(-2))
+}
+
+func (l *Instance) merge(fields []zap.Field) []zap.Field {
+	if len(fields) == 0 {
+		return l.fields
+	}
+	if len(l.fields) == 0 {
+		return fields
+	}
+	out := make([]zap.Field, 0, len(l.fields)+len(fields))
+	return append(append(out, l.fields...), fields...)
+}
+
+func (l *Instance) Debugln(msg string, fields ...zap.Field) { l.log(DebugLevel, msg, fields) }
+func (l *Instance) Warnln(msg string, fields ...zap.Field)  { l.log(WarnLevel, msg, fields) }
+func (l *Instance) Println(msg string, fields ...zap.Field) { l.log(InfoLevel, msg, fields) }
+func (l *Instance) Infoln(msg string, fields ...zap.Field)  { l.log(InfoLevel, msg, fields) }
+func (l *Instance) Errln(msg string, fields ...zap.Field)   { l.log(ErrorLevel, msg, fields) }
+func (l *Instance) Fatalln(msg string, fields ...zap.Field) { l.log(FatalLevel, msg, fields) }
+
+// log 写入 zap、Sink 和 Sentry，只能由日志方法直接调用以保证 caller 正确
+func (l *Instance) log(level Level, msg string, fields []zap.Field) {
+	if level >= l.out.minLevel || (l.out.sentry && level >= ErrorLevel) {
+		all := l.merge(fields)
+		l.dispatch(level, msg, all)
+		if l.out.sentry {
+			sendToSentry(level, l.out.redactor.String(msg), l.out.redactor.fields(all)...)
+		}
+	}
+	if level == FatalLevel {
+		// zap 写完 Fatal 日志后退出进程，先写完缓冲的日志
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		_ = l.out.stopSinks(ctx)
+		cancel()
+	}
+	if ce := l.zl.Check(level, msg); ce != nil {
+		ce.Write(fields...)
+	}
+}
+
+// dispatch 把日志分发给级别满足的 Sink，Entry 只编码一次
+func (l *Instance) dispatch(level Level, msg string, fields []zap.Field) {
+	if level < l.out.minLevel {
+		return
+	}
+	e := newEntry(level, msg, fields)
+	e.Logger = l.name
+	// 相邻 Sink 通常共用同一个 Redactor，复用上一次的结果
+	var (
+		lastRedactor *Redactor
+		redacted     Entry
+	)
+	for _, w := range l.out.sinks {
+		if level < w.cfg.Level {
+			continue
+		}
+		if w.cfg.Redactor != lastRedactor || lastRedactor == nil {
+			lastRedactor, redacted = w.cfg.Redactor, w.cfg.Redactor.entry(e)
+		}
+		w.enqueue(redacted)
+	}
+}
+
+// Rotate 立即轮转日志文件，未启用文件日志时不做任何事
+func (l *Instance) Rotate() error {
+	if l.out.file == nil {
+		return nil
+	}
+	return l.out.file.Rotate()
+}
+
+// Reopen 重新打开日志文件，收到 SIGHUP 时也会自动调用
+func (l *Instance) Reopen() error {
+	if l.out.file == nil {
+		return nil
+	}
+	return l.out.file.Reopen()
+}
+
+// Stats 返回各 Sink 的累计计数，键为 SinkConfig.Name
+func (l *Instance) Stats() map[string]SinkStats {
+	out := make(map[string]SinkStats, len(l.out.sinks))
+	for _, w := range l.out.sinks {
+		out[w.cfg.Name] = w.Stats()
+	}
+	return out
+}
+
+// shutdownTimeout Close 与 Fatalln 写入缓冲日志的期限
+const shutdownTimeout = 5 * time.Second
+
+// Close 关闭日志器，最多等待 5s 写入缓冲的日志；子日志器共享输出，关闭任意一个即全部关闭
+func (l *Instance) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	return l.CloseContext(ctx)
+}
+
+// CloseContext 与 Close 相同，ctx 到期时放弃未写入的日志并返回 ctx.Err()，重复调用返回第一次的结果
+func (l *Instance) CloseContext(ctx context.Context) error {
+	l.out.closeOnce.Do(func() {
+		l.out.closeErr = l.out.stopSinks(ctx)
+		_ = l.zl.Sync()
+		if l.out.stopReopen != nil {
+			l.out.stopReopen()
+		}
+		if l.out.file != nil {
+			_ = l.out.file.Close()
+		}
+	})
+	return l.out.closeErr
+}
+
+// stopSinks 并行关闭所有写入器，共享同一个期限
+func (o *outputs) stopSinks(ctx context.Context) error {
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	for _, w := range o.sinks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := w.StopContext(ctx); err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return firstErr
+}
+
+// std 包级函数使用的默认日志器
+var std atomic.Pointer[Instance]
+
+func init() {
+	std.Store(newNop())
+}
+
+// Default 返回默认日志器，Init 之前为不输出任何内容的空日志器
+func Default() *Instance {
+	return std.Load()
+}
+
+// SetDefault 替换默认日志器并返回之前的日志器，调用方负责关闭返回值
+func SetDefault(l *Instance) *Instance {
+	if l == nil {
+		l = newNop()
+	}
+	return std.Swap(l)
+}
@@ -0,0 +1,111 @@
+package rlog
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNopBeforeInit(t *testing.T) {
+	old := SetDefault(nil)
+	defer SetDefault(old)
+
+	Debugln("debug")
+	Infoln("info", String("k", "v"))
+	Errln("error")
+	Ctx(nil).Warnln("warn")
+	Default().Named("x").With(Int("n", 1)).Println("child")
+	if err := Rotate(); err != nil {
+		t.Error(err)
+	}
+	if len(Stats()) != 0 {
+		t.Error("nop logger should have no sinks")
+	}
+	if Zap() == nil || Logger() == nil {
+		t.Error("Zap() and Logger() should not be nil before Init")
+	}
+}
+
+func TestNewLogger(t *testing.T) {
+	var console bytes.Buffer
+	sink := &memorySink{}
+	l, err := New(Config{Mode: "dev", Sinks: []SinkConfig{{Sink: sink, Level: DebugLevel}}}, WithConsole(&console))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	child := l.Named("order").With(String("module", "pay")).Named("refund")
+	child.Debugln("hello", Int("n", 1))
+	l.Infoln("root")
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	out := console.String()
+	if !strings.Contains(out, "order.refund") || !strings.Contains(out, `"module": "pay"`) {
+		t.Errorf("console output = %q", out)
+	}
+	if !strings.Contains(out, "rlog/logger_test.go") {
+		t.Errorf("caller should point to the test file: %q", out)
+	}
+
+	entries := sink.entries()
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if e := entries[0]; e.Logger != "order.refund" || e.Fields["module"] != "pay" || e.Fields["n"] != int64(1) {
+		t.Errorf("child entry = %+v", e)
+	}
+	if e := entries[1]; e.Logger != "" || len(e.Fields) != 0 {
+		t.Errorf("root entry should not inherit child fields: %+v", e)
+	}
+	if len(l.Fields()) != 0 || len(child.Fields()) != 1 {
+		t.Errorf("fields: root %v, child %v", l.Fields(), child.Fields())
+	}
+	// 子日志器共享输出，重复关闭无副作用
+	if err := child.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFacadeCaller(t *testing.T) {
+	var console bytes.Buffer
+	l, err := New(Config{Mode: "dev"}, WithConsole(&console))
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := SetDefault(l)
+	defer SetDefault(old)
+
+	Infoln("facade")
+	Ctx(nil).Infoln("ctx")
+	Zap().Info("raw")
+	for _, line := range strings.Split(strings.TrimSpace(console.String()), "\n") {
+		if !strings.Contains(line, "rlog/logger_test.go") {
+			t.Errorf("caller should point to the test file: %q", line)
+		}
+	}
+}
+
+func TestInitErrors(t *testing.T) {
+	dir := t.TempDir()
+	blocker := filepath.Join(dir, "file")
+	if err := os.WriteFile(blocker, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := Config{Mode: "prod", EnableFile: true, LogFile: filepath.Join(blocker, "app.log")}
+	if _, err := New(cfg, WithConsole(io.Discard)); err == nil {
+		t.Error("New should fail when the log directory cannot be created")
+	}
+
+	prev := Default()
+	if err := Init(cfg, nil); err == nil {
+		t.Error("Init should return the error")
+	}
+	if Default() != prev {
+		t.Error("failed Init should keep the previous default logger")
+	}
+}
@@ -0,0 +1,59 @@
+package rlog
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// rlog 结构体用于存储日志信息
+type rlog struct {
+	Level   string         `gorm:"column:level"`
+	Logger  string         `gorm:"column:logger;size:128;index"`
+	Message string         `gorm:"column:message"`
+	Time    string         `gorm:"column:time"`
+	Fields  datatypes.JSON `gorm:"column:fields"`
+}
+
+// MySQLSink 写入 MySQL 的 rlogs 表，每批一条 INSERT
+type MySQLSink struct {
+	db *gorm.DB
+}
+
+// NewMySQLSink 创建 MySQL Sink 并自动迁移日志表
+func NewMySQLSink(db *gorm.DB) (*MySQLSink, error) {
+	if err := db.AutoMigrate(&rlog{}); err != nil {
+		return nil, err
+	}
+	return &MySQLSink{db: db}, nil
+}
+
+// WriteBatch 实现 Sink
+func (s *MySQLSink) WriteBatch(ctx context.Context, entries []Entry) error {
+	rows := make([]rlog, 0, len(entries))
+	for _, e := range entries {
+		fields, err := marshalFields(e.Fields)
+		if err != nil {
+			return err
+		}
+		rows = append(rows, rlog{
+			Level:   e.Level.String(),
+			Logger:  e.Logger,
+			Message: e.Message,
+			Time:    e.Time.Format(time.RFC3339),
+			Fields:  datatypes.JSON(fields),
+		})
+	}
+	return s.db.WithContext(ctx).Create(&rows).Error
+}
+
+// marshalFields 序列化字段，没有字段时为 {}
+func marshalFields(fields map[string]any) ([]byte, error) {
+	if fields == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(fields)
+}
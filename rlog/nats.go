@@ -0,0 +1,28 @@
+package rlog
+
+import (
+	"context"
+
+	"github.com/trancecho/ragnarok/rnats"
+)
+
+// publisher rnats.Client 中 NATSSink 用到的方法
+type publisher interface {
+	Publish(ctx context.Context, subject string, data interface{}) error
+}
+
+// NATSSink 通过 rnats.Client 发布到 JetStream，每批一条消息，消息体为 Entry 的 JSON 数组
+type NATSSink struct {
+	client  publisher
+	subject string
+}
+
+// NewNATSSink 创建 NATS Sink，subject 需要已被某个 stream 覆盖，可先调用 client.EnsureStream
+func NewNATSSink(client *rnats.Client, subject string) *NATSSink {
+	return &NATSSink{client: client, subject: subject}
+}
+
+// WriteBatch 实现 Sink
+func (s *NATSSink) WriteBatch(ctx context.Context, entries []Entry) error {
+	return s.client.Publish(ctx, s.subject, entries)
+}
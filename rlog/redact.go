@@ -0,0 +1,315 @@
+package rlog
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// DefaultRedactKeys 默认脱敏的字段名，按小写包含匹配，如 old_password、X-Auth-Token
+var DefaultRedactKeys = []string{
+	"password", "passwd", "pwd", "secret", "token", "authorization",
+	"cookie", "api_key", "apikey", "access_key", "private_key",
+}
+
+// DefaultRedactPatterns 默认脱敏的取值：JWT（如 util.GenerateToken 生成的 token）、Bearer 凭证和大陆手机号
+var DefaultRedactPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`),
+	regexp.MustCompile(`(?i)bearer\s+[A-Za-z0-9._~+/-]+=*`),
+	regexp.MustCompile(`\b1[3-9]\d{9}\b`),
+}
+
+// redactMask 打码后的取值
+const redactMask = "***"
+
+// RedactOption Redactor 配置项
+type RedactOption func(*Redactor)
+
+// WithRedactKeys 添加按字段名脱敏的规则，字段名包含任一 key（不区分大小写）时整个取值被替换
+func WithRedactKeys(keys ...string) RedactOption {
+	return func(r *Redactor) {
+		for _, k := range keys {
+			r.keys = append(r.keys, strings.ToLower(k))
+		}
+	}
+}
+
+// WithRedactPatterns 添加按取值脱敏的规则，字符串中匹配的部分被替换，也作用于日志消息
+func WithRedactPatterns(patterns ...*regexp.Regexp) RedactOption {
+	return func(r *Redactor) {
+		r.patterns = append(r.patterns, patterns...)
+	}
+}
+
+// WithRedactHash 用 HMAC-SHA256 摘要代替打码，相同取值得到相同摘要，便于关联排查而不暴露原值
+func WithRedactHash(salt string) RedactOption {
+	return func(r *Redactor) {
+		r.hash = true
+		r.salt = []byte(salt)
+	}
+}
+
+// Redactor 在日志输出前对敏感字段脱敏，并发安全
+type Redactor struct {
+	keys     []string
+	patterns []*regexp.Regexp
+	hash     bool
+	salt     []byte
+}
+
+// NewRedactor 创建脱敏器，不带规则时不做任何处理，可用于关闭脱敏
+func NewRedactor(opts ...RedactOption) *Redactor {
+	r := &Redactor{}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// DefaultRedactor 使用 DefaultRedactKeys 与 DefaultRedactPatterns 打码的脱敏器
+func DefaultRedactor(opts ...RedactOption) *Redactor {
+	return NewRedactor(append([]RedactOption{
+		WithRedactKeys(DefaultRedactKeys...),
+		WithRedactPatterns(DefaultRedactPatterns...),
+	}, opts...)...)
+}
+
+// enabled 没有规则时跳过脱敏
+func (r *Redactor) enabled() bool {
+	return r != nil && (len(r.keys) > 0 || len(r.patterns) > 0)
+}
+
+func (r *Redactor) matchKey(key string) bool {
+	if len(r.keys) == 0 {
+		return false
+	}
+	key = strings.ToLower(key)
+	for _, k := range r.keys {
+		if strings.Contains(key, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// replace 返回 s 的替换值：打码或摘要
+func (r *Redactor) replace(s string) string {
+	if !r.hash {
+		return redactMask
+	}
+	mac := hmac.New(sha256.New, r.salt)
+	mac.Write([]byte(s))
+	return "sha256:" + hex.EncodeToString(mac.Sum(nil))[:16]
+}
+
+// String 替换 s 中匹配的部分
+func (r *Redactor) String(s string) string {
+	for _, p := range r.patterns {
+		if p.MatchString(s) {
+			s = p.ReplaceAllStringFunc(s, r.replace)
+		}
+	}
+	return s
+}
+
+// whole 替换字段名命中规则的整个取值
+func (r *Redactor) whole(v any) string {
+	if !r.hash {
+		return redactMask
+	}
+	s, ok := v.(string)
+	if !ok {
+		b, err := json.Marshal(v)
+		if err != nil {
+			b = []byte(fmt.Sprint(v))
+		}
+		s = string(b)
+	}
+	return r.replace(s)
+}
+
+// value 对任意取值脱敏，未修改时返回原值与 false。
+// 结构体等非通用类型先经 JSON 转换为 map/slice，以便处理嵌套的字段
+func (r *Redactor) value(v any) (any, bool) {
+	switch x := v.(type) {
+	case nil, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, uintptr,
+		float32, float64, complex64, complex128, time.Time, time.Duration, []byte:
+		return v, false
+	case string:
+		s := r.String(x)
+		return s, s != x
+	case map[string]any:
+		return r.redactMap(x)
+	case []any:
+		var out []any
+		for i, e := range x {
+			ne, changed := r.value(e)
+			if changed && out == nil {
+				out = slices.Clone(x)
+			}
+			if out != nil {
+				out[i] = ne
+			}
+		}
+		if out == nil {
+			return x, false
+		}
+		return out, true
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		s := fmt.Sprint(v)
+		if ns := r.String(s); ns != s {
+			return ns, true
+		}
+		return v, false
+	}
+	var generic any
+	if err := json.Unmarshal(b, &generic); err != nil {
+		return v, false
+	}
+	if nv, changed := r.value(generic); changed {
+		return nv, true
+	}
+	return v, false
+}
+
+// redactMap 返回脱敏后的副本，未修改时返回原 map
+func (r *Redactor) redactMap(m map[string]any) (map[string]any, bool) {
+	var out map[string]any
+	for k, v := range m {
+		var nv any
+		changed := false
+		if r.matchKey(k) {
+			nv, changed = r.whole(v), true
+		} else {
+			nv, changed = r.value(v)
+		}
+		if !changed {
+			continue
+		}
+		if out == nil {
+			out = make(map[string]any, len(m))
+			for k2, v2 := range m {
+				out[k2] = v2
+			}
+		}
+		out[k] = nv
+	}
+	if out == nil {
+		return m, false
+	}
+	return out, true
+}
+
+// fields 对 zap 字段脱敏，未修改的字段保持原样
+func (r *Redactor) fields(fields []zap.Field) []zap.Field {
+	var out []zap.Field
+	for i, f := range fields {
+		nf, changed := r.field(f)
+		if changed && out == nil {
+			out = make([]zap.Field, 0, len(fields)+len(nf))
+			out = append(out, fields[:i]...)
+		}
+		switch {
+		case changed:
+			out = append(out, nf...)
+		case out != nil:
+			out = append(out, f)
+		}
+	}
+	if out == nil {
+		return fields
+	}
+	return out
+}
+
+// field 单个字段可能编码为多个键（如 error 的 errorVerbose），修改时按键名排序返回
+func (r *Redactor) field(f zap.Field) ([]zap.Field, bool) {
+	switch f.Type {
+	case zapcore.BoolType, zapcore.DurationType, zapcore.Float32Type, zapcore.Float64Type,
+		zapcore.Int8Type, zapcore.Int16Type, zapcore.Int32Type, zapcore.Int64Type,
+		zapcore.Uint8Type, zapcore.Uint16Type, zapcore.Uint32Type, zapcore.Uint64Type, zapcore.UintptrType,
+		zapcore.TimeType, zapcore.TimeFullType, zapcore.SkipType:
+		if !r.matchKey(f.Key) {
+			return nil, false
+		}
+	case zapcore.StringType:
+		if !r.matchKey(f.Key) {
+			s := r.String(f.String)
+			if s == f.String {
+				return nil, false
+			}
+			return []zap.Field{zap.String(f.Key, s)}, true
+		}
+	}
+
+	enc := zapcore.NewMapObjectEncoder()
+	f.AddTo(enc)
+	m, changed := r.redactMap(enc.Fields)
+	if !changed {
+		return nil, false
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	out := make([]zap.Field, 0, len(keys))
+	for _, k := range keys {
+		out = append(out, zap.Any(k, m[k]))
+	}
+	return out, true
+}
+
+// entry 返回脱敏后的日志条目，未修改时返回原条目
+func (r *Redactor) entry(e Entry) Entry {
+	if !r.enabled() {
+		return e
+	}
+	e.Message = r.String(e.Message)
+	if m, changed := r.redactMap(e.Fields); changed {
+		e.Fields = m
+	}
+	return e
+}
+
+// redactCore 在写入前对消息和字段脱敏
+type redactCore struct {
+	zapcore.Core
+	r *Redactor
+}
+
+// newRedactCore r 没有规则时直接返回 core
+func newRedactCore(core zapcore.Core, r *Redactor) zapcore.Core {
+	if !r.enabled() {
+		return core
+	}
+	return &redactCore{Core: core, r: r}
+}
+
+func (c *redactCore) With(fields []zap.Field) zapcore.Core {
+	return &redactCore{Core: c.Core.With(c.r.fields(fields)), r: c.r}
+}
+
+func (c *redactCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *redactCore) Write(ent zapcore.Entry, fields []zap.Field) error {
+	ent.Message = c.r.String(ent.Message)
+	return c.Core.Write(ent, c.r.fields(fields))
+}
@@ -0,0 +1,139 @@
+package rlog
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+const testJWT = "eyJhbGciOiJIUzI1NiJ9.eyJ1aWQiOjF9.c2lnbmF0dXJl"
+
+type loginForm struct {
+	Username string            `json:"username"`
+	Password string            `json:"password"`
+	Profile  map[string]string `json:"profile"`
+}
+
+func TestRedactorString(t *testing.T) {
+	r := DefaultRedactor()
+	got := r.String("user 13812345678 logged in with Bearer abc.def and " + testJWT)
+	if strings.Contains(got, "13812345678") || strings.Contains(got, "abc.def") || strings.Contains(got, testJWT) {
+		t.Errorf("String() = %q", got)
+	}
+	if got := r.String("order 20240101123456"); got != "order 20240101123456" {
+		t.Errorf("long numbers should not match the phone pattern: %q", got)
+	}
+	if got := NewRedactor().String(testJWT); got != testJWT {
+		t.Errorf("empty redactor should be a no-op: %q", got)
+	}
+}
+
+func TestRedactNestedAny(t *testing.T) {
+	sink := &memorySink{}
+	l, err := New(Config{Mode: "dev", Sinks: []SinkConfig{{Sink: sink, Level: DebugLevel}}}, WithConsole(&bytes.Buffer{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	form := loginForm{Username: "alice", Password: "hunter2", Profile: map[string]string{"phone": "13812345678", "city": "杭州"}}
+	l.Infoln("login "+testJWT,
+		Any("form", form),
+		Any("headers", map[string]any{"Authorization": "Bearer abc", "meta": map[string]any{"api_key": "k-1"}}),
+		String("user_token", "t-1"),
+		Int("pwd_len", 7),
+	)
+	l.Close()
+
+	entries := sink.entries()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+	e := entries[0]
+	if e.Message != "login "+redactMask {
+		t.Errorf("message = %q", e.Message)
+	}
+	f, ok := e.Fields["form"].(map[string]any)
+	if !ok {
+		t.Fatalf("form = %#v", e.Fields["form"])
+	}
+	profile := f["profile"].(map[string]any)
+	if f["username"] != "alice" || f["password"] != redactMask || profile["phone"] != redactMask || profile["city"] != "杭州" {
+		t.Errorf("form = %#v", f)
+	}
+	h := e.Fields["headers"].(map[string]any)
+	if h["Authorization"] != redactMask || h["meta"].(map[string]any)["api_key"] != redactMask {
+		t.Errorf("headers = %#v", h)
+	}
+	if e.Fields["user_token"] != redactMask || e.Fields["pwd_len"] != redactMask {
+		t.Errorf("fields = %#v", e.Fields)
+	}
+	// 原始对象不受影响
+	if form.Password != "hunter2" || form.Profile["phone"] != "13812345678" {
+		t.Errorf("original value was modified: %+v", form)
+	}
+}
+
+func TestRedactHash(t *testing.T) {
+	r := DefaultRedactor(WithRedactHash("salt"))
+	a, b := r.String("call 13812345678"), r.String("13812345678 again")
+	ha := strings.TrimPrefix(a, "call ")
+	if !strings.HasPrefix(ha, "sha256:") || !strings.HasPrefix(b, ha) {
+		t.Errorf("same value should hash the same: %q, %q", a, b)
+	}
+	if other := DefaultRedactor(WithRedactHash("pepper")).String("13812345678"); other == ha {
+		t.Error("different salts should give different digests")
+	}
+	e := r.entry(Entry{Fields: map[string]any{"password": "p", "secret": "p"}})
+	if e.Fields["password"] != e.Fields["secret"] || e.Fields["password"] == "p" {
+		t.Errorf("fields = %#v", e.Fields)
+	}
+}
+
+func TestRedactPerSink(t *testing.T) {
+	masked, raw, custom := &memorySink{}, &memorySink{}, &memorySink{}
+	var console bytes.Buffer
+	l, err := New(Config{Mode: "dev", Sinks: []SinkConfig{
+		{Name: "masked", Sink: masked, Level: DebugLevel},
+		{Name: "raw", Sink: raw, Level: DebugLevel, Redactor: NewRedactor()},
+		{Name: "custom", Sink: custom, Level: DebugLevel, Redactor: NewRedactor(
+			WithRedactKeys("id_card"),
+			WithRedactPatterns(regexp.MustCompile(`\d{17}[\dXx]`)),
+		)},
+	}}, WithConsole(&console))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Infoln("bind 13812345678", String("password", "hunter2"), String("id_card", "110101199003070011"))
+	l.Close()
+
+	if e := masked.entries()[0]; e.Message != "bind "+redactMask || e.Fields["password"] != redactMask || e.Fields["id_card"] != "110101199003070011" {
+		t.Errorf("masked = %+v", e)
+	}
+	if e := raw.entries()[0]; e.Message != "bind 13812345678" || e.Fields["password"] != "hunter2" {
+		t.Errorf("raw = %+v", e)
+	}
+	if e := custom.entries()[0]; e.Message != "bind 13812345678" || e.Fields["password"] != "hunter2" || e.Fields["id_card"] != redactMask {
+		t.Errorf("custom = %+v", e)
+	}
+	if out := console.String(); strings.Contains(out, "hunter2") || strings.Contains(out, "13812345678") {
+		t.Errorf("console should use the logger's redactor: %q", out)
+	}
+}
+
+func TestRedactConsoleAndChildFields(t *testing.T) {
+	var console bytes.Buffer
+	l, err := New(Config{Mode: "dev", Redactor: NewRedactor(WithRedactKeys("session"))}, WithConsole(&console))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.With(String("session_id", "s-1")).Infoln("hi", Any("req", map[string]any{"session": "s-2", "path": "/login"}), String("password", "visible"))
+	l.Close()
+
+	out := console.String()
+	if strings.Contains(out, "s-1") || strings.Contains(out, "s-2") || !strings.Contains(out, "/login") {
+		t.Errorf("console output = %q", out)
+	}
+	if !strings.Contains(out, "visible") {
+		t.Errorf("Config.Redactor should replace the default rules: %q", out)
+	}
+}
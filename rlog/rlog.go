@@ -2,17 +2,11 @@ package rlog
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
-	"fmt"
 	"log"
-	"os"
-	"path/filepath"
-	"sync"
 	"time"
 
 	"github.com/spf13/viper"
-	"gorm.io/datatypes"
 	"gorm.io/gorm"
 
 	"github.com/getsentry/sentry-go"
@@ -25,6 +19,7 @@ type Level = zapcore.Level
 const (
 	DebugLevel = zapcore.DebugLevel
 	InfoLevel  = zapcore.InfoLevel
+	WarnLevel  = zapcore.WarnLevel
 	ErrorLevel = zapcore.ErrorLevel
 	FatalLevel = zapcore.FatalLevel
 )
@@ -36,98 +31,15 @@ type Config struct {
 	SentryDSN    string `json:"sentry_dsn"`
 	EnableSentry bool   `json:"enable_sentry"` // Sentry开关
 	EnableMysql  bool   `json:"enable_mysql"`  // MySQL日志开关
-}
-
-var logger *zap.Logger
-var dbx *gorm.DB
-var logWriter *LogWriter
-
-// rlog 结构体用于存储日志信息
-type rlog struct {
-	Level   string         `gorm:"column:level"`
-	Message string         `gorm:"column:message"`
-	Time    string         `gorm:"column:time"`
-	Fields  datatypes.JSON `gorm:"column:fields"`
-}
-
-// logEntry 日志条目
-type logEntry struct {
-	level  Level
-	msg    string
-	fields []zap.Field
-}
-
-// LogWriter 异步日志写入器
-type LogWriter struct {
-	ch     chan logEntry
-	db     *gorm.DB
-	wg     sync.WaitGroup
-	ctx    context.Context
-	cancel context.CancelFunc
-}
-
-// newLogWriter 创建日志写入器
-func newLogWriter(db *gorm.DB, bufferSize int) *LogWriter {
-	ctx, cancel := context.WithCancel(context.Background())
-	return &LogWriter{
-		ch:     make(chan logEntry, bufferSize),
-		db:     db,
-		ctx:    ctx,
-		cancel: cancel,
-	}
-}
-
-// Start 启动后台写入协程
-func (lw *LogWriter) Start() {
-	lw.wg.Add(1)
-	go lw.run()
-}
-
-// run 后台处理协程
-func (lw *LogWriter) run() {
-	defer lw.wg.Done()
-	for {
-		select {
-		case entry := <-lw.ch:
-			save2MysqlDirect(lw.db, entry.level, entry.msg, entry.fields...)
-		case <-lw.ctx.Done():
-			// 处理剩余的日志
-			for {
-				select {
-				case entry := <-lw.ch:
-					save2MysqlDirect(lw.db, entry.level, entry.msg, entry.fields...)
-				default:
-					return
-				}
-			}
-		}
-	}
-}
-
-// Write 写入日志条目
-func (lw *LogWriter) Write(level Level, msg string, fields ...zap.Field) {
-	select {
-	case lw.ch <- logEntry{level: level, msg: msg, fields: fields}:
-	default:
-		// 缓冲区满时丢弃日志，避免阻塞
-		logger.Debug("log buffer full, dropping log entry")
-	}
-}
 
-// Stop 停止写入器并等待所有日志写入完成
-func (lw *LogWriter) Stop() {
-	if lw == nil {
-		return
-	}
-	lw.cancel()
-	lw.wg.Wait()
-}
+	Level     string            `json:"level"`      // 控制台级别，为空时 dev 为 debug，prod 为 info
+	FileLevel string            `json:"file_level"` // 文件级别，默认 debug
+	Levels    map[string]string `json:"levels"`     // 按日志器名称覆盖级别，如 {"order": "debug"}
+	Sampling  *SamplingConfig   `json:"sampling"`   // 高频日志采样，为 nil 时不采样
+	Redactor  *Redactor         `json:"-"`          // 控制台、文件、Sentry 及未单独配置的 Sink 的脱敏规则，为 nil 时使用 DefaultRedactor
 
-// Shutdown 优雅关闭日志写入器
-func Shutdown() {
-	if logWriter != nil {
-		logWriter.Stop()
-	}
+	Rotate RotateConfig `json:"rotate"` // 文件轮转与保留策略
+	Sinks  []SinkConfig `json:"-"`      // 额外的日志输出目标，可同时配置多个
 }
 
 func InitConfig() Config {
@@ -137,6 +49,19 @@ func InitConfig() Config {
 			log.Fatalf("❌ rlog 配置项 %s 未设置，请检查配置文件或环境变量", configs[i])
 		}
 	}
+	// 轮转相关配置项可选，未设置时按 100MB 轮转、不清理备份
+	viper.SetDefault("rlog.max_size_mb", 100)
+
+	// 级别与采样配置项可选
+	var sampling *SamplingConfig
+	if viper.IsSet("rlog.sampling") {
+		sampling = &SamplingConfig{
+			Initial:    viper.GetInt("rlog.sampling.initial"),
+			Thereafter: viper.GetInt("rlog.sampling.thereafter"),
+			Tick:       viper.GetDuration("rlog.sampling.tick"),
+			MaxLevel:   viper.GetString("rlog.sampling.max_level"),
+		}
+	}
 	return Config{
 		Mode:         viper.GetString("rlog.mode"),
 		LogFile:      viper.GetString("rlog.log_file"),
@@ -144,48 +69,80 @@ func InitConfig() Config {
 		SentryDSN:    viper.GetString("rlog.sentry_dsn"),
 		EnableSentry: viper.GetBool("rlog.enable_sentry"),
 		EnableMysql:  viper.GetBool("rlog.enable_mysql"),
+		Level:        viper.GetString("rlog.level"),
+		FileLevel:    viper.GetString("rlog.file_level"),
+		Levels:       viper.GetStringMapString("rlog.levels"),
+		Sampling:     sampling,
+		Rotate: RotateConfig{
+			MaxSizeMB:      viper.GetInt("rlog.max_size_mb"),
+			RotateInterval: viper.GetDuration("rlog.rotate_interval"),
+			MaxBackups:     viper.GetInt("rlog.max_backups"),
+			MaxAgeDays:     viper.GetInt("rlog.max_age_days"),
+			Compress:       viper.GetBool("rlog.compress"),
+		},
+	}
+}
+
+// Init 按配置创建日志器并设为默认日志器，db 为 nil 时忽略 EnableMysql。
+// 重复调用时关闭之前的默认日志器，关闭失败只记录到新日志器，不影响返回值
+func Init(cfg Config, db *gorm.DB) error {
+	l, err := New(cfg, WithDB(db))
+	if err != nil {
+		return err
+	}
+	if err := SetDefault(l).Close(); err != nil {
+		l.zl.WithOptions(zap.WithCaller(false)).Error("failed to close previous logger", zap.Error(err))
 	}
+	return nil
 }
 
-func Init(cfg Config, db *gorm.DB) bool {
-	// 创建日志目录
-	if cfg.EnableFile && cfg.LogFile != "" {
-		if err := os.MkdirAll(filepath.Dir(cfg.LogFile), 0755); err != nil {
-			log.Fatalln(err)
-		}
-	}
+// Shutdown 关闭默认日志器，最多等待 5s 写入缓冲的日志，之后的日志被丢弃
+func Shutdown() {
+	_ = SetDefault(nil).Close()
+}
 
-	// 初始化 Sentry
-	if cfg.EnableSentry && cfg.SentryDSN != "" {
-		err := sentry.Init(sentry.ClientOptions{Dsn: cfg.SentryDSN})
-		if err != nil {
-			log.Fatalln(err)
-		}
-	}
-	if cfg.EnableMysql && db != nil {
-		dbx = db
-		// 自动迁移日志表
-		err := db.AutoMigrate(&rlog{})
-		if err != nil {
-			logger.Error("failed to auto migrate rlog table", zap.Error(err))
-			return false
-		}
-		// 初始化异步日志写入器
-		logWriter = newLogWriter(db, 1000)
-		logWriter.Start()
-	}
+// ShutdownContext 与 Shutdown 相同，ctx 到期时放弃未写入的日志并返回 ctx.Err()
+func ShutdownContext(ctx context.Context) error {
+	return SetDefault(nil).CloseContext(ctx)
+}
 
-	var cores []zapcore.Core
+// Rotate 立即轮转默认日志器的日志文件
+func Rotate() error { return Default().Rotate() }
 
-	// 控制台输出 - dev模式全打印，prod模式只打印info及以上
-	var consoleLevel Level
-	if cfg.Mode == "dev" {
-		consoleLevel = DebugLevel
-	} else {
-		consoleLevel = InfoLevel
-	}
+// Reopen 重新打开默认日志器的日志文件
+func Reopen() error { return Default().Reopen() }
+
+// Stats 返回默认日志器各 Sink 的累计计数
+func Stats() map[string]SinkStats { return Default().Stats() }
+
+// 简洁的日志接口，Init 之前调用不会输出
+func Debugln(msg string, fields ...zap.Field) { Default().log(DebugLevel, msg, fields) }
+func Warnln(msg string, fields ...zap.Field)  { Default().log(WarnLevel, msg, fields) }
+func Println(msg string, fields ...zap.Field) { Default().log(InfoLevel, msg, fields) }
+func Infoln(msg string, fields ...zap.Field)  { Default().log(InfoLevel, msg, fields) }
+func Errln(msg string, fields ...zap.Field)   { Default().log(ErrorLevel, msg, fields) }
+func Fatalln(msg string, fields ...zap.Field) { Default().log(FatalLevel, msg, fields) }
 
-	consoleEncoder := zapcore.NewConsoleEncoder(zapcore.EncoderConfig{
+// 便捷字段构造器
+func String(key, val string) zap.Field                 { return zap.String(key, val) }
+func Int(key string, val int) zap.Field                { return zap.Int(key, val) }
+func Uint(key string, val uint) zap.Field              { return zap.Uint(key, val) }
+func Float64(key string, val float64) zap.Field        { return zap.Float64(key, val) }
+func Bool(key string, val bool) zap.Field              { return zap.Bool(key, val) }
+func Any(key string, val interface{}) zap.Field        { return zap.Any(key, val) }
+func Err(err error) zap.Field                          { return zap.Error(err) }
+func Duration(key string, val time.Duration) zap.Field { return zap.Duration(key, val) }
+
+// Zap 返回默认日志器底层的 zap.Logger
+func Zap() *zap.Logger { return Default().Zap() }
+
+// Logger 返回默认日志器底层的 zap.Logger
+//
+// Deprecated: 使用 Zap
+func Logger() *zap.Logger { return Default().Zap() }
+
+func newConsoleEncoder() zapcore.Encoder {
+	return zapcore.NewConsoleEncoder(zapcore.EncoderConfig{
 		TimeKey:        "time",
 		LevelKey:       "level",
 		NameKey:        "logger",
@@ -198,76 +155,24 @@ func Init(cfg Config, db *gorm.DB) bool {
 		EncodeDuration: zapcore.SecondsDurationEncoder,
 		EncodeCaller:   zapcore.ShortCallerEncoder,
 	})
-	cores = append(cores, zapcore.NewCore(consoleEncoder, zapcore.AddSync(os.Stdout), consoleLevel))
-
-	// 文件输出 - 全打印
-	if cfg.EnableFile && cfg.LogFile != "" {
-		fileEncoder := zapcore.NewJSONEncoder(zapcore.EncoderConfig{
-			TimeKey:        "time",
-			LevelKey:       "level",
-			NameKey:        "logger",
-			CallerKey:      "caller",
-			MessageKey:     "msg",
-			StacktraceKey:  "stacktrace",
-			LineEnding:     zapcore.DefaultLineEnding,
-			EncodeLevel:    zapcore.LowercaseLevelEncoder,
-			EncodeTime:     zapcore.ISO8601TimeEncoder,
-			EncodeDuration: zapcore.SecondsDurationEncoder,
-			EncodeCaller:   zapcore.ShortCallerEncoder,
-		})
-
-		fileWriter := getFileWriter(cfg)
-		cores = append(cores, zapcore.NewCore(fileEncoder, zapcore.AddSync(fileWriter), DebugLevel))
-	}
-
-	core := zapcore.NewTee(cores...)
-	logger = zap.New(core, zap.AddCaller(), zap.AddStacktrace(ErrorLevel))
-	return true
 }
 
-// 简洁的日志接口 - 删除Warn级别
-func Debugln(msg string, fields ...zap.Field) { logger.Debug(msg, fields...) }
-func Warnln(msg string, fields ...zap.Field)  { logger.Warn(msg, fields...) }
-func Println(msg string, fields ...zap.Field) {
-	logger.Info(msg, fields...)
-	if logWriter != nil {
-		logWriter.Write(InfoLevel, msg, fields...)
-	}
-}
-func Infoln(msg string, fields ...zap.Field) {
-	logger.Info(msg, fields...)
-	if logWriter != nil {
-		logWriter.Write(InfoLevel, msg, fields...)
-	}
-}
-func Errln(msg string, fields ...zap.Field) {
-	logger.Error(msg, fields...)
-	if logWriter != nil {
-		logWriter.Write(ErrorLevel, msg, fields...)
-	}
-	sendToSentry(ErrorLevel, msg, fields...)
-}
-func Fatalln(msg string, fields ...zap.Field) {
-	logger.Fatal(msg, fields...)
-	if logWriter != nil {
-		logWriter.Write(FatalLevel, msg, fields...)
-	}
-	sendToSentry(FatalLevel, msg, fields...)
+func newFileEncoder() zapcore.Encoder {
+	return zapcore.NewJSONEncoder(zapcore.EncoderConfig{
+		TimeKey:        "time",
+		LevelKey:       "level",
+		NameKey:        "logger",
+		CallerKey:      "caller",
+		MessageKey:     "msg",
+		StacktraceKey:  "stacktrace",
+		LineEnding:     zapcore.DefaultLineEnding,
+		EncodeLevel:    zapcore.LowercaseLevelEncoder,
+		EncodeTime:     zapcore.ISO8601TimeEncoder,
+		EncodeDuration: zapcore.SecondsDurationEncoder,
+		EncodeCaller:   zapcore.ShortCallerEncoder,
+	})
 }
 
-// 便捷字段构造器
-func String(key, val string) zap.Field                 { return zap.String(key, val) }
-func Int(key string, val int) zap.Field                { return zap.Int(key, val) }
-func Uint(key string, val uint) zap.Field              { return zap.Uint(key, val) }
-func Float64(key string, val float64) zap.Field        { return zap.Float64(key, val) }
-func Bool(key string, val bool) zap.Field              { return zap.Bool(key, val) }
-func Any(key string, val interface{}) zap.Field        { return zap.Any(key, val) }
-func Err(err error) zap.Field                          { return zap.Error(err) }
-func Duration(key string, val time.Duration) zap.Field { return zap.Duration(key, val) }
-
-// 获取原始logger
-func Logger() *zap.Logger { return logger }
-
 // 时间格式化
 func timeEncoder(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
 	enc.AppendString(t.Format("15:04:05.000"))
@@ -295,64 +200,18 @@ func sendToSentry(level Level, msg string, fields ...zap.Field) {
 
 	sentry.WithScope(func(scope *sentry.Scope) {
 		scope.SetExtras(extra)
+		// 请求与链路 ID 作为 tag，便于在 Sentry 中检索
+		for _, key := range sentryTagKeys {
+			if v, ok := extra[key].(string); ok && v != "" {
+				scope.SetTag(key, v)
+			}
+		}
 		switch level {
 		case ErrorLevel:
 			sentry.CaptureMessage(msg)
 		case FatalLevel:
 			sentry.CaptureException(errors.New(msg))
+			sentry.Flush(2 * time.Second)
 		}
 	})
 }
-
-// save2MysqlDirect 直接保存日志到数据库（由后台协程调用）
-func save2MysqlDirect(db *gorm.DB, level Level, msg string, fields ...zap.Field) {
-	if db == nil || level < InfoLevel {
-		return
-	}
-
-	// 收集字段到一个 map，然后作为 JSON 存入 Fields 列
-	fieldsMap := make(map[string]interface{})
-	for _, field := range fields {
-		switch field.Type {
-		case zapcore.StringType:
-			fieldsMap[field.Key] = field.String
-		case zapcore.Int64Type, zapcore.Int32Type, zapcore.Int16Type, zapcore.Int8Type:
-			fieldsMap[field.Key] = field.Integer
-		case zapcore.Uint64Type, zapcore.Uint32Type, zapcore.Uint16Type, zapcore.Uint8Type:
-			fieldsMap[field.Key] = field.Integer
-		case zapcore.Float64Type, zapcore.Float32Type:
-			fieldsMap[field.Key] = field.Interface
-		case zapcore.BoolType:
-			fieldsMap[field.Key] = field.Interface
-		default:
-			fieldsMap[field.Key] = field.Interface
-		}
-	}
-
-	// 正确序列化为 datatypes.JSON
-	jsonBytes, err := json.Marshal(fieldsMap)
-	if err != nil {
-		logger.Error("failed to marshal fields to JSON", zap.Error(err))
-		return
-	}
-
-	entry := &rlog{
-		Level:   level.String(),
-		Message: msg,
-		Time:    time.Now().Format(time.RFC3339),
-		Fields:  datatypes.JSON(jsonBytes),
-	}
-
-	if err := db.Create(entry).Error; err != nil {
-		logger.Error("failed to save log to database", zap.Error(err))
-	}
-}
-
-// 文件写入器
-func getFileWriter(cfg Config) *os.File {
-	file, err := os.OpenFile(cfg.LogFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
-	if err != nil {
-		panic(fmt.Sprintf("open log file failed: %v", err))
-	}
-	return file
-}
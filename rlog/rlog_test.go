@@ -1,6 +1,7 @@
 package rlog
 
 import (
+	"context"
 	"fmt"
 	"go.uber.org/zap"
 	"gorm.io/driver/mysql"
@@ -13,15 +14,23 @@ func TestSave2Mysql(t *testing.T) {
 		"root", "123456", "localhost", "13306", "trace")
 	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
 	if err != nil {
-		t.Fatalf("failed to connect database: %v", err)
+		t.Skipf("mysql unavailable: %v", err)
+	}
+	sink, err := NewMySQLSink(db)
+	if err != nil {
+		t.Fatal(err)
 	}
 	msg := "unit test error"
-	save2Mysql(
-		db,
+	err = sink.WriteBatch(context.Background(), []Entry{newEntry(
 		ErrorLevel,
 		msg,
-		zap.String("user", "alice"),
-		zap.Int("code", 500),
-		zap.Bool("retry", true),
-	)
+		[]zap.Field{
+			zap.String("user", "alice"),
+			zap.Int("code", 500),
+			zap.Bool("retry", true),
+		},
+	)})
+	if err != nil {
+		t.Fatal(err)
+	}
 }
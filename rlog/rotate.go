@@ -0,0 +1,353 @@
+package rlog
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat 备份文件名中的时间格式，如 app-2024-01-02T15-04-05.000.log
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// RotateConfig 日志文件轮转配置，零值字段表示不启用对应功能
+type RotateConfig struct {
+	MaxSizeMB      int           `json:"max_size_mb"`     // 单个文件超过该大小时轮转
+	RotateInterval time.Duration `json:"rotate_interval"` // 按本地时间对齐的周期轮转，如 24h 为每天零点
+	MaxBackups     int           `json:"max_backups"`     // 最多保留的备份数
+	MaxAgeDays     int           `json:"max_age_days"`    // 备份最长保留天数
+	Compress       bool          `json:"compress"`        // 是否 gzip 压缩备份
+}
+
+// rotateWriter 支持按大小和时间轮转的文件写入器，清理和压缩在后台协程中进行
+type rotateWriter struct {
+	filename string
+	cfg      RotateConfig
+	now      func() time.Time
+	rename   func(oldpath, newpath string) error
+
+	mu         sync.Mutex
+	file       *os.File // 轮转失败且无法重新打开时为 nil，下次写入时重试
+	closed     bool
+	size       int64
+	nextRotate time.Time // 下次按时间轮转的时刻，未启用时为零值
+
+	millCh chan struct{}
+	done   chan struct{}
+	wg     sync.WaitGroup
+}
+
+// newRotateWriter 打开 filename 并启动后台清理，now 为 nil 时使用 time.Now
+func newRotateWriter(filename string, cfg RotateConfig, now func() time.Time) (*rotateWriter, error) {
+	if now == nil {
+		now = time.Now
+	}
+	w := &rotateWriter{
+		filename: filename,
+		cfg:      cfg,
+		now:      now,
+		rename:   os.Rename,
+		millCh:   make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	w.wg.Add(1)
+	go w.millLoop()
+	w.millCh <- struct{}{} // 启动时清理上次运行留下的备份
+	return w, nil
+}
+
+func (w *rotateWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.ensureOpen(); err != nil {
+		return 0, err
+	}
+
+	maxSize := int64(w.cfg.MaxSizeMB) << 20
+	sizeExceeded := maxSize > 0 && w.size > 0 && w.size+int64(len(p)) > maxSize
+	timeExceeded := !w.nextRotate.IsZero() && !w.now().Before(w.nextRotate)
+	var rotateErr error
+	if sizeExceeded || timeExceeded {
+		// 轮转失败时仍写入当前文件，错误随本次写入返回
+		if rotateErr = w.rotate(); w.file == nil {
+			return 0, rotateErr
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, errors.Join(rotateErr, err)
+}
+
+// Sync 实现 zapcore.WriteSyncer
+func (w *rotateWriter) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	return w.file.Sync()
+}
+
+// Rotate 立即轮转
+func (w *rotateWriter) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.ensureOpen(); err != nil {
+		return err
+	}
+	return w.rotate()
+}
+
+// Reopen 关闭并重新打开日志文件，用于配合外部 logrotate 等工具移动文件后继续写入
+func (w *rotateWriter) Reopen() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return os.ErrClosed
+	}
+	if w.file != nil {
+		w.file.Close()
+		w.file = nil
+	}
+	return w.open()
+}
+
+// Close 关闭文件并等待后台清理结束
+func (w *rotateWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	var err error
+	if w.file != nil {
+		err = w.file.Close()
+		w.file = nil
+	}
+	w.mu.Unlock()
+
+	close(w.done)
+	w.wg.Wait()
+	return err
+}
+
+// ensureOpen 关闭后返回 os.ErrClosed，之前轮转失败留下的空文件句柄在此重新打开，调用方持有锁
+func (w *rotateWriter) ensureOpen() error {
+	if w.closed {
+		return os.ErrClosed
+	}
+	if w.file == nil {
+		return w.open()
+	}
+	return nil
+}
+
+// open 以追加方式打开日志文件，调用方持有锁
+func (w *rotateWriter) open() error {
+	if err := os.MkdirAll(filepath.Dir(w.filename), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(w.filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("open log file failed: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	w.file = f
+	w.size = info.Size()
+	if w.cfg.RotateInterval > 0 {
+		w.nextRotate = nextBoundary(w.now(), w.cfg.RotateInterval)
+	}
+	return nil
+}
+
+// rotate 把当前文件重命名为备份并打开新文件，调用方持有锁。
+// 失败时重新打开原文件继续写入，无法打开时 w.file 为 nil，由下次写入重试
+func (w *rotateWriter) rotate() error {
+	err := w.file.Close()
+	w.file = nil
+	if err != nil {
+		return err
+	}
+	if err := w.rename(w.filename, w.backupName(w.now())); err != nil && !os.IsNotExist(err) {
+		if oerr := w.open(); oerr != nil {
+			return errors.Join(err, oerr)
+		}
+		// 不再逐条重试，再写满 MaxSizeMB 或到下个周期时重试
+		w.size = 0
+		return fmt.Errorf("rotate log file failed: %w", err)
+	}
+	if err := w.open(); err != nil {
+		return err
+	}
+	select {
+	case w.millCh <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// nextBoundary 返回 t 之后第一个按本地时间对齐到 interval 的时刻
+func nextBoundary(t time.Time, interval time.Duration) time.Time {
+	_, offset := t.Zone()
+	shift := time.Duration(offset) * time.Second
+	return t.Add(shift).Truncate(interval).Add(interval - shift)
+}
+
+func (w *rotateWriter) splitName() (prefix, ext string) {
+	base := filepath.Base(w.filename)
+	ext = filepath.Ext(base)
+	return strings.TrimSuffix(base, ext) + "-", ext
+}
+
+// backupName 同一毫秒内多次轮转时追加序号，如 app-2024-01-02T15-04-05.000.1.log，避免覆盖之前的备份
+func (w *rotateWriter) backupName(t time.Time) string {
+	prefix, ext := w.splitName()
+	stamp := t.Format(backupTimeFormat)
+	for seq := 0; ; seq++ {
+		name := stamp
+		if seq > 0 {
+			name += "." + strconv.Itoa(seq)
+		}
+		path := filepath.Join(filepath.Dir(w.filename), prefix+name+ext)
+		if !fileExists(path) && !fileExists(path+".gz") {
+			return path
+		}
+	}
+}
+
+func fileExists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+type backupFile struct {
+	path string
+	t    time.Time
+	seq  int
+	gz   bool
+}
+
+// backups 返回现有备份，按时间从新到旧排序
+func (w *rotateWriter) backups() ([]backupFile, error) {
+	entries, err := os.ReadDir(filepath.Dir(w.filename))
+	if err != nil {
+		return nil, err
+	}
+	prefix, ext := w.splitName()
+	var out []backupFile
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		gz := strings.HasSuffix(name, ext+".gz")
+		stamp := strings.TrimSuffix(strings.TrimPrefix(name, prefix), ".gz")
+		if !strings.HasSuffix(stamp, ext) {
+			continue
+		}
+		stamp = strings.TrimSuffix(stamp, ext)
+		if len(stamp) < len(backupTimeFormat) {
+			continue
+		}
+		t, err := time.ParseInLocation(backupTimeFormat, stamp[:len(backupTimeFormat)], time.Local)
+		if err != nil {
+			continue
+		}
+		seq := 0
+		if rest := stamp[len(backupTimeFormat):]; rest != "" {
+			if seq, err = strconv.Atoi(strings.TrimPrefix(rest, ".")); err != nil || rest[0] != '.' || seq <= 0 {
+				continue
+			}
+		}
+		out = append(out, backupFile{path: filepath.Join(filepath.Dir(w.filename), name), t: t, seq: seq, gz: gz})
+	}
+	slices.SortFunc(out, func(a, b backupFile) int {
+		if c := b.t.Compare(a.t); c != 0 {
+			return c
+		}
+		return b.seq - a.seq
+	})
+	return out, nil
+}
+
+func (w *rotateWriter) millLoop() {
+	defer w.wg.Done()
+	for {
+		select {
+		case <-w.millCh:
+			w.mill()
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// mill 删除超出数量或过期的备份，并压缩未压缩的备份
+func (w *rotateWriter) mill() {
+	if w.cfg.MaxBackups <= 0 && w.cfg.MaxAgeDays <= 0 && !w.cfg.Compress {
+		return
+	}
+	files, err := w.backups()
+	if err != nil {
+		return
+	}
+	cutoff := w.now().AddDate(0, 0, -w.cfg.MaxAgeDays)
+	for i, f := range files {
+		tooMany := w.cfg.MaxBackups > 0 && i >= w.cfg.MaxBackups
+		tooOld := w.cfg.MaxAgeDays > 0 && f.t.Before(cutoff)
+		if tooMany || tooOld {
+			os.Remove(f.path)
+			continue
+		}
+		if w.cfg.Compress && !f.gz {
+			_ = compressFile(f.path)
+		}
+	}
+}
+
+// compressFile 压缩为 path.gz 后删除原文件，中途失败时保留原文件
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	tmp := path + ".gz.tmp"
+	dst, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(dst)
+	if _, err = io.Copy(gz, src); err == nil {
+		err = gz.Close()
+	}
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path+".gz"); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,308 @@
+package rlog
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+func newFakeClock(t time.Time) *fakeClock { return &fakeClock{t: t} }
+
+func (c *fakeClock) now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+func (c *fakeClock) set(t time.Time) {
+	c.mu.Lock()
+	c.t = t
+	c.mu.Unlock()
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.set(c.now().Add(d))
+}
+
+func newTestWriter(t *testing.T, cfg RotateConfig, clock *fakeClock) (*rotateWriter, string) {
+	t.Helper()
+	filename := filepath.Join(t.TempDir(), "app.log")
+	var now func() time.Time
+	if clock != nil {
+		now = clock.now
+	}
+	w, err := newRotateWriter(filename, cfg, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { w.Close() })
+	return w, filename
+}
+
+// waitMill 等待后台清理处理完已触发的任务
+func waitMill(w *rotateWriter) {
+	w.millCh <- struct{}{}
+	w.millCh <- struct{}{}
+}
+
+func mustBackups(t *testing.T, w *rotateWriter) []backupFile {
+	t.Helper()
+	files, err := w.backups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestRotateBySize(t *testing.T) {
+	clock := newFakeClock(time.Date(2024, 1, 2, 3, 4, 5, 0, time.Local))
+	w, filename := newTestWriter(t, RotateConfig{MaxSizeMB: 1}, clock)
+
+	line := []byte(strings.Repeat("a", 600<<10) + "\n")
+	if _, err := w.Write(line); err != nil {
+		t.Fatal(err)
+	}
+	if files := mustBackups(t, w); len(files) != 0 {
+		t.Fatalf("unexpected rotation: %v", files)
+	}
+
+	clock.advance(time.Second)
+	if _, err := w.Write(line); err != nil {
+		t.Fatal(err)
+	}
+	files := mustBackups(t, w)
+	if len(files) != 1 {
+		t.Fatalf("expected 1 backup, got %d", len(files))
+	}
+	if !files[0].t.Equal(clock.now()) {
+		t.Errorf("backup time = %v, want %v", files[0].t, clock.now())
+	}
+	if got := readFile(t, files[0].path); got != string(line) {
+		t.Errorf("backup size = %d, want %d", len(got), len(line))
+	}
+	if got := readFile(t, filename); got != string(line) {
+		t.Errorf("current size = %d, want %d", len(got), len(line))
+	}
+}
+
+func TestRotateByInterval(t *testing.T) {
+	clock := newFakeClock(time.Date(2024, 1, 2, 23, 59, 0, 0, time.Local))
+	w, filename := newTestWriter(t, RotateConfig{RotateInterval: 24 * time.Hour}, clock)
+
+	w.Write([]byte("day1\n"))
+	clock.advance(30 * time.Second)
+	w.Write([]byte("day1 again\n"))
+	if files := mustBackups(t, w); len(files) != 0 {
+		t.Fatalf("unexpected rotation before midnight: %v", files)
+	}
+
+	clock.set(time.Date(2024, 1, 3, 0, 0, 1, 0, time.Local))
+	w.Write([]byte("day2\n"))
+	files := mustBackups(t, w)
+	if len(files) != 1 {
+		t.Fatalf("expected 1 backup, got %d", len(files))
+	}
+	if got := readFile(t, files[0].path); got != "day1\nday1 again\n" {
+		t.Errorf("backup = %q", got)
+	}
+	if got := readFile(t, filename); got != "day2\n" {
+		t.Errorf("current = %q", got)
+	}
+	if want := time.Date(2024, 1, 4, 0, 0, 0, 0, time.Local); !w.nextRotate.Equal(want) {
+		t.Errorf("nextRotate = %v, want %v", w.nextRotate, want)
+	}
+}
+
+func TestRetention(t *testing.T) {
+	clock := newFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local))
+	w, _ := newTestWriter(t, RotateConfig{MaxBackups: 3, MaxAgeDays: 7}, clock)
+
+	for i := 0; i < 5; i++ {
+		clock.advance(time.Hour)
+		w.Write([]byte("x\n"))
+		if err := w.Rotate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	waitMill(w)
+	files := mustBackups(t, w)
+	if len(files) != 3 {
+		t.Fatalf("expected 3 backups, got %d", len(files))
+	}
+	if want := clock.now(); !files[0].t.Equal(want) {
+		t.Errorf("newest backup = %v, want %v", files[0].t, want)
+	}
+
+	// 8 天后再轮转一次，之前的备份全部过期
+	clock.set(clock.now().AddDate(0, 0, 8))
+	w.Write([]byte("y\n"))
+	if err := w.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	waitMill(w)
+	files = mustBackups(t, w)
+	if len(files) != 1 || !files[0].t.Equal(clock.now()) {
+		t.Fatalf("expected only the newest backup, got %v", files)
+	}
+}
+
+func TestCompress(t *testing.T) {
+	clock := newFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local))
+	w, _ := newTestWriter(t, RotateConfig{Compress: true}, clock)
+
+	w.Write([]byte("hello\n"))
+	if err := w.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	waitMill(w)
+
+	files := mustBackups(t, w)
+	if len(files) != 1 || !files[0].gz {
+		t.Fatalf("expected 1 compressed backup, got %v", files)
+	}
+	if _, err := os.Stat(strings.TrimSuffix(files[0].path, ".gz")); !os.IsNotExist(err) {
+		t.Errorf("uncompressed backup still exists: %v", err)
+	}
+	f, err := os.Open(files[0].path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "hello\n" {
+		t.Errorf("decompressed = %q", b)
+	}
+}
+
+func TestReopen(t *testing.T) {
+	w, filename := newTestWriter(t, RotateConfig{}, nil)
+
+	w.Write([]byte("before\n"))
+	moved := filename + ".1"
+	if err := os.Rename(filename, moved); err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("still old\n"))
+	if err := w.Reopen(); err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("after\n"))
+
+	if got := readFile(t, moved); got != "before\nstill old\n" {
+		t.Errorf("moved = %q", got)
+	}
+	if got := readFile(t, filename); got != "after\n" {
+		t.Errorf("reopened = %q", got)
+	}
+}
+
+func TestRotateRenameFailure(t *testing.T) {
+	w, filename := newTestWriter(t, RotateConfig{}, nil)
+	w.rename = func(string, string) error { return errors.New("cross-device link") }
+
+	w.Write([]byte("before\n"))
+	if err := w.Rotate(); err == nil {
+		t.Fatal("expected rotate error")
+	}
+	if _, err := w.Write([]byte("after\n")); err != nil {
+		t.Fatalf("write after failed rotate: %v", err)
+	}
+	if got := readFile(t, filename); got != "before\nafter\n" {
+		t.Errorf("current = %q", got)
+	}
+
+	w.rename = os.Rename
+	if err := w.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	if files := mustBackups(t, w); len(files) != 1 || readFile(t, files[0].path) != "before\nafter\n" {
+		t.Errorf("backups = %v", files)
+	}
+}
+
+func TestRotateSameMillisecond(t *testing.T) {
+	clock := newFakeClock(time.Date(2024, 1, 2, 3, 4, 5, 0, time.Local))
+	w, _ := newTestWriter(t, RotateConfig{}, clock)
+
+	for _, s := range []string{"first\n", "second\n", "third\n"} {
+		w.Write([]byte(s))
+		if err := w.Rotate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files := mustBackups(t, w)
+	if len(files) != 3 {
+		t.Fatalf("expected 3 backups, got %v", files)
+	}
+	// 同一时刻按序号从新到旧
+	for i, want := range []string{"third\n", "second\n", "first\n"} {
+		if got := readFile(t, files[i].path); got != want {
+			t.Errorf("backup %d (%s) = %q, want %q", i, files[i].path, got, want)
+		}
+	}
+	if filepath.Base(files[0].path) != "app-2024-01-02T03-04-05.000.2.log" {
+		t.Errorf("newest backup = %s", files[0].path)
+	}
+}
+
+func TestClose(t *testing.T) {
+	w, _ := newTestWriter(t, RotateConfig{}, nil)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("x")); err != os.ErrClosed {
+		t.Errorf("write after close: %v", err)
+	}
+}
+
+func TestInitWithRotation(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "logs", "app.log")
+	cfg := Config{Mode: "prod", LogFile: filename, EnableFile: true, Rotate: RotateConfig{MaxSizeMB: 1}}
+	if err := Init(cfg, nil); err != nil {
+		t.Fatal(err)
+	}
+	defer Shutdown()
+	Infoln("hello", String("k", "v"))
+	if err := Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	Infoln("world")
+	Shutdown()
+
+	if got := readFile(t, filename); !strings.Contains(got, `"msg":"world"`) || strings.Contains(got, "hello") {
+		t.Errorf("current log = %q", got)
+	}
+	if Rotate() != nil || Default().out.file != nil {
+		t.Error("default logger should be reset after Shutdown")
+	}
+}
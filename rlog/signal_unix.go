@@ -0,0 +1,34 @@
+//go:build !windows
+
+package rlog
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"go.uber.org/zap"
+)
+
+// watchReopen 收到 SIGHUP 时重新打开日志文件，返回停止监听的函数
+func watchReopen(w *rotateWriter, errLog *zap.Logger) func() {
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, syscall.SIGHUP)
+	go func() {
+		for {
+			select {
+			case <-ch:
+				if err := w.Reopen(); err != nil {
+					errLog.Error("failed to reopen log file", zap.Error(err))
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(ch)
+		close(done)
+	}
+}
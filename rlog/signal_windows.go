@@ -0,0 +1,10 @@
+//go:build windows
+
+package rlog
+
+import "go.uber.org/zap"
+
+// watchReopen Windows 没有 SIGHUP，需要时调用 Reopen
+func watchReopen(*rotateWriter, *zap.Logger) func() {
+	return func() {}
+}
@@ -0,0 +1,359 @@
+package rlog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Entry 发送给 Sink 的一条日志
+type Entry struct {
+	Time    time.Time      `json:"time"`
+	Level   Level          `json:"level"`
+	Logger  string         `json:"logger,omitempty"` // Named 设置的名称
+	Message string         `json:"msg"`
+	Fields  map[string]any `json:"fields,omitempty"`
+}
+
+// newEntry 把 zap 字段编码为普通的 map，与 JSON 文件日志中的取值一致
+func newEntry(level Level, msg string, fields []zap.Field) Entry {
+	e := Entry{Time: time.Now(), Level: level, Message: msg}
+	if len(fields) > 0 {
+		enc := zapcore.NewMapObjectEncoder()
+		for _, f := range fields {
+			f.AddTo(enc)
+		}
+		e.Fields = enc.Fields
+	}
+	return e
+}
+
+// Sink 日志的批量输出目标，由 LogWriter 在后台协程中串行调用。
+// 实现了 io.Closer 的 Sink 会在 Shutdown 时被关闭
+type Sink interface {
+	WriteBatch(ctx context.Context, entries []Entry) error
+}
+
+// OverflowPolicy 缓冲区满时的处理策略
+type OverflowPolicy int
+
+const (
+	DropNewest OverflowPolicy = iota // 丢弃新日志（默认）
+	DropOldest                       // 丢弃缓冲区中最旧的日志
+	Block                            // 阻塞等待至多 BlockTimeout，超时后丢弃新日志
+	Spill                            // 以 JSON 行追加到 SpillFile，写失败时丢弃
+)
+
+// String 返回策略名称
+func (p OverflowPolicy) String() string {
+	switch p {
+	case DropNewest:
+		return "drop_newest"
+	case DropOldest:
+		return "drop_oldest"
+	case Block:
+		return "block"
+	case Spill:
+		return "spill"
+	}
+	return fmt.Sprintf("OverflowPolicy(%d)", int(p))
+}
+
+// SinkConfig 单个 Sink 的配置，零值字段使用默认值
+type SinkConfig struct {
+	Name          string         // 出错时日志和 Stats 中的名称，默认为 Sink 的类型名
+	Sink          Sink           // 输出目标，必填
+	Level         Level          // 最低级别，默认 InfoLevel
+	BufferSize    int            // 缓冲的日志条数，默认 1000
+	BatchSize     int            // 每批最多条数，默认 100
+	FlushInterval time.Duration  // 不足一批时的最长等待，默认 1s
+	Timeout       time.Duration  // 单批写入超时，默认 10s
+	Overflow      OverflowPolicy // 缓冲区满时的策略，默认 DropNewest
+	BlockTimeout  time.Duration  // Block 策略的最长等待，默认 100ms
+	SpillFile     string         // Spill 策略的本地文件，为空时使用 rlog-spill-<Name>.jsonl
+	Redactor      *Redactor      // 脱敏规则，为 nil 时使用 Config.Redactor，NewRedactor() 表示不脱敏
+}
+
+// SinkStats Sink 的累计计数
+type SinkStats struct {
+	Written uint64 `json:"written"` // 写入成功的条数
+	Failed  uint64 `json:"failed"`  // WriteBatch 返回错误的条数
+	Dropped uint64 `json:"dropped"` // 因缓冲区满或关闭超时而丢弃的条数
+	Spilled uint64 `json:"spilled"` // 缓冲区满时写入 SpillFile 的条数
+}
+
+func (c *SinkConfig) setDefaults() {
+	if c.Name == "" {
+		c.Name = fmt.Sprintf("%T", c.Sink)
+	}
+	// Level 的零值是 InfoLevel，无需处理
+	if c.BufferSize <= 0 {
+		c.BufferSize = 1000
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = 100
+	}
+	if c.FlushInterval <= 0 {
+		c.FlushInterval = time.Second
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = 10 * time.Second
+	}
+	if c.BlockTimeout <= 0 {
+		c.BlockTimeout = 100 * time.Millisecond
+	}
+	if c.Overflow == Spill && c.SpillFile == "" {
+		c.SpillFile = "rlog-spill-" + filepath.Base(c.Name) + ".jsonl"
+	}
+}
+
+// LogWriter 异步批量日志写入器，每个 Sink 一个，拥有独立的级别过滤和缓冲区
+type LogWriter struct {
+	cfg    SinkConfig
+	errLog *zap.Logger // 记录写入失败
+	ch     chan Entry
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// stopped 之后的日志直接计为丢弃，关闭后仍被子日志器持有时不会阻塞或重新打开溢出文件。
+	// enqueue 持有读锁直到入队完成，stop 在写锁下设置 stopped，保证后台协程退出前收到所有入队的日志
+	stopMu   sync.RWMutex
+	stopped  bool
+	stopOnce sync.Once
+	stopErr  error
+	// stopCtx 在 cancel 之前设置，限制关闭时写入剩余日志的时间
+	stopCtx context.Context
+	done    chan struct{}
+	// flushCtx 关闭超时时取消，中断正在进行的 WriteBatch
+	flushCtx    context.Context
+	flushCancel context.CancelFunc
+
+	written atomic.Uint64
+	failed  atomic.Uint64
+	dropped atomic.Uint64
+	spilled atomic.Uint64
+
+	spillMu   sync.Mutex
+	spillFile *os.File
+}
+
+// newLogWriter 创建日志写入器
+func newLogWriter(cfg SinkConfig) *LogWriter {
+	cfg.setDefaults()
+	ctx, cancel := context.WithCancel(context.Background())
+	flushCtx, flushCancel := context.WithCancel(context.Background())
+	return &LogWriter{
+		cfg:         cfg,
+		ch:          make(chan Entry, cfg.BufferSize),
+		ctx:         ctx,
+		cancel:      cancel,
+		flushCtx:    flushCtx,
+		flushCancel: flushCancel,
+		errLog:      zap.NewNop(),
+	}
+}
+
+// Start 启动后台写入协程
+func (lw *LogWriter) Start() {
+	lw.done = make(chan struct{})
+	go lw.run()
+}
+
+// run 后台处理协程，攒够一批或到达刷新间隔时写入
+func (lw *LogWriter) run() {
+	defer close(lw.done)
+	ticker := time.NewTicker(lw.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]Entry, 0, lw.cfg.BatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		lw.flush(batch)
+		batch = batch[:0]
+	}
+	for {
+		select {
+		case e := <-lw.ch:
+			batch = append(batch, e)
+			if len(batch) >= lw.cfg.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-lw.ctx.Done():
+			// 处理剩余的日志，超过关闭期限后计为丢弃
+			for {
+				if lw.stopCtx.Err() != nil {
+					lw.dropped.Add(uint64(len(batch) + len(lw.ch)))
+					return
+				}
+				select {
+				case e := <-lw.ch:
+					batch = append(batch, e)
+					if len(batch) >= lw.cfg.BatchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+func (lw *LogWriter) flush(batch []Entry) {
+	ctx, cancel := context.WithTimeout(lw.flushCtx, lw.cfg.Timeout)
+	defer cancel()
+	if err := lw.cfg.Sink.WriteBatch(ctx, batch); err != nil {
+		lw.failed.Add(uint64(len(batch)))
+		lw.errLog.Error("failed to write log batch", zap.String("sink", lw.cfg.Name), zap.Int("count", len(batch)), zap.Error(err))
+		return
+	}
+	lw.written.Add(uint64(len(batch)))
+}
+
+// Write 写入日志条目，低于 Sink 级别的日志直接忽略
+func (lw *LogWriter) Write(level Level, msg string, fields ...zap.Field) {
+	if level < lw.cfg.Level {
+		return
+	}
+	lw.enqueue(newEntry(level, msg, fields))
+}
+
+func (lw *LogWriter) enqueue(e Entry) {
+	lw.stopMu.RLock()
+	defer lw.stopMu.RUnlock()
+	if lw.stopped {
+		lw.dropped.Add(1)
+		return
+	}
+	select {
+	case lw.ch <- e:
+		return
+	default:
+	}
+	lw.overflow(e)
+}
+
+// overflow 缓冲区满时按策略处理
+func (lw *LogWriter) overflow(e Entry) {
+	switch lw.cfg.Overflow {
+	case DropOldest:
+		// 并发写入时可能被其他协程抢占空位，有限次重试后丢弃新日志
+		for i := 0; i < 3; i++ {
+			select {
+			case <-lw.ch:
+				lw.dropped.Add(1)
+			default:
+			}
+			select {
+			case lw.ch <- e:
+				return
+			default:
+			}
+		}
+	case Block:
+		timer := time.NewTimer(lw.cfg.BlockTimeout)
+		defer timer.Stop()
+		select {
+		case lw.ch <- e:
+			return
+		case <-timer.C:
+		}
+	case Spill:
+		if err := lw.spill(e); err == nil {
+			lw.spilled.Add(1)
+			return
+		}
+	}
+	lw.dropped.Add(1)
+}
+
+// spill 以 JSON 行追加到本地文件，文件在首次使用时打开
+func (lw *LogWriter) spill(e Entry) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	lw.spillMu.Lock()
+	defer lw.spillMu.Unlock()
+	if lw.spillFile == nil {
+		f, err := os.OpenFile(lw.cfg.SpillFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return err
+		}
+		lw.spillFile = f
+	}
+	_, err = lw.spillFile.Write(append(line, '\n'))
+	return err
+}
+
+// Stats 返回累计计数
+func (lw *LogWriter) Stats() SinkStats {
+	return SinkStats{
+		Written: lw.written.Load(),
+		Failed:  lw.failed.Load(),
+		Dropped: lw.dropped.Load(),
+		Spilled: lw.spilled.Load(),
+	}
+}
+
+// Stop 停止写入器，等待缓冲的日志写入完成后关闭 Sink
+func (lw *LogWriter) Stop() {
+	_ = lw.StopContext(context.Background())
+}
+
+// StopContext 停止写入器，ctx 到期时放弃剩余日志（计入 Dropped）并返回 ctx.Err()。
+// 并发或重复调用时只执行一次，其余调用等待其完成并返回相同结果
+func (lw *LogWriter) StopContext(ctx context.Context) error {
+	if lw == nil {
+		return nil
+	}
+	lw.stopOnce.Do(func() { lw.stopErr = lw.stop(ctx) })
+	return lw.stopErr
+}
+
+func (lw *LogWriter) stop(ctx context.Context) error {
+	lw.stopCtx = ctx
+	// 等待进行中的 enqueue 完成，Block 策略最多等待 BlockTimeout
+	lw.stopMu.Lock()
+	lw.stopped = true
+	lw.stopMu.Unlock()
+	lw.cancel()
+
+	var err error
+	if lw.done != nil {
+		select {
+		case <-lw.done:
+		case <-ctx.Done():
+			// 中断正在进行的 WriteBatch，等待后台协程记录丢弃数
+			lw.flushCancel()
+			<-lw.done
+			err = ctx.Err()
+		}
+	}
+	lw.flushCancel()
+
+	if c, ok := lw.cfg.Sink.(io.Closer); ok {
+		_ = c.Close()
+	}
+	lw.spillMu.Lock()
+	if lw.spillFile != nil {
+		_ = lw.spillFile.Close()
+		lw.spillFile = nil
+	}
+	lw.spillMu.Unlock()
+	return err
+}
@@ -0,0 +1,439 @@
+package rlog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type memorySink struct {
+	mu      sync.Mutex
+	batches [][]Entry
+	closed  bool
+	closes  int
+}
+
+func (s *memorySink) WriteBatch(_ context.Context, entries []Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.batches = append(s.batches, append([]Entry(nil), entries...))
+	return nil
+}
+
+func (s *memorySink) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	s.closes++
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *memorySink) entries() []Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []Entry
+	for _, b := range s.batches {
+		out = append(out, b...)
+	}
+	return out
+}
+
+func (s *memorySink) batchSizes() []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sizes := make([]int, len(s.batches))
+	for i, b := range s.batches {
+		sizes[i] = len(b)
+	}
+	return sizes
+}
+
+func TestLogWriterBatching(t *testing.T) {
+	sink := &memorySink{}
+	w := newLogWriter(SinkConfig{Sink: sink, Level: WarnLevel, BatchSize: 3, FlushInterval: time.Hour})
+	w.Start()
+
+	w.Write(InfoLevel, "ignored")
+	for i := 0; i < 7; i++ {
+		w.Write(ErrorLevel, "err", Int("i", i))
+	}
+	w.Stop()
+
+	if sizes := sink.batchSizes(); len(sizes) != 3 || sizes[0] != 3 || sizes[1] != 3 || sizes[2] != 1 {
+		t.Errorf("batch sizes = %v, want [3 3 1]", sizes)
+	}
+	entries := sink.entries()
+	if len(entries) != 7 {
+		t.Fatalf("expected 7 entries, got %d", len(entries))
+	}
+	if entries[6].Level != ErrorLevel || entries[6].Fields["i"] != int64(6) {
+		t.Errorf("last entry = %+v", entries[6])
+	}
+	if !sink.closed {
+		t.Error("sink should be closed on Stop")
+	}
+}
+
+func TestLogWriterFlushInterval(t *testing.T) {
+	sink := &memorySink{}
+	w := newLogWriter(SinkConfig{Sink: sink, FlushInterval: 10 * time.Millisecond})
+	w.Start()
+	defer w.Stop()
+
+	w.Write(InfoLevel, "hello")
+	deadline := time.Now().Add(2 * time.Second)
+	for len(sink.entries()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("entry was not flushed by interval")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestLogWriterDropWhenFull(t *testing.T) {
+	sink := &memorySink{}
+	// 不启动后台协程，缓冲区满后直接丢弃
+	w := newLogWriter(SinkConfig{Sink: sink, BufferSize: 2})
+	for i := 0; i < 5; i++ {
+		w.Write(InfoLevel, "x")
+	}
+	if len(w.ch) != 2 {
+		t.Errorf("buffered = %d, want 2", len(w.ch))
+	}
+	if st := w.Stats(); st.Dropped != 3 {
+		t.Errorf("stats = %+v, want 3 dropped", st)
+	}
+}
+
+func TestOverflowDropOldest(t *testing.T) {
+	w := newLogWriter(SinkConfig{Sink: &memorySink{}, BufferSize: 2, Overflow: DropOldest})
+	for i := 0; i < 5; i++ {
+		w.Write(InfoLevel, "x", Int("i", i))
+	}
+	if e := <-w.ch; e.Fields["i"] != int64(3) {
+		t.Errorf("oldest kept entry = %v, want i=3", e.Fields)
+	}
+	if st := w.Stats(); st.Dropped != 3 {
+		t.Errorf("stats = %+v, want 3 dropped", st)
+	}
+}
+
+func TestOverflowBlock(t *testing.T) {
+	w := newLogWriter(SinkConfig{Sink: &memorySink{}, BufferSize: 1, Overflow: Block, BlockTimeout: 20 * time.Millisecond})
+	w.Write(InfoLevel, "first")
+
+	start := time.Now()
+	w.Write(InfoLevel, "timeout")
+	if d := time.Since(start); d < 20*time.Millisecond {
+		t.Errorf("write returned after %v, want at least BlockTimeout", d)
+	}
+	if st := w.Stats(); st.Dropped != 1 {
+		t.Errorf("stats = %+v, want 1 dropped", st)
+	}
+
+	// 消费者腾出空间后阻塞的写入成功
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		<-w.ch
+	}()
+	w.Write(InfoLevel, "unblocked")
+	if e := <-w.ch; e.Message != "unblocked" {
+		t.Errorf("buffered = %q, want unblocked", e.Message)
+	}
+	if st := w.Stats(); st.Dropped != 1 {
+		t.Errorf("stats = %+v, want still 1 dropped", st)
+	}
+}
+
+func TestOverflowSpill(t *testing.T) {
+	spill := filepath.Join(t.TempDir(), "spill.jsonl")
+	w := newLogWriter(SinkConfig{Sink: &memorySink{}, BufferSize: 1, Overflow: Spill, SpillFile: spill})
+	w.Write(InfoLevel, "buffered")
+	w.Write(ErrorLevel, "spilled-1")
+	w.Write(ErrorLevel, "spilled-2", String("k", "v"))
+	w.Stop()
+
+	if st := w.Stats(); st.Spilled != 2 || st.Dropped != 0 {
+		t.Errorf("stats = %+v, want 2 spilled", st)
+	}
+	b, err := os.ReadFile(spill)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("spill file has %d lines, want 2", len(lines))
+	}
+	var e map[string]any
+	if err := json.Unmarshal([]byte(lines[1]), &e); err != nil {
+		t.Fatal(err)
+	}
+	if e["msg"] != "spilled-2" || e["level"] != "error" {
+		t.Errorf("spilled entry = %v", e)
+	}
+
+	// 无法写入的 Spill 文件计为丢弃
+	w = newLogWriter(SinkConfig{Sink: &memorySink{}, BufferSize: 1, Overflow: Spill, SpillFile: filepath.Join(spill, "x")})
+	w.Write(InfoLevel, "buffered")
+	w.Write(InfoLevel, "lost")
+	if st := w.Stats(); st.Dropped != 1 || st.Spilled != 0 {
+		t.Errorf("stats = %+v, want 1 dropped", st)
+	}
+}
+
+type failingSink struct{}
+
+func (failingSink) WriteBatch(context.Context, []Entry) error { return errors.New("unavailable") }
+
+// slowSink 每批阻塞到 ctx 取消
+type slowSink struct{}
+
+func (slowSink) WriteBatch(ctx context.Context, _ []Entry) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestStats(t *testing.T) {
+	l, err := New(Config{Sinks: []SinkConfig{{Name: "ok", Sink: &memorySink{}}, {Name: "bad", Sink: failingSink{}}}}, WithConsole(io.Discard))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Infoln("a")
+	l.Infoln("b")
+	l.Close()
+
+	stats := l.Stats()
+	if stats["ok"].Written != 2 || stats["bad"].Failed != 2 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestStopDeadline(t *testing.T) {
+	w := newLogWriter(SinkConfig{Sink: slowSink{}, BatchSize: 2, FlushInterval: time.Hour})
+	w.Start()
+	for i := 0; i < 5; i++ {
+		w.Write(InfoLevel, "x")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := w.StopContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("StopContext error = %v, want deadline exceeded", err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("StopContext took %v", d)
+	}
+	// 被中断的一批计为失败，其余未写入的计为丢弃
+	st := w.Stats()
+	if st.Written != 0 || st.Failed+st.Dropped != 5 || st.Dropped == 0 {
+		t.Errorf("stats = %+v", st)
+	}
+}
+
+func TestWriteAfterStop(t *testing.T) {
+	spill := filepath.Join(t.TempDir(), "spill.jsonl")
+	for _, policy := range []OverflowPolicy{DropNewest, DropOldest, Block, Spill} {
+		t.Run(policy.String(), func(t *testing.T) {
+			w := newLogWriter(SinkConfig{Sink: &memorySink{}, BufferSize: 1, Overflow: policy, BlockTimeout: time.Second, SpillFile: spill})
+			w.Start()
+			w.Stop()
+
+			start := time.Now()
+			for i := 0; i < 5; i++ {
+				w.Write(InfoLevel, "late")
+			}
+			if d := time.Since(start); d > 100*time.Millisecond {
+				t.Errorf("writes after stop took %v", d)
+			}
+			if st := w.Stats(); st.Dropped != 5 || st.Spilled != 0 {
+				t.Errorf("stats = %+v, want 5 dropped", st)
+			}
+			if w.spillFile != nil {
+				t.Error("spill file reopened after stop")
+			}
+		})
+	}
+	if _, err := os.Stat(spill); !os.IsNotExist(err) {
+		t.Errorf("spill file should not be created after stop: %v", err)
+	}
+}
+
+func TestConcurrentStop(t *testing.T) {
+	sink := &memorySink{}
+	w := newLogWriter(SinkConfig{Sink: sink, FlushInterval: time.Hour})
+	w.Start()
+	w.Write(InfoLevel, "pending")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.Stop()
+		}()
+	}
+	wg.Wait()
+	if sink.closes != 1 || len(sink.entries()) != 1 {
+		t.Errorf("closes = %d, entries = %d", sink.closes, len(sink.entries()))
+	}
+}
+
+func TestStopAccountsForConcurrentWrites(t *testing.T) {
+	sink := &memorySink{}
+	w := newLogWriter(SinkConfig{Sink: sink, BufferSize: 10000, FlushInterval: time.Hour})
+	w.Start()
+
+	const writers, perWriter = 8, 500
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < perWriter; j++ {
+				w.Write(InfoLevel, "x")
+			}
+		}()
+	}
+	time.Sleep(time.Millisecond)
+	w.Stop()
+	wg.Wait()
+
+	// 停止前后的每条日志要么写入要么计为丢弃
+	st := w.Stats()
+	if got := uint64(len(sink.entries())); got != st.Written || st.Written+st.Dropped != writers*perWriter {
+		t.Errorf("entries = %d, stats = %+v, want %d in total", got, st, writers*perWriter)
+	}
+}
+
+func TestShutdownContext(t *testing.T) {
+	sink := &memorySink{}
+	if err := Init(Config{Mode: "prod", Sinks: []SinkConfig{{Sink: sink, FlushInterval: time.Hour}}}, nil); err != nil {
+		t.Fatal(err)
+	}
+	Infoln("pending")
+	if err := ShutdownContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(sink.entries()) != 1 {
+		t.Errorf("pending entry was not flushed on shutdown")
+	}
+}
+
+func TestInitSinks(t *testing.T) {
+	info, errs := &memorySink{}, &memorySink{}
+	cfg := Config{Mode: "prod", Sinks: []SinkConfig{
+		{Name: "info", Sink: info},
+		{Name: "errors", Sink: errs, Level: ErrorLevel},
+	}}
+	if err := Init(cfg, nil); err != nil {
+		t.Fatal(err)
+	}
+	Debugln("debug")
+	Infoln("info", String("k", "v"))
+	Errln("error")
+	Shutdown()
+
+	if got := len(info.entries()); got != 2 {
+		t.Errorf("info sink got %d entries, want 2", got)
+	}
+	if got := errs.entries(); len(got) != 1 || got[0].Message != "error" {
+		t.Errorf("error sink got %+v", got)
+	}
+	if !info.closed || !errs.closed {
+		t.Error("sinks should be closed on Shutdown")
+	}
+}
+
+func TestHTTPSink(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		bodies [][]byte
+		auth   string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, b)
+		auth = r.Header.Get("Authorization")
+		mu.Unlock()
+		if r.URL.Path == "/fail" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	entries := []Entry{newEntry(InfoLevel, "hello", nil), newEntry(ErrorLevel, "boom", []zap.Field{String("k", "v")})}
+
+	s := NewHTTPSink(srv.URL, WithHeader("Authorization", "Bearer token"))
+	if err := s.WriteBatch(context.Background(), entries); err != nil {
+		t.Fatal(err)
+	}
+	var got []map[string]any
+	if err := json.Unmarshal(bodies[0], &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0]["level"] != "info" || got[1]["msg"] != "boom" || auth != "Bearer token" {
+		t.Errorf("body = %s, auth = %q", bodies[0], auth)
+	}
+
+	loki := NewHTTPSink(srv.URL, WithEncoder(LokiEncoder(map[string]string{"app": "test"})))
+	if err := loki.WriteBatch(context.Background(), entries); err != nil {
+		t.Fatal(err)
+	}
+	var push struct {
+		Streams []struct {
+			Stream map[string]string `json:"stream"`
+			Values [][2]string       `json:"values"`
+		} `json:"streams"`
+	}
+	if err := json.Unmarshal(bodies[1], &push); err != nil {
+		t.Fatal(err)
+	}
+	if len(push.Streams) != 1 || push.Streams[0].Stream["app"] != "test" || len(push.Streams[0].Values) != 2 {
+		t.Errorf("loki body = %s", bodies[1])
+	}
+
+	if err := NewHTTPSink(srv.URL+"/fail").WriteBatch(context.Background(), entries); err == nil {
+		t.Error("expected error for non-2xx response")
+	}
+}
+
+type fakePublisher struct {
+	subject string
+	data    interface{}
+	err     error
+}
+
+func (p *fakePublisher) Publish(_ context.Context, subject string, data interface{}) error {
+	p.subject, p.data = subject, data
+	return p.err
+}
+
+func TestNATSSink(t *testing.T) {
+	pub := &fakePublisher{}
+	s := &NATSSink{client: pub, subject: "logs.app"}
+	entries := []Entry{newEntry(InfoLevel, "hello", nil)}
+	if err := s.WriteBatch(context.Background(), entries); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := pub.data.([]Entry); pub.subject != "logs.app" || !ok || len(got) != 1 {
+		t.Errorf("published %q %v", pub.subject, pub.data)
+	}
+
+	pub.err = errors.New("no responders")
+	if err := s.WriteBatch(context.Background(), entries); err == nil {
+		t.Error("expected publish error")
+	}
+}
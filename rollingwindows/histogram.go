@@ -0,0 +1,230 @@
+package rollingwindows
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// ErrLayoutMismatch is returned when merging histograms built with different layouts.
+var ErrLayoutMismatch = errors.New("rollingwindows: histogram layouts do not match")
+
+// Layout maps values to histogram slots. Slot i covers the half-open range
+// returned by Bounds(i); the first and last slots may be unbounded.
+type Layout interface {
+	// Len returns the number of slots.
+	Len() int
+	// Index returns the slot that v falls into.
+	Index(v float64) int
+	// Bounds returns the value range of slot i.
+	Bounds(i int) (lo, hi float64)
+}
+
+// fixedLayout uses caller supplied upper bounds, like Prometheus histograms.
+type fixedLayout struct {
+	bounds []float64
+}
+
+// FixedBuckets returns a Layout with the given upper bounds, slot i holds
+// values in (bounds[i-1], bounds[i]] and an extra slot holds values above the last bound.
+func FixedBuckets(bounds ...float64) Layout {
+	if len(bounds) == 0 {
+		panic("bounds must not be empty")
+	}
+	b := append([]float64(nil), bounds...)
+	sort.Float64s(b)
+	return &fixedLayout{bounds: b}
+}
+
+func (l *fixedLayout) Len() int {
+	return len(l.bounds) + 1
+}
+
+func (l *fixedLayout) Index(v float64) int {
+	return sort.SearchFloat64s(l.bounds, v)
+}
+
+func (l *fixedLayout) Bounds(i int) (lo, hi float64) {
+	lo, hi = math.Inf(-1), math.Inf(1)
+	if i > 0 {
+		lo = l.bounds[i-1]
+	}
+	if i < len(l.bounds) {
+		hi = l.bounds[i]
+	}
+	return lo, hi
+}
+
+// logLinearLayout splits every power of two in [lowest, highest) into subBuckets
+// linear slots, the same scheme HDR histograms use, so the relative error of any
+// recorded value is bounded by 1/subBuckets regardless of magnitude.
+type logLinearLayout struct {
+	lowest     float64
+	exponents  int
+	subBuckets int
+}
+
+// LogLinearBuckets returns an HDR-style Layout covering [lowest, highest) with a
+// relative error of at most 1/subBuckets. Values below lowest or at/above highest
+// go to the first and last slot.
+func LogLinearBuckets(lowest, highest float64, subBuckets int) Layout {
+	if lowest <= 0 || highest <= lowest {
+		panic("require 0 < lowest < highest")
+	}
+	if subBuckets < 1 {
+		panic("subBuckets must be greater than 0")
+	}
+	return &logLinearLayout{
+		lowest:     lowest,
+		exponents:  int(math.Ceil(math.Log2(highest / lowest))),
+		subBuckets: subBuckets,
+	}
+}
+
+func (l *logLinearLayout) Len() int {
+	return l.exponents*l.subBuckets + 2
+}
+
+func (l *logLinearLayout) Index(v float64) int {
+	if !(v >= l.lowest) {
+		return 0
+	}
+	// v/lowest = frac * 2^exp with frac in [0.5, 1)
+	frac, exp := math.Frexp(v / l.lowest)
+	e := exp - 1
+	if e >= l.exponents {
+		return l.Len() - 1
+	}
+	sub := int((frac*2 - 1) * float64(l.subBuckets))
+	return 1 + e*l.subBuckets + min(sub, l.subBuckets-1)
+}
+
+func (l *logLinearLayout) Bounds(i int) (lo, hi float64) {
+	switch {
+	case i <= 0:
+		return math.Inf(-1), l.lowest
+	case i >= l.Len()-1:
+		return l.lowest * math.Ldexp(1, l.exponents), math.Inf(1)
+	}
+	e, sub := (i-1)/l.subBuckets, (i-1)%l.subBuckets
+	base := l.lowest * math.Ldexp(1, e)
+	step := base / float64(l.subBuckets)
+	return base + float64(sub)*step, base + float64(sub+1)*step
+}
+
+// Histogram counts values per Layout slot and keeps the exact min, max and sum.
+type Histogram struct {
+	layout Layout
+	counts []int64
+	count  int64
+	sum    float64
+	min    float64
+	max    float64
+}
+
+// NewHistogram returns an empty Histogram with the given layout.
+func NewHistogram(layout Layout) *Histogram {
+	return &Histogram{
+		layout: layout,
+		counts: make([]int64, layout.Len()),
+	}
+}
+
+// Record adds v to the histogram.
+func (h *Histogram) Record(v float64) {
+	if h.count == 0 || v < h.min {
+		h.min = v
+	}
+	if h.count == 0 || v > h.max {
+		h.max = v
+	}
+	h.counts[h.layout.Index(v)]++
+	h.count++
+	h.sum += v
+}
+
+// Merge adds all values recorded by other into h.
+func (h *Histogram) Merge(other *Histogram) error {
+	if other.layout != h.layout {
+		return ErrLayoutMismatch
+	}
+	if other.count == 0 {
+		return nil
+	}
+	if h.count == 0 || other.min < h.min {
+		h.min = other.min
+	}
+	if h.count == 0 || other.max > h.max {
+		h.max = other.max
+	}
+	for i, c := range other.counts {
+		h.counts[i] += c
+	}
+	h.count += other.count
+	h.sum += other.sum
+	return nil
+}
+
+// Reset clears all recorded values.
+func (h *Histogram) Reset() {
+	clear(h.counts)
+	h.count = 0
+	h.sum = 0
+	h.min = 0
+	h.max = 0
+}
+
+// Count returns the number of recorded values.
+func (h *Histogram) Count() int64 {
+	return h.count
+}
+
+// Sum returns the sum of recorded values.
+func (h *Histogram) Sum() float64 {
+	return h.sum
+}
+
+// Min returns the smallest recorded value, 0 if empty.
+func (h *Histogram) Min() float64 {
+	return h.min
+}
+
+// Max returns the largest recorded value, 0 if empty.
+func (h *Histogram) Max() float64 {
+	return h.max
+}
+
+// Mean returns the average of recorded values, 0 if empty.
+func (h *Histogram) Mean() float64 {
+	if h.count == 0 {
+		return 0
+	}
+	return h.sum / float64(h.count)
+}
+
+// Quantile returns the estimated value at quantile q in [0, 1], e.g. 0.99 for p99.
+// Values are interpolated linearly inside the slot and clamped to [Min, Max].
+func (h *Histogram) Quantile(q float64) float64 {
+	if h.count == 0 {
+		return 0
+	}
+	if q <= 0 {
+		return h.min
+	}
+	if q >= 1 {
+		return h.max
+	}
+
+	rank := q * float64(h.count)
+	var seen int64
+	for i, c := range h.counts {
+		if c == 0 || float64(seen+c) < rank {
+			seen += c
+			continue
+		}
+		lo, hi := h.layout.Bounds(i)
+		lo, hi = math.Max(lo, h.min), math.Min(hi, h.max)
+		return lo + (hi-lo)*(rank-float64(seen))/float64(c)
+	}
+	return h.max
+}
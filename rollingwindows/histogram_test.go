@@ -0,0 +1,145 @@
+package rollingwindows
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+)
+
+func exactQuantile(sorted []float64, q float64) float64 {
+	return sorted[int(math.Ceil(q*float64(len(sorted))))-1]
+}
+
+func TestFixedBuckets(t *testing.T) {
+	l := FixedBuckets(10, 1, 5)
+	cases := map[float64]int{0.5: 0, 1: 0, 3: 1, 5: 1, 7: 2, 10: 2, 11: 3}
+	for v, want := range cases {
+		if got := l.Index(v); got != want {
+			t.Errorf("Index(%v) = %d, want %d", v, got, want)
+		}
+	}
+	if lo, hi := l.Bounds(1); lo != 1 || hi != 5 {
+		t.Errorf("Bounds(1) = %v, %v", lo, hi)
+	}
+	if _, hi := l.Bounds(3); !math.IsInf(hi, 1) {
+		t.Error("last slot should be unbounded")
+	}
+}
+
+func TestLogLinearBuckets(t *testing.T) {
+	l := LogLinearBuckets(1, 1024, 16)
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 10000; i++ {
+		v := math.Exp(r.Float64() * math.Log(1024))
+		lo, hi := l.Bounds(l.Index(v))
+		if v < lo || v >= hi {
+			t.Fatalf("%v not in slot [%v, %v)", v, lo, hi)
+		}
+		if (hi-lo)/lo > 1.0/16+1e-12 {
+			t.Fatalf("slot [%v, %v) wider than the relative error", lo, hi)
+		}
+	}
+	if l.Index(0.5) != 0 || l.Index(2048) != l.Len()-1 {
+		t.Error("out-of-range values should go to the edge slots")
+	}
+}
+
+func TestHistogramQuantile(t *testing.T) {
+	layouts := map[string]Layout{
+		"fixed":      FixedBuckets(1, 2, 5, 10, 20, 50, 100, 200, 500, 1000),
+		"log-linear": LogLinearBuckets(1, 1000, 32),
+	}
+	r := rand.New(rand.NewSource(2))
+	values := make([]float64, 20000)
+	for i := range values {
+		values[i] = 1 + r.ExpFloat64()*50
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	for name, layout := range layouts {
+		t.Run(name, func(t *testing.T) {
+			h := NewHistogram(layout)
+			for _, v := range values {
+				h.Record(v)
+			}
+			if h.Min() != sorted[0] || h.Max() != sorted[len(sorted)-1] {
+				t.Error("min/max should be exact")
+			}
+			for _, q := range []float64{0.5, 0.9, 0.99} {
+				want := exactQuantile(sorted, q)
+				got := h.Quantile(q)
+				tolerance := 0.05
+				if name == "fixed" {
+					// 固定边界的误差取决于所在区间的宽度
+					lo, hi := layout.Bounds(layout.Index(want))
+					tolerance = (math.Min(hi, h.Max()) - lo) / want
+				}
+				if math.Abs(got-want)/want > tolerance {
+					t.Errorf("p%v = %v, want %v", q*100, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestHistogramMerge(t *testing.T) {
+	layout := LogLinearBuckets(1, 1000, 8)
+	a, b, all := NewHistogram(layout), NewHistogram(layout), NewHistogram(layout)
+	for i := 1; i <= 100; i++ {
+		a.Record(float64(i))
+		b.Record(float64(i * 5))
+		all.Record(float64(i))
+		all.Record(float64(i * 5))
+	}
+	if err := a.Merge(b); err != nil {
+		t.Fatal(err)
+	}
+	if a.Count() != all.Count() || a.Sum() != all.Sum() || a.Min() != 1 || a.Max() != 500 {
+		t.Errorf("merged stats differ: %d %v %v %v", a.Count(), a.Sum(), a.Min(), a.Max())
+	}
+	for _, q := range []float64{0.1, 0.5, 0.99} {
+		if a.Quantile(q) != all.Quantile(q) {
+			t.Errorf("merged quantile %v differs", q)
+		}
+	}
+	if err := a.Merge(NewHistogram(FixedBuckets(1))); err != ErrLayoutMismatch {
+		t.Errorf("Merge with other layout = %v", err)
+	}
+	a.Reset()
+	if a.Count() != 0 || a.Quantile(0.5) != 0 {
+		t.Error("Reset should clear the histogram")
+	}
+}
+
+func TestRollingWindowPercentile(t *testing.T) {
+	if NewRollingWindow(2, time.Second).Percentile(0.5) != 0 {
+		t.Error("window without histogram should return 0")
+	}
+
+	clock := newFakeClock()
+	rw := NewRollingWindow(3, time.Second, WithClock(clock.Now), WithHistogram(LogLinearBuckets(1, 1e4, 64)))
+	for i := 1; i <= 1000; i++ {
+		rw.Add(float64(i))
+	}
+	if p := rw.Percentile(0.5); math.Abs(p-500)/500 > 0.02 {
+		t.Errorf("p50 = %v", p)
+	}
+	if p := rw.Percentile(0.99); math.Abs(p-990)/990 > 0.02 {
+		t.Errorf("p99 = %v", p)
+	}
+	var count int64
+	rw.Reduce(func(b *Bucket) { count += b.Hist.Count() })
+	if count != 1000 {
+		t.Errorf("histogram count = %d", count)
+	}
+
+	// 所有桶过期后旧数据不再参与统计
+	clock.Advance(4 * time.Second)
+	rw.Add(5000)
+	if h := rw.Histogram(); h.Count() != 1 || rw.Percentile(0.5) != 5000 {
+		t.Errorf("expired buckets still counted: %d", h.Count())
+	}
+}
@@ -1,7 +1,9 @@
 package rollingwindows
 
 import (
+	"math"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -11,13 +13,15 @@ type (
 
 	// RollingWindow defines a rolling window to calculate the events in buckets with time interval.
 	RollingWindow struct {
-		lock          sync.RWMutex
+		lock          sync.RWMutex // Add only locks to rotate buckets, Reduce holds the read lock
 		size          int
 		win           *window
 		interval      time.Duration
-		offset        int
+		offset        atomic.Int64
 		ignoreCurrent bool
-		lastTime      time.Time // start time of the last bucket
+		lastTime      atomic.Int64 // start time of the last bucket, in unix nanoseconds
+		layout        Layout       // non-nil if buckets hold histograms
+		now           func() time.Time
 	}
 )
 
@@ -32,117 +36,185 @@ func NewRollingWindow(size int, interval time.Duration, opts ...RollingWindowOpt
 		size:     size,
 		win:      newWindow(size),
 		interval: interval,
-		lastTime: time.Now(),
+		now:      time.Now,
 	}
 	for _, opt := range opts {
 		opt(w)
 	}
+	w.lastTime.Store(w.now().UnixNano())
+	if w.layout != nil {
+		for i := range w.win.buckets {
+			w.win.buckets[i].hist = NewHistogram(w.layout)
+		}
+	}
 	return w
 }
 
 // Add adds value to current bucket.
 func (rw *RollingWindow) Add(v float64) {
+	rw.AddAt(rw.now(), v)
+}
+
+// AddAt adds value to the bucket that covers t. Times after the current bucket move the
+// window forward as Add does, times older than the window are dropped, so historical
+// events can be replayed in order.
+func (rw *RollingWindow) AddAt(t time.Time, v float64) {
+	ts := t.UnixNano()
+	// hot path: t falls into the current bucket, add atomically without locking
+	if rw.layout == nil {
+		last := rw.lastTime.Load()
+		if ts >= last && ts-last < int64(rw.interval) {
+			rw.win.add(int(rw.offset.Load()), v)
+			return
+		}
+	}
+
 	rw.lock.Lock()
 	defer rw.lock.Unlock()
-	rw.updateOffset()
-	rw.win.add(rw.offset, v)
+	last := rw.lastTime.Load()
+	if ts >= last {
+		rw.updateOffset(ts)
+		rw.win.add(int(rw.offset.Load()), v)
+		return
+	}
+	// bucket k (0 is the current one) starts at last - k*interval
+	k := (last - ts + int64(rw.interval) - 1) / int64(rw.interval)
+	if k < int64(rw.size) {
+		rw.win.add(int((rw.offset.Load()-k)%int64(rw.size)+int64(rw.size)), v)
+	}
 }
 
 // Reduce runs fn on all buckets, ignore current bucket if ignoreCurrent was set.
+// fn must not retain b, it is reused between calls.
 func (rw *RollingWindow) Reduce(fn func(b *Bucket)) {
+	rw.ReduceAt(rw.now(), fn)
+}
+
+// ReduceAt runs fn on the buckets that form the window at time t, oldest first.
+// Buckets that have already been reused for newer data are skipped.
+func (rw *RollingWindow) ReduceAt(t time.Time, fn func(b *Bucket)) {
 	rw.lock.RLock()
 	defer rw.lock.RUnlock()
 
-	var diff int
-	span := rw.span()
-	// ignore current bucket, because of partial data
-	if span == 0 && rw.ignoreCurrent {
-		diff = rw.size - 1
-	} else {
-		diff = rw.size - span
-	}
-	if diff > 0 {
-		offset := (rw.offset + span + 1) % rw.size
-		rw.win.reduce(offset, diff, fn)
+	ts := t.UnixNano()
+	last, offset := rw.lastTime.Load(), rw.offset.Load()
+	interval, size := int64(rw.interval), int64(rw.size)
+	var b Bucket
+	for k := size - 1; k >= 0; k-- {
+		start := last - k*interval
+		if start > ts || start+size*interval <= ts {
+			continue
+		}
+		// ignore current bucket, because of partial data
+		if rw.ignoreCurrent && ts < start+interval {
+			continue
+		}
+		rw.win.snapshot(int((offset-k+size)%size), &b)
+		fn(&b)
 	}
 }
 
-func (rw *RollingWindow) span() int {
-	offset := int(time.Since(rw.lastTime) / rw.interval)
-	if 0 <= offset && offset < rw.size {
-		return offset
+// Histogram returns a histogram merged from the buckets Reduce visits,
+// nil if the window was not created with WithHistogram.
+func (rw *RollingWindow) Histogram() *Histogram {
+	if rw.layout == nil {
+		return nil
 	}
+	h := NewHistogram(rw.layout)
+	rw.Reduce(func(b *Bucket) {
+		_ = h.Merge(b.Hist)
+	})
+	return h
+}
 
-	return rw.size
+// Percentile returns the estimated value at quantile q in [0, 1] over the window,
+// e.g. Percentile(0.99) for p99. It returns 0 if the window has no histogram or no data.
+func (rw *RollingWindow) Percentile(q float64) float64 {
+	h := rw.Histogram()
+	if h == nil {
+		return 0
+	}
+	return h.Quantile(q)
 }
 
-func (rw *RollingWindow) updateOffset() {
-	span := rw.span()
+// updateOffset moves the window forward to ts, resetting the expired buckets.
+// Buckets are reset before the new offset and start time are published, so a
+// concurrent hot-path Add lands in the old or the new current bucket; only after
+// the window has been idle for a whole window can such an Add be lost.
+func (rw *RollingWindow) updateOffset(ts int64) {
+	last := rw.lastTime.Load()
+	completed := (ts - last) / int64(rw.interval)
+	span := int(min(completed, int64(rw.size)))
 	if span <= 0 {
 		return
 	}
 
-	offset := rw.offset
+	offset := int(rw.offset.Load())
 	// reset expired buckets
 	for i := 0; i < span; i++ {
 		rw.win.resetBucket((offset + i + 1) % rw.size)
 	}
 
-	rw.offset = (offset + span) % rw.size
-	now := time.Now()
-
-	// 计算自上次更新以来经过的时间
-	elapsed := now.Sub(rw.lastTime)
-	// 计算已经经过的完整间隔数
-	completedIntervals := elapsed / rw.interval
-	// 更新 lastTime，将其设置为最近的间隔边界
-	rw.lastTime = rw.lastTime.Add(completedIntervals * rw.interval)
+	rw.offset.Store(int64((offset + span) % rw.size))
+	// 将 lastTime 对齐到最近的间隔边界
+	rw.lastTime.Store(last + completed*int64(rw.interval))
 }
 
-// Bucket defines the bucket that holds sum and num of additions.
+// Bucket defines the bucket that holds sum and num of additions,
+// and the value distribution if the window was created with WithHistogram.
 type Bucket struct {
 	Sum   float64
 	Count int64
+	Hist  *Histogram
 }
 
-func (b *Bucket) add(v float64) {
-	b.Sum += v
-	b.Count++
-}
-
-func (b *Bucket) reset() {
-	b.Sum = 0
-	b.Count = 0
+// slot is the storage of a bucket, sum and count are updated atomically.
+type slot struct {
+	sum   atomic.Uint64 // float64 bits
+	count atomic.Int64
+	hist  *Histogram // guarded by RollingWindow.lock
 }
 
 type window struct {
-	buckets []*Bucket
+	buckets []slot
 	size    int
 }
 
 func newWindow(size int) *window {
-	buckets := make([]*Bucket, size)
-	for i := 0; i < size; i++ {
-		buckets[i] = new(Bucket)
-	}
 	return &window{
-		buckets: buckets,
+		buckets: make([]slot, size),
 		size:    size,
 	}
 }
 
 func (w *window) add(offset int, v float64) {
-	w.buckets[offset%w.size].add(v)
+	s := &w.buckets[offset%w.size]
+	for {
+		old := s.sum.Load()
+		if s.sum.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+v)) {
+			break
+		}
+	}
+	s.count.Add(1)
+	if s.hist != nil {
+		s.hist.Record(v)
+	}
 }
 
-func (w *window) reduce(start, count int, fn func(b *Bucket)) {
-	for i := 0; i < count; i++ {
-		fn(w.buckets[(start+i)%w.size])
-	}
+func (w *window) snapshot(offset int, b *Bucket) {
+	s := &w.buckets[offset%w.size]
+	b.Sum = math.Float64frombits(s.sum.Load())
+	b.Count = s.count.Load()
+	b.Hist = s.hist
 }
 
 func (w *window) resetBucket(offset int) {
-	w.buckets[offset%w.size].reset()
+	s := &w.buckets[offset%w.size]
+	s.sum.Store(0)
+	s.count.Store(0)
+	if s.hist != nil {
+		s.hist.Reset()
+	}
 }
 
 // IgnoreCurrentBucket lets the Reduce call ignore current bucket.
@@ -151,3 +223,18 @@ func IgnoreCurrentBucket() RollingWindowOption {
 		w.ignoreCurrent = true
 	}
 }
+
+// WithHistogram lets every bucket keep a histogram with the given layout,
+// so that Histogram and Percentile can be used.
+func WithHistogram(layout Layout) RollingWindowOption {
+	return func(w *RollingWindow) {
+		w.layout = layout
+	}
+}
+
+// WithClock replaces time.Now, e.g. with a fake clock in tests.
+func WithClock(now func() time.Time) RollingWindowOption {
+	return func(w *RollingWindow) {
+		w.now = now
+	}
+}
@@ -0,0 +1,194 @@
+package rollingwindows
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock 手动推进的时钟
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Unix(1700000000, 0)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func sums(rw *RollingWindow) []float64 {
+	var out []float64
+	rw.Reduce(func(b *Bucket) {
+		out = append(out, b.Sum)
+	})
+	return out
+}
+
+func equal(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestRollingWindowWithClock(t *testing.T) {
+	clock := newFakeClock()
+	rw := NewRollingWindow(3, time.Second, WithClock(clock.Now))
+	rw.Add(1)
+	rw.Add(2)
+	clock.Advance(time.Second)
+	rw.Add(3)
+	clock.Advance(time.Second)
+	rw.Add(4)
+	if got := sums(rw); !equal(got, []float64{3, 3, 4}) {
+		t.Fatalf("sums = %v", got)
+	}
+
+	// 不写入时旧桶随时间滑出窗口
+	clock.Advance(time.Second)
+	if got := sums(rw); !equal(got, []float64{3, 4}) {
+		t.Fatalf("after 1s sums = %v", got)
+	}
+	clock.Advance(2 * time.Second)
+	if got := sums(rw); got != nil {
+		t.Fatalf("after window sums = %v", got)
+	}
+	rw.Add(5)
+	if got := sums(rw); !equal(got, []float64{0, 0, 5}) {
+		t.Fatalf("after reuse sums = %v", got)
+	}
+}
+
+func TestIgnoreCurrentBucket(t *testing.T) {
+	clock := newFakeClock()
+	rw := NewRollingWindow(3, time.Second, WithClock(clock.Now), IgnoreCurrentBucket())
+	rw.Add(1)
+	clock.Advance(time.Second)
+	rw.Add(2)
+	if got := sums(rw); !equal(got, []float64{0, 1}) {
+		t.Fatalf("sums = %v", got)
+	}
+}
+
+func TestAddAtReduceAt(t *testing.T) {
+	clock := newFakeClock()
+	start := clock.Now()
+	rw := NewRollingWindow(4, time.Second, WithClock(clock.Now))
+
+	// 按时间顺序回放历史事件，窗口随事件时间前进
+	for i := 0; i < 6; i++ {
+		rw.AddAt(start.Add(time.Duration(i)*time.Second+100*time.Millisecond), float64(i))
+	}
+	at := start.Add(5500 * time.Millisecond)
+	var got []float64
+	rw.ReduceAt(at, func(b *Bucket) { got = append(got, b.Sum) })
+	if !equal(got, []float64{2, 3, 4, 5}) {
+		t.Fatalf("ReduceAt = %v", got)
+	}
+
+	// 写入窗口内的旧时间点，窗口之外的被丢弃
+	rw.AddAt(start.Add(3*time.Second), 10)
+	rw.AddAt(start.Add(time.Second), 100)
+	got = nil
+	rw.ReduceAt(at, func(b *Bucket) { got = append(got, b.Sum) })
+	if !equal(got, []float64{2, 13, 4, 5}) {
+		t.Fatalf("after late adds = %v", got)
+	}
+
+	// 过去时间点的窗口只包含当时已经开始的桶
+	got = nil
+	rw.ReduceAt(start.Add(3500*time.Millisecond), func(b *Bucket) { got = append(got, b.Sum) })
+	if !equal(got, []float64{2, 13}) {
+		t.Fatalf("ReduceAt past = %v", got)
+	}
+}
+
+func TestConcurrentAdd(t *testing.T) {
+	clock := newFakeClock()
+	rw := NewRollingWindow(10, time.Second, WithClock(clock.Now))
+	const goroutines, perGoroutine = 8, 1000
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < perGoroutine; i++ {
+				rw.Add(1)
+				if g == 0 && i%200 == 0 { // 只推进 5 次，所有写入都还在窗口内
+					clock.Advance(time.Second)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	var sum float64
+	var count int64
+	rw.Reduce(func(b *Bucket) {
+		sum += b.Sum
+		count += b.Count
+	})
+	if sum != goroutines*perGoroutine || count != goroutines*perGoroutine {
+		t.Errorf("sum = %v, count = %d", sum, count)
+	}
+}
+
+func BenchmarkAdd(b *testing.B) {
+	rw := NewRollingWindow(10, time.Second)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		rw.Add(1)
+	}
+}
+
+func BenchmarkAddParallel(b *testing.B) {
+	rw := NewRollingWindow(10, time.Second)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			rw.Add(1)
+		}
+	})
+}
+
+func BenchmarkAddHistogram(b *testing.B) {
+	rw := NewRollingWindow(10, time.Second, WithHistogram(LogLinearBuckets(1, 1e6, 32)))
+	b.RunParallel(func(pb *testing.PB) {
+		v := 1.0
+		for pb.Next() {
+			rw.Add(v)
+			v = v*1.1 + 1
+			if v > 1e6 {
+				v = 1
+			}
+		}
+	})
+}
+
+func BenchmarkReduce(b *testing.B) {
+	rw := NewRollingWindow(40, 250*time.Millisecond)
+	for i := 0; i < 1000; i++ {
+		rw.Add(1)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var sum float64
+		rw.Reduce(func(b *Bucket) { sum += b.Sum })
+	}
+}
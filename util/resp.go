@@ -42,4 +42,8 @@ const (
 	TokenExpiredError = "Error.TokenExpired"
 	// 权限不足
 	PermissionDeniedError = "Error.PermissionDenied"
+	// 服务熔断或过载
+	ServiceUnavailableError = "Error.ServiceUnavailable"
+	// 请求被限流
+	TooManyRequestsError = "Error.TooManyRequests"
 )
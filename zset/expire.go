@@ -0,0 +1,230 @@
+package zset
+
+import (
+	"container/heap"
+	"time"
+)
+
+// expireEntry 过期堆中的一项，deadline 与 expires 中记录不一致时视为过期条目（已被 Expire/ZRem 覆盖）
+type expireEntry struct {
+	ele      string
+	deadline int64 // UnixNano
+}
+
+// expireHeap 按 deadline 排序的小根堆
+type expireHeap []expireEntry
+
+func (h expireHeap) Len() int           { return len(h) }
+func (h expireHeap) Less(i, j int) bool { return h[i].deadline < h[j].deadline }
+func (h expireHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *expireHeap) Push(x any)        { *h = append(*h, x.(expireEntry)) }
+func (h *expireHeap) Pop() any {
+	old := *h
+	n := len(old)
+	e := old[n-1]
+	*h = old[:n-1]
+	return e
+}
+
+// ZAddEx 添加元素并设置存活时间，ttl <= 0 时等同于 ZAdd 且清除已有的过期时间
+func (this *ZSet) ZAddEx(ele string, score float64, ttl time.Duration) bool {
+	this.mu.Lock()
+	now := this.now()
+	events := this.purgeExpiredInternal(now.UnixNano())
+	added, events := this.addWithEvent(ele, score, events)
+	if ttl > 0 {
+		this.setExpireInternal(ele, now.Add(ttl))
+	} else {
+		delete(this.expires, ele)
+	}
+	this.notifyWatchers(events)
+	this.mu.Unlock()
+	this.emit(events)
+	return added
+}
+
+// Expire 为已存在的元素设置存活时间，元素不存在或已过期时返回 false；ttl <= 0 时立即删除该元素
+func (this *ZSet) Expire(ele string, ttl time.Duration) bool {
+	this.expireDue()
+	this.mu.Lock()
+	if _, ok := this.dict[ele]; !ok {
+		this.mu.Unlock()
+		return false
+	}
+	// expireDue 之后才到期的元素留给下次清理，不能被续期
+	if deadline, ok := this.expires[ele]; ok && deadline <= this.now().UnixNano() {
+		this.mu.Unlock()
+		return false
+	}
+	if ttl <= 0 {
+		delete(this.expires, ele)
+		_, events := this.removeWithEvent(ele, eventExpire, nil)
+		this.notifyWatchers(events)
+		this.mu.Unlock()
+		this.emit(events)
+		return true
+	}
+	this.setExpireInternal(ele, this.now().Add(ttl))
+	this.mu.Unlock()
+	return true
+}
+
+// Persist 移除元素的过期时间，元素不存在、已过期或没有过期时间时返回 false
+func (this *ZSet) Persist(ele string) bool {
+	this.expireDue()
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	// expireDue 之后才到期的元素留给下次清理，不能被复活
+	if deadline, ok := this.expires[ele]; !ok || deadline <= this.now().UnixNano() {
+		return false
+	}
+	delete(this.expires, ele)
+	return true
+}
+
+// TTL 返回元素的剩余存活时间，第二个返回值表示元素是否设置了过期时间
+func (this *ZSet) TTL(ele string) (time.Duration, bool) {
+	this.expireDue()
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+	deadline, ok := this.expires[ele]
+	if !ok {
+		return 0, false
+	}
+	return time.Duration(deadline - this.now().UnixNano()), true
+}
+
+// OnExpire 注册元素过期时的回调，回调在锁外执行，可以安全地访问 ZSet。过期删除也会触发 OnRemove
+func (this *ZSet) OnExpire(f func(ele string, score float64)) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	this.onExpire = append(this.onExpire, f)
+}
+
+// StartSweeper 启动后台清理协程，按最近的过期时间唤醒，interval 为最长休眠间隔
+func (this *ZSet) StartSweeper(interval time.Duration) {
+	if interval <= 0 {
+		interval = time.Second
+	}
+	this.mu.Lock()
+	if this.sweepStop != nil {
+		this.mu.Unlock()
+		return // 已经启动
+	}
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	this.sweepStop, this.sweepDone = stop, done
+	this.mu.Unlock()
+
+	go this.sweep(interval, stop, done)
+}
+
+// StopSweeper 停止后台清理协程并等待其退出
+func (this *ZSet) StopSweeper() {
+	this.mu.Lock()
+	stop, done := this.sweepStop, this.sweepDone
+	this.sweepStop, this.sweepDone = nil, nil
+	this.mu.Unlock()
+	if stop == nil {
+		return
+	}
+	close(stop)
+	<-done
+}
+
+func (this *ZSet) sweep(interval time.Duration, stop, done chan struct{}) {
+	defer close(done)
+	timer := time.NewTimer(this.nextSweepDelay(interval))
+	defer timer.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-this.sweepWake:
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+		case <-timer.C:
+			this.expireDue()
+		}
+		timer.Reset(this.nextSweepDelay(interval))
+	}
+}
+
+// nextSweepDelay 计算到堆顶过期时间的等待时长，不超过 interval
+func (this *ZSet) nextSweepDelay(interval time.Duration) time.Duration {
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+	if len(this.ttlHeap) == 0 {
+		return interval
+	}
+	delay := time.Duration(this.ttlHeap[0].deadline - this.now().UnixNano())
+	if delay < 0 {
+		return 0
+	}
+	if delay > interval {
+		return interval
+	}
+	return delay
+}
+
+// setExpireInternal 设置过期时间（不获取锁，由调用方保证线程安全）
+func (this *ZSet) setExpireInternal(ele string, at time.Time) {
+	deadline := at.UnixNano()
+	this.expires[ele] = deadline
+	earliest := len(this.ttlHeap) == 0 || deadline < this.ttlHeap[0].deadline
+	heap.Push(&this.ttlHeap, expireEntry{ele: ele, deadline: deadline})
+	// 过期条目过多时重建堆，避免反复 Expire 导致堆无限增长
+	if len(this.ttlHeap) > 2*len(this.expires)+64 {
+		this.rebuildExpireHeap()
+	}
+	if earliest {
+		select {
+		case this.sweepWake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (this *ZSet) rebuildExpireHeap() {
+	h := make(expireHeap, 0, len(this.expires))
+	for ele, deadline := range this.expires {
+		h = append(h, expireEntry{ele: ele, deadline: deadline})
+	}
+	heap.Init(&h)
+	this.ttlHeap = h
+}
+
+// expireDue 删除所有已到期的元素并触发回调。先用读锁检查堆顶，避免读多的场景下频繁争抢写锁
+func (this *ZSet) expireDue() {
+	now := this.now().UnixNano()
+	this.mu.RLock()
+	due := len(this.ttlHeap) > 0 && this.ttlHeap[0].deadline <= now
+	this.mu.RUnlock()
+	if !due {
+		return
+	}
+
+	this.mu.Lock()
+	events := this.purgeExpiredInternal(now)
+	this.notifyWatchers(events)
+	this.mu.Unlock()
+	this.emit(events)
+}
+
+// purgeExpiredInternal 弹出所有到期的堆顶元素（不获取锁，由调用方保证线程安全）
+func (this *ZSet) purgeExpiredInternal(now int64) []zsetEvent {
+	var events []zsetEvent
+	for len(this.ttlHeap) > 0 && this.ttlHeap[0].deadline <= now {
+		e := heap.Pop(&this.ttlHeap).(expireEntry)
+		if deadline, ok := this.expires[e.ele]; !ok || deadline != e.deadline {
+			continue // 过期时间已被修改或元素已删除
+		}
+		delete(this.expires, e.ele)
+		_, events = this.removeWithEvent(e.ele, eventExpire, events)
+	}
+	return events
+}
@@ -0,0 +1,225 @@
+package zset
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock 可手动推进的时钟，避免测试依赖真实 sleep
+type fakeClock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.t = c.t.Add(d)
+}
+
+func newZSetWithClock() (*ZSet, *fakeClock) {
+	clock := &fakeClock{t: time.Unix(1700000000, 0)}
+	zs := NewZSet()
+	zs.now = clock.Now
+	return zs, clock
+}
+
+func TestZAddExLazyExpire(t *testing.T) {
+	zs, clock := newZSetWithClock()
+	zs.ZAddEx("alice", 10, time.Minute)
+	zs.ZAddEx("bob", 20, 5*time.Minute)
+	zs.ZAdd("carol", 30)
+
+	if ttl, ok := zs.TTL("alice"); !ok || ttl != time.Minute {
+		t.Errorf("Expected alice ttl 1m, got %v %v", ttl, ok)
+	}
+	if _, ok := zs.TTL("carol"); ok {
+		t.Error("carol should not have a ttl")
+	}
+
+	clock.Advance(2 * time.Minute)
+	if _, ok := zs.ZScore("alice"); ok {
+		t.Error("alice should be expired")
+	}
+	if rank, ok := zs.ZRank("bob"); !ok || rank != 1 {
+		t.Errorf("Expected bob rank 1, got %d", rank)
+	}
+	if got := zs.ZRange(0, 10); len(got) != 2 {
+		t.Errorf("Expected 2 members, got %v", got)
+	}
+
+	clock.Advance(5 * time.Minute)
+	if got := zs.ZRevRange(0, 10); len(got) != 1 || got[0] != "carol:30.00" {
+		t.Errorf("Expected only carol, got %v", got)
+	}
+	if zs.skiplist.length != 1 || len(zs.expires) != 0 {
+		t.Errorf("Expected 1 member and no ttl, got %d members %d ttls", zs.skiplist.length, len(zs.expires))
+	}
+}
+
+func TestZSetExpireAndPersist(t *testing.T) {
+	zs, clock := newZSetWithClock()
+	if zs.Expire("ghost", time.Second) {
+		t.Error("Expire on missing member should return false")
+	}
+
+	zs.ZAdd("a", 1)
+	zs.ZAdd("b", 2)
+	if !zs.Expire("a", time.Second) || !zs.Expire("b", time.Second) {
+		t.Fatal("Expire should succeed on existing members")
+	}
+	// 延长过期时间后，旧的堆条目不能删掉该元素
+	zs.Expire("a", time.Hour)
+	if !zs.Persist("b") {
+		t.Error("Persist should succeed on member with ttl")
+	}
+	if zs.Persist("b") {
+		t.Error("Persist should fail when ttl already removed")
+	}
+
+	clock.Advance(time.Minute)
+	if _, ok := zs.ZScore("a"); !ok {
+		t.Error("a should survive after its ttl was extended")
+	}
+	if _, ok := zs.ZScore("b"); !ok {
+		t.Error("b should survive after Persist")
+	}
+
+	// ZAdd 只更新分数，保留过期时间
+	zs.ZAdd("a", 100)
+	if _, ok := zs.TTL("a"); !ok {
+		t.Error("ZAdd should keep the existing ttl")
+	}
+	// ZRem 清除过期时间，重新加入的元素不再过期
+	zs.ZRem("a")
+	zs.ZAdd("a", 1)
+	clock.Advance(2 * time.Hour)
+	if _, ok := zs.ZScore("a"); !ok {
+		t.Error("re-added a should not inherit the old ttl")
+	}
+
+	if !zs.Expire("b", 0) {
+		t.Error("Expire with non-positive ttl should delete the member")
+	}
+	if _, ok := zs.ZScore("b"); ok {
+		t.Error("b should be removed")
+	}
+}
+
+func TestZSetPersistExpired(t *testing.T) {
+	zs, clock := newZSetWithClock()
+	zs.ZAddEx("a", 1, time.Second)
+
+	// 已到期但尚未被清理的元素不能被 Persist 复活
+	clock.Advance(time.Second)
+	if zs.Persist("a") {
+		t.Error("Persist should fail on an expired member")
+	}
+	if _, ok := zs.ZScore("a"); ok {
+		t.Error("a should be expired")
+	}
+	if zs.skiplist.length != 0 || len(zs.expires) != 0 {
+		t.Errorf("Expected empty set, got %d members %d ttls", zs.skiplist.length, len(zs.expires))
+	}
+}
+
+func TestZSetExpireRacesDeadline(t *testing.T) {
+	zs, clock := newZSetWithClock()
+	zs.ZAddEx("a", 1, time.Second)
+
+	// expireDue 读到的时间尚未到期，加锁后再读时间已经到期
+	calls := 0
+	zs.now = func() time.Time {
+		calls++
+		if calls == 1 {
+			return clock.Now()
+		}
+		return clock.Now().Add(time.Second)
+	}
+	if zs.Expire("a", time.Hour) {
+		t.Error("Expire should fail on a member that expired after expireDue")
+	}
+
+	zs.now = clock.Now
+	clock.Advance(time.Second)
+	if _, ok := zs.ZScore("a"); ok {
+		t.Error("a should not be revived by Expire")
+	}
+}
+
+func TestZSetOnExpire(t *testing.T) {
+	zs, clock := newZSetWithClock()
+	var mu sync.Mutex
+	expired := map[string]float64{}
+	zs.OnExpire(func(ele string, score float64) {
+		// 回调在锁外执行，可以访问 ZSet
+		if _, ok := zs.ZScore(ele); ok {
+			t.Errorf("%s should already be removed in callback", ele)
+		}
+		mu.Lock()
+		expired[ele] = score
+		mu.Unlock()
+	})
+
+	for i := 0; i < 10; i++ {
+		zs.ZAddEx(fmt.Sprintf("user-%d", i), float64(i), time.Duration(i+1)*time.Second)
+	}
+	clock.Advance(5 * time.Second)
+	zs.ZRange(0, 10)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(expired) != 5 {
+		t.Errorf("Expected 5 expired members, got %v", expired)
+	}
+	if expired["user-3"] != 3 {
+		t.Errorf("Expected user-3 score 3, got %v", expired["user-3"])
+	}
+}
+
+func TestZSetSweeper(t *testing.T) {
+	zs := NewZSet()
+	done := make(chan string, 3)
+	zs.OnExpire(func(ele string, score float64) { done <- ele })
+	zs.StartSweeper(time.Second)
+	defer zs.StopSweeper()
+
+	zs.ZAddEx("slow", 1, time.Hour)
+	// 更早的过期时间会唤醒清理协程，而不是等待 interval
+	zs.ZAddEx("fast", 2, 20*time.Millisecond)
+
+	select {
+	case ele := <-done:
+		if ele != "fast" {
+			t.Errorf("Expected fast to expire first, got %s", ele)
+		}
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("sweeper did not expire member in time")
+	}
+
+	zs.mu.RLock()
+	_, ok := zs.dict["fast"]
+	zs.mu.RUnlock()
+	if ok {
+		t.Error("fast should be removed by sweeper without any read")
+	}
+}
+
+func TestZSetExpireHeapCompaction(t *testing.T) {
+	zs, _ := newZSetWithClock()
+	zs.ZAdd("a", 1)
+	for i := 0; i < 1000; i++ {
+		zs.Expire("a", time.Duration(i+1)*time.Second)
+	}
+	if len(zs.ttlHeap) > 2*len(zs.expires)+64 {
+		t.Errorf("Expected heap to be compacted, got %d entries", len(zs.ttlHeap))
+	}
+}
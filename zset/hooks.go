@@ -0,0 +1,127 @@
+package zset
+
+// eventKind 集合变更事件类型
+type eventKind int
+
+const (
+	eventAdd eventKind = iota
+	eventRemove
+	eventExpire // 过期删除，同时触发 OnRemove 和 OnExpire
+	eventScoreChange
+)
+
+// zsetEvent 在锁内记录的变更，释放锁后再交给回调，排名只在有人关心时计算
+type zsetEvent struct {
+	kind     eventKind
+	ele      string
+	oldScore float64
+	newScore float64
+	oldRank  int
+	newRank  int
+}
+
+// affectedRank 返回该事件影响到的最小排名，排名在它之后的区间不受影响
+func (e zsetEvent) affectedRank() int {
+	switch e.kind {
+	case eventAdd:
+		return e.newRank
+	case eventRemove, eventExpire:
+		return e.oldRank
+	default:
+		return min(e.oldRank, e.newRank)
+	}
+}
+
+// OnAdd 注册新元素加入时的回调
+func (this *ZSet) OnAdd(f func(ele string, score float64)) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	this.onAdd = append(this.onAdd, f)
+}
+
+// OnRemove 注册元素删除时的回调，包括 ZRem 和过期删除
+func (this *ZSet) OnRemove(f func(ele string, score float64)) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	this.onRemove = append(this.onRemove, f)
+}
+
+// OnScoreChange 注册已有元素分数变化时的回调，排名为变化前后的正序排名
+func (this *ZSet) OnScoreChange(f func(ele string, oldScore, newScore float64, oldRank, newRank int)) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	this.onScoreChange = append(this.onScoreChange, f)
+}
+
+// needRank 是否需要为事件计算排名（不获取锁，由调用方保证线程安全）
+func (this *ZSet) needRank() bool {
+	return len(this.onScoreChange) > 0 || len(this.watchers) > 0
+}
+
+// addWithEvent 添加或更新元素并记录事件（不获取锁，由调用方保证线程安全）
+func (this *ZSet) addWithEvent(ele string, score float64, events []zsetEvent) (bool, []zsetEvent) {
+	old, exists := this.dict[ele]
+	if exists && old == score {
+		return false, events
+	}
+	needRank := this.needRank()
+	ev := zsetEvent{kind: eventAdd, ele: ele, newScore: score}
+	if exists {
+		ev.kind, ev.oldScore = eventScoreChange, old
+		if needRank {
+			ev.oldRank = this.countBeforeInternal(old, ele)
+		}
+	}
+	added := this.zaddInternal(ele, score)
+	if needRank {
+		ev.newRank = this.countBeforeInternal(score, ele)
+	}
+	return added, append(events, ev)
+}
+
+// removeWithEvent 删除元素并记录事件（不获取锁，由调用方保证线程安全）
+func (this *ZSet) removeWithEvent(ele string, kind eventKind, events []zsetEvent) (bool, []zsetEvent) {
+	score, ok := this.dict[ele]
+	if !ok {
+		return false, events
+	}
+	ev := zsetEvent{kind: kind, ele: ele, oldScore: score}
+	if this.needRank() {
+		ev.oldRank = this.countBeforeInternal(score, ele)
+	}
+	if !this.zremInternal(ele) {
+		return false, events
+	}
+	return true, append(events, ev)
+}
+
+// emit 在锁外触发回调，回调中可以安全地访问 ZSet
+func (this *ZSet) emit(events []zsetEvent) {
+	if len(events) == 0 {
+		return
+	}
+	this.mu.RLock()
+	onAdd, onRemove, onExpire, onScoreChange := this.onAdd, this.onRemove, this.onExpire, this.onScoreChange
+	this.mu.RUnlock()
+	for _, ev := range events {
+		switch ev.kind {
+		case eventAdd:
+			for _, cb := range onAdd {
+				cb(ev.ele, ev.newScore)
+			}
+		case eventRemove, eventExpire:
+			for _, cb := range onRemove {
+				cb(ev.ele, ev.oldScore)
+			}
+			if ev.kind == eventExpire {
+				for _, cb := range onExpire {
+					cb(ev.ele, ev.oldScore)
+				}
+			}
+		case eventScoreChange:
+			for _, cb := range onScoreChange {
+				cb(ev.ele, ev.oldScore, ev.newScore, ev.oldRank, ev.newRank)
+			}
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package zset
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestZSetHooks(t *testing.T) {
+	zs, clock := newZSetWithClock()
+	var log []string
+	zs.OnAdd(func(ele string, score float64) {
+		log = append(log, fmt.Sprintf("add %s %.0f", ele, score))
+	})
+	zs.OnRemove(func(ele string, score float64) {
+		log = append(log, fmt.Sprintf("remove %s %.0f", ele, score))
+	})
+	zs.OnExpire(func(ele string, score float64) {
+		log = append(log, fmt.Sprintf("expire %s %.0f", ele, score))
+	})
+	zs.OnScoreChange(func(ele string, oldScore, newScore float64, oldRank, newRank int) {
+		log = append(log, fmt.Sprintf("change %s %.0f->%.0f rank %d->%d", ele, oldScore, newScore, oldRank, newRank))
+	})
+
+	zs.ZAdd("a", 10)
+	zs.ZAdd("b", 20)
+	zs.ZAdd("c", 30)
+	zs.ZAdd("c", 30) // 分数不变不触发
+	zs.ZAdd("a", 40)
+	zs.ZRem("b")
+	zs.ZRem("b") // 已删除不触发
+	zs.ZAddEx("d", 5, time.Second)
+	clock.Advance(time.Minute)
+	zs.ZScore("d")
+
+	want := []string{
+		"add a 10",
+		"add b 20",
+		"add c 30",
+		"change a 10->40 rank 2->0",
+		"remove b 20",
+		"add d 5",
+		"remove d 5",
+		"expire d 5",
+	}
+	if !reflect.DeepEqual(log, want) {
+		t.Errorf("Expected events %v, got %v", want, log)
+	}
+}
+
+func recvRange(t *testing.T, w *RangeWatcher) RangeEvent {
+	t.Helper()
+	select {
+	case ev := <-w.Events():
+		return ev
+	case <-time.After(time.Second):
+		t.Fatal("expected a range event")
+		return RangeEvent{}
+	}
+}
+
+func expectNoRange(t *testing.T, w *RangeWatcher) {
+	t.Helper()
+	select {
+	case ev := <-w.Events():
+		t.Fatalf("unexpected range event %+v", ev)
+	default:
+	}
+}
+
+func eles(ms []Member) []string {
+	res := make([]string, len(ms))
+	for i, m := range ms {
+		res[i] = m.Ele
+	}
+	return res
+}
+
+func TestZSetWatchRange(t *testing.T) {
+	zs := NewZSet()
+	for i := 1; i <= 5; i++ {
+		zs.ZAdd(fmt.Sprintf("p%d", i), float64(i*10))
+	}
+	w := zs.WatchRange(0, 2)
+	defer w.Close()
+
+	ev := recvRange(t, w)
+	if got := eles(ev.After); !reflect.DeepEqual(got, []string{"p5", "p4", "p3"}) {
+		t.Fatalf("Expected initial top3 [p5 p4 p3], got %v", got)
+	}
+
+	// 区间外的变化不会产生事件
+	zs.ZAdd("p1", 15)
+	zs.ZAdd("p0", 1)
+	expectNoRange(t, w)
+
+	// 只改分数、顺序不变也不会产生事件
+	zs.ZAdd("p5", 55)
+	expectNoRange(t, w)
+
+	// 顺序变化
+	zs.ZAdd("p3", 45)
+	ev = recvRange(t, w)
+	if got := eles(ev.After); !reflect.DeepEqual(got, []string{"p5", "p3", "p4"}) {
+		t.Errorf("Expected [p5 p3 p4], got %v", got)
+	}
+	if len(ev.Added) != 0 || len(ev.Removed) != 0 {
+		t.Errorf("Expected pure reorder, got added %v removed %v", ev.Added, ev.Removed)
+	}
+
+	// 成员变化
+	zs.ZAdd("p2", 100)
+	ev = recvRange(t, w)
+	if !reflect.DeepEqual(ev.Added, []string{"p2"}) || !reflect.DeepEqual(ev.Removed, []string{"p4"}) {
+		t.Errorf("Expected p2 in and p4 out, got added %v removed %v", ev.Added, ev.Removed)
+	}
+
+	zs.ZRem("p2")
+	ev = recvRange(t, w)
+	if !reflect.DeepEqual(ev.Added, []string{"p4"}) || !reflect.DeepEqual(ev.Removed, []string{"p2"}) {
+		t.Errorf("Expected p4 in and p2 out, got added %v removed %v", ev.Added, ev.Removed)
+	}
+}
+
+func TestZSetWatchRangeCoalesce(t *testing.T) {
+	zs := NewZSet()
+	w := zs.WatchRange(0, 1)
+	recvRange(t, w) // 初始为空区间
+
+	// 消费者不读取时，多次变化合并为一个事件
+	zs.ZAdd("a", 1)
+	zs.ZAdd("b", 2)
+	zs.ZAdd("c", 3)
+	ev := recvRange(t, w)
+	if len(ev.Before) != 0 {
+		t.Errorf("Expected Before to be the last seen state, got %v", ev.Before)
+	}
+	if got := eles(ev.After); !reflect.DeepEqual(got, []string{"c", "b"}) {
+		t.Errorf("Expected [c b], got %v", got)
+	}
+	expectNoRange(t, w)
+
+	// 变化又被撤销时不产生事件
+	zs.ZAdd("d", 10)
+	zs.ZRem("d")
+	expectNoRange(t, w)
+
+	w.Close()
+	w.Close()
+	if _, ok := <-w.Events(); ok {
+		t.Error("Expected events channel to be closed")
+	}
+	zs.ZAdd("e", 100) // 关闭后不再通知
+}
@@ -0,0 +1,189 @@
+package zset
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/trancecho/ragnarok/heap"
+)
+
+// ShardedZSet 按元素哈希分片的有序集合，每个分片是一个独立加锁的 ZSet，适合多协程高频 ZAdd 的场景。
+//
+// 一致性保证：
+//   - 单个元素的操作（ZAdd/ZAddEx/ZRem/ZScore/Expire）只访问所属分片，与 ZSet 一样是线性一致的。
+//   - 排名和范围查询（ZRank/ZRevRank/ZRange/ZRevRange/Len）依次读取各分片，每个分片内部是一致的快照，
+//     但分片之间不是同一时刻的快照：并发写入时结果可能反映部分分片的新状态，排名可能有短暂的偏差。
+//     没有并发写入时，结果与单锁 ZSet 完全相同。
+type ShardedZSet struct {
+	shards []*ZSet
+}
+
+// rankedBefore 判断 a 是否排在 b 之前（分数降序，同分按元素降序，与跳表顺序一致）
+func rankedBefore(a, b Member) bool {
+	return a.Score > b.Score || (a.Score == b.Score && a.Ele > b.Ele)
+}
+
+// NewShardedZSet 创建包含 shardCount 个分片的有序集合，shardCount < 1 时使用 1
+func NewShardedZSet(shardCount int) *ShardedZSet {
+	if shardCount < 1 {
+		shardCount = 1
+	}
+	s := &ShardedZSet{shards: make([]*ZSet, shardCount)}
+	for i := range s.shards {
+		s.shards[i] = NewZSet()
+	}
+	return s
+}
+
+var _ IZSet = (*ShardedZSet)(nil) // 确保 ShardedZSet 实现了 IZSet 接口
+
+// shard 使用 FNV-1a 计算元素所属分片
+func (s *ShardedZSet) shard(ele string) *ZSet {
+	h := uint32(2166136261)
+	for i := 0; i < len(ele); i++ {
+		h ^= uint32(ele[i])
+		h *= 16777619
+	}
+	return s.shards[h%uint32(len(s.shards))]
+}
+
+func (s *ShardedZSet) ZAdd(ele string, score float64) bool {
+	return s.shard(ele).ZAdd(ele, score)
+}
+
+func (s *ShardedZSet) ZAddEx(ele string, score float64, ttl time.Duration) bool {
+	return s.shard(ele).ZAddEx(ele, score, ttl)
+}
+
+func (s *ShardedZSet) Expire(ele string, ttl time.Duration) bool {
+	return s.shard(ele).Expire(ele, ttl)
+}
+
+func (s *ShardedZSet) ZRem(ele string) bool {
+	return s.shard(ele).ZRem(ele)
+}
+
+func (s *ShardedZSet) ZScore(ele string) (float64, bool) {
+	return s.shard(ele).ZScore(ele)
+}
+
+// Len 返回所有分片的元素总数
+func (s *ShardedZSet) Len() int {
+	total := 0
+	for _, zs := range s.shards {
+		total += zs.Len()
+	}
+	return total
+}
+
+// ZRank 返回元素的全局排名，等于各分片中排在它前面的元素个数之和
+func (s *ShardedZSet) ZRank(ele string) (int, bool) {
+	score, ok := s.ZScore(ele)
+	if !ok {
+		return -1, false
+	}
+	rank := 0
+	for _, zs := range s.shards {
+		rank += zs.countBefore(score, ele)
+	}
+	return rank, true
+}
+
+func (s *ShardedZSet) ZRevRank(ele string) (int, bool) {
+	score, ok := s.ZScore(ele)
+	if !ok {
+		return 0, false
+	}
+	rank, total := 0, 0
+	for _, zs := range s.shards {
+		before, length := zs.countBeforeAndLen(score, ele)
+		rank += before
+		total += length
+	}
+	return total - 1 - rank, true
+}
+
+func (s *ShardedZSet) ZRange(start, stop int) []string {
+	return s.rangeMerge(start, stop, false)
+}
+
+func (s *ShardedZSet) ZRevRange(start, stop int) []string {
+	return s.rangeMerge(start, stop, true)
+}
+
+// rangeMerge 从每个分片取出前 stop+1 个元素，再用堆做 k 路归并
+func (s *ShardedZSet) rangeMerge(start, stop int, reverse bool) []string {
+	if start < 0 || start > stop {
+		return nil
+	}
+	heads := make([][]Member, len(s.shards))
+	for i, zs := range s.shards {
+		heads[i] = zs.headMembers(stop+1, reverse)
+	}
+
+	// cursor 指向某个分片结果中的当前位置
+	type cursor struct {
+		shard int
+		pos   int
+	}
+	less := func(a, b cursor) bool {
+		x, y := heads[a.shard][a.pos], heads[b.shard][b.pos]
+		if reverse {
+			return rankedBefore(y, x)
+		}
+		return rankedBefore(x, y)
+	}
+	h := heap.NewHeap[cursor](len(heads), heap.MinHeap(less))
+	for i := range heads {
+		if len(heads[i]) > 0 {
+			h.Insert(cursor{shard: i})
+		}
+	}
+
+	var res []string
+	for idx := 0; idx <= stop && !h.IsEmpty(); idx++ {
+		c, _ := h.Pop()
+		if idx >= start {
+			m := heads[c.shard][c.pos]
+			res = append(res, fmt.Sprintf("%s:%.2f", m.Ele, m.Score))
+		}
+		if c.pos+1 < len(heads[c.shard]) {
+			h.Insert(cursor{shard: c.shard, pos: c.pos + 1})
+		}
+	}
+	return res
+}
+
+// countBefore 返回排在 (score, ele) 之前的元素个数，(score, ele) 本身不必存在于集合中
+func (this *ZSet) countBefore(score float64, ele string) int {
+	before, _ := this.countBeforeAndLen(score, ele)
+	return before
+}
+
+func (this *ZSet) countBeforeAndLen(score float64, ele string) (int, int) {
+	this.expireDue()
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+	return this.countBeforeInternal(score, ele), this.skiplist.length
+}
+
+// headMembers 复制正序（或逆序）的前 n 个元素
+func (this *ZSet) headMembers(n int, reverse bool) []Member {
+	this.expireDue()
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+	if n > this.skiplist.length {
+		n = this.skiplist.length
+	}
+	res := make([]Member, 0, n)
+	if reverse {
+		for x := this.skiplist.tail; x != nil && len(res) < n; x = x.backward {
+			res = append(res, Member{Ele: x.ele, Score: x.score})
+		}
+		return res
+	}
+	for x := this.skiplist.header.level[0].forward; x != nil && len(res) < n; x = x.level[0].forward {
+		res = append(res, Member{Ele: x.ele, Score: x.score})
+	}
+	return res
+}
@@ -0,0 +1,158 @@
+package zset
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestShardedZSetMatchesZSet(t *testing.T) {
+	single := NewZSet()
+	sharded := NewShardedZSet(8)
+
+	for i := 0; i < 500; i++ {
+		ele := fmt.Sprintf("player-%d", i)
+		// 故意制造大量同分元素，检查同分时的归并顺序
+		score := float64(rand.Intn(50))
+		single.ZAdd(ele, score)
+		sharded.ZAdd(ele, score)
+	}
+	for i := 0; i < 100; i++ {
+		ele := fmt.Sprintf("player-%d", rand.Intn(500))
+		single.ZRem(ele)
+		sharded.ZRem(ele)
+	}
+
+	if sharded.Len() != single.Len() {
+		t.Fatalf("Expected length %d, got %d", single.Len(), sharded.Len())
+	}
+
+	for i := 0; i < 500; i++ {
+		ele := fmt.Sprintf("player-%d", i)
+		wantRank, wantOk := single.ZRank(ele)
+		gotRank, gotOk := sharded.ZRank(ele)
+		if wantOk != gotOk || wantRank != gotRank {
+			t.Errorf("ZRank(%s): expected %d %v, got %d %v", ele, wantRank, wantOk, gotRank, gotOk)
+		}
+		wantRev, _ := single.ZRevRank(ele)
+		gotRev, _ := sharded.ZRevRank(ele)
+		if wantOk && wantRev != gotRev {
+			t.Errorf("ZRevRank(%s): expected %d, got %d", ele, wantRev, gotRev)
+		}
+	}
+
+	ranges := [][2]int{{0, 0}, {0, 9}, {10, 30}, {350, 1000}, {5, 4}, {1000, 1010}}
+	for _, r := range ranges {
+		if want, got := single.ZRange(r[0], r[1]), sharded.ZRange(r[0], r[1]); !reflect.DeepEqual(want, got) {
+			t.Errorf("ZRange(%d,%d): expected %v, got %v", r[0], r[1], want, got)
+		}
+		if want, got := single.ZRevRange(r[0], r[1]), sharded.ZRevRange(r[0], r[1]); !reflect.DeepEqual(want, got) {
+			t.Errorf("ZRevRange(%d,%d): expected %v, got %v", r[0], r[1], want, got)
+		}
+	}
+}
+
+func TestShardedZSetBasicOperations(t *testing.T) {
+	zs := NewShardedZSet(0) // 非法分片数退化为单分片
+	if len(zs.shards) != 1 {
+		t.Fatalf("Expected 1 shard, got %d", len(zs.shards))
+	}
+
+	zs = NewShardedZSet(4)
+	zs.ZAdd("Alice", 85.5)
+	zs.ZAdd("Bob", 72.0)
+	zs.ZAdd("Charlie", 92.5)
+
+	if score, ok := zs.ZScore("Alice"); !ok || score != 85.5 {
+		t.Errorf("Expected Alice score 85.5, got %.1f", score)
+	}
+	if rank, ok := zs.ZRank("Charlie"); !ok || rank != 0 {
+		t.Errorf("Expected Charlie rank 0, got %d", rank)
+	}
+	if _, ok := zs.ZRank("David"); ok {
+		t.Error("David should not exist")
+	}
+
+	zs.ZAdd("Bob", 99)
+	if got := zs.ZRange(0, 0); len(got) != 1 || got[0] != "Bob:99.00" {
+		t.Errorf("Expected Bob on top after update, got %v", got)
+	}
+	if !zs.ZRem("Bob") || zs.ZRem("Bob") {
+		t.Error("ZRem should succeed exactly once")
+	}
+	if zs.Len() != 2 {
+		t.Errorf("Expected length 2, got %d", zs.Len())
+	}
+}
+
+func TestShardedZSetConcurrentOperations(t *testing.T) {
+	zs := NewShardedZSet(16)
+	const numWorkers = 16
+	const opsPerWorker = 200
+
+	var wg sync.WaitGroup
+	var reads atomic.Int64
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
+		go func(workerID int) {
+			defer wg.Done()
+			for j := 0; j < opsPerWorker; j++ {
+				zs.ZAdd(fmt.Sprintf("worker-%d-element-%d", workerID, j), float64(workerID*opsPerWorker+j))
+				if j%20 == 0 {
+					zs.ZRange(0, 9)
+					reads.Add(1)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if zs.Len() != numWorkers*opsPerWorker {
+		t.Errorf("Expected %d elements, got %d", numWorkers*opsPerWorker, zs.Len())
+	}
+	top := zs.ZRange(0, 0)
+	want := fmt.Sprintf("worker-%d-element-%d:%.2f", numWorkers-1, opsPerWorker-1, float64(numWorkers*opsPerWorker-1))
+	if len(top) != 1 || top[0] != want {
+		t.Errorf("Expected top %s, got %v", want, top)
+	}
+}
+
+func benchmarkZAddParallel(b *testing.B, zs IZSet) {
+	var seq atomic.Int64
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		r := rand.New(rand.NewSource(seq.Add(1)))
+		for pb.Next() {
+			zs.ZAdd(fmt.Sprintf("member-%d", r.Intn(100000)), r.Float64()*1000)
+		}
+	})
+}
+
+func BenchmarkZSetZAddParallel(b *testing.B) {
+	benchmarkZAddParallel(b, NewZSet())
+}
+
+func BenchmarkShardedZSetZAddParallel(b *testing.B) {
+	benchmarkZAddParallel(b, NewShardedZSet(32))
+}
+
+func benchmarkZRangeTop(b *testing.B, zs IZSet) {
+	for i := 0; i < 100000; i++ {
+		zs.ZAdd(fmt.Sprintf("member-%d", i), rand.Float64()*1000)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		zs.ZRange(0, 99)
+	}
+}
+
+func BenchmarkZSetZRangeTop100(b *testing.B) {
+	benchmarkZRangeTop(b, NewZSet())
+}
+
+func BenchmarkShardedZSetZRangeTop100(b *testing.B) {
+	benchmarkZRangeTop(b, NewShardedZSet(32))
+}
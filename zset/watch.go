@@ -0,0 +1,160 @@
+package zset
+
+// RangeEvent 描述一次正序排名区间的变化
+type RangeEvent struct {
+	Before  []Member // 变化前的区间内容（上一次通知时的状态）
+	After   []Member // 变化后的区间内容
+	Added   []string // 新进入区间的元素
+	Removed []string // 离开区间的元素
+}
+
+// RangeWatcher 监听 [start, stop] 排名区间，区间内元素或顺序变化时通过 Events 发出差异事件。
+// 只有分数变化而顺序不变时不会发出事件。
+//
+// 事件通道容量为 1，消费者来不及处理时未读的事件会被合并：新事件的 Before 保持为消费者最后看到的状态，
+// 因此慢消费者不会阻塞写入，也不会丢失最终状态。
+type RangeWatcher struct {
+	zs          *ZSet
+	start, stop int
+	last        []Member // 最近一次发出事件时的区间内容
+	ch          chan RangeEvent
+	closed      bool
+}
+
+// WatchRange 监听正序排名区间 [start, stop]，创建后会立即发出一个包含当前区间内容的事件
+func (this *ZSet) WatchRange(start, stop int) *RangeWatcher {
+	if start < 0 {
+		start = 0
+	}
+	w := &RangeWatcher{
+		zs:    this,
+		start: start,
+		stop:  stop,
+		ch:    make(chan RangeEvent, 1),
+	}
+	this.expireDue()
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	this.watchers = append(this.watchers, w)
+	w.publish(this.rangeMembersInternal(start, stop))
+	return w
+}
+
+// Events 返回事件通道，Close 后通道会被关闭
+func (w *RangeWatcher) Events() <-chan RangeEvent {
+	return w.ch
+}
+
+// Close 停止监听并关闭事件通道
+func (w *RangeWatcher) Close() {
+	w.zs.mu.Lock()
+	defer w.zs.mu.Unlock()
+	if w.closed {
+		return
+	}
+	w.closed = true
+	for i, x := range w.zs.watchers {
+		if x == w {
+			w.zs.watchers = append(w.zs.watchers[:i], w.zs.watchers[i+1:]...)
+			break
+		}
+	}
+	close(w.ch)
+}
+
+// publish 发出从上次状态到 cur 的差异（由 ZSet 写锁保护，所有发送方互斥，因此不会阻塞）
+func (w *RangeWatcher) publish(cur []Member) {
+	base := w.last
+	select {
+	case pending := <-w.ch:
+		base = pending.Before // 消费者还没读到上一个事件，合并为一个
+	default:
+		if w.last != nil && sameOrder(w.last, cur) {
+			return
+		}
+	}
+	if base != nil && sameOrder(base, cur) {
+		w.last = cur
+		return
+	}
+	w.ch <- diffRange(base, cur)
+	w.last = cur
+}
+
+// notifyWatchers 根据事件影响到的最小排名通知相关的监听者（不获取锁，由调用方保证线程安全）
+func (this *ZSet) notifyWatchers(events []zsetEvent) {
+	if len(this.watchers) == 0 || len(events) == 0 {
+		return
+	}
+	lowest := events[0].affectedRank()
+	for _, ev := range events[1:] {
+		lowest = min(lowest, ev.affectedRank())
+	}
+	for _, w := range this.watchers {
+		if lowest > w.stop {
+			continue // 变化发生在区间之后，不影响区间内容
+		}
+		w.publish(this.rangeMembersInternal(w.start, w.stop))
+	}
+}
+
+// rangeMembersInternal 复制正序排名 [start, stop] 的元素（不获取锁，由调用方保证线程安全）
+func (this *ZSet) rangeMembersInternal(start, stop int) []Member {
+	res := []Member{}
+	if start > stop || start >= this.skiplist.length {
+		return res
+	}
+	curSpan := 0
+	x := this.skiplist.header
+	for i := this.skiplist.level - 1; i >= 0; i-- {
+		for nxt := x.level[i].forward; nxt != nil; {
+			if curSpan+x.level[i].span > start {
+				break
+			}
+			curSpan += x.level[i].span
+			x = nxt
+			nxt = x.level[i].forward
+		}
+	}
+	for i := start; i <= stop; i++ {
+		x = x.level[0].forward
+		if x == nil {
+			break
+		}
+		res = append(res, Member{Ele: x.ele, Score: x.score})
+	}
+	return res
+}
+
+func sameOrder(a, b []Member) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Ele != b[i].Ele {
+			return false
+		}
+	}
+	return true
+}
+
+func diffRange(before, after []Member) RangeEvent {
+	ev := RangeEvent{Before: before, After: after}
+	in := make(map[string]struct{}, len(before))
+	for _, m := range before {
+		in[m.Ele] = struct{}{}
+	}
+	for _, m := range after {
+		if _, ok := in[m.Ele]; ok {
+			delete(in, m.Ele)
+		} else {
+			ev.Added = append(ev.Added, m.Ele)
+		}
+	}
+	for _, m := range before {
+		if _, ok := in[m.Ele]; ok {
+			ev.Removed = append(ev.Removed, m.Ele)
+		}
+	}
+	return ev
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"math/rand/v2"
 	"sync"
+	"time"
 )
 
 const (
@@ -28,10 +29,29 @@ type zskiplist struct {
 	level  int // 跳跃表的最大层数
 }
 
+// Member 有序集合中的一个元素及其分数
+type Member struct {
+	Ele   string
+	Score float64
+}
+
 type ZSet struct {
 	dict     map[string]float64 // 元素到分数的映射
 	skiplist *zskiplist         // 跳跃表
 	mu       sync.RWMutex
+
+	expires   map[string]int64 // 元素到过期时间（UnixNano）的映射
+	ttlHeap   expireHeap       // 过期时间小根堆
+	sweepWake chan struct{}    // 出现更早的过期时间时唤醒清理协程
+	sweepStop chan struct{}
+	sweepDone chan struct{}
+	now       func() time.Time
+
+	onAdd         []func(ele string, score float64) // 新增回调列表
+	onRemove      []func(ele string, score float64) // 删除回调列表
+	onExpire      []func(ele string, score float64) // 过期回调列表
+	onScoreChange []func(ele string, oldScore, newScore float64, oldRank, newRank int)
+	watchers      []*RangeWatcher // 排名区间监听者
 }
 
 // zremInternal 内部删除方法（不获取锁，由调用方保证线程安全）
@@ -64,13 +84,17 @@ func (zs *ZSet) zremInternal(ele string) bool {
 
 func (this *ZSet) ZRem(ele string) bool {
 	this.mu.Lock()
-	defer this.mu.Unlock()
-	return this.zremInternal(ele)
+	delete(this.expires, ele)
+	removed, events := this.removeWithEvent(ele, eventRemove, nil)
+	this.notifyWatchers(events)
+	this.mu.Unlock()
+	this.emit(events)
+	return removed
 }
 
 func zslDeleteNode(zsl *zskiplist, x *zskiplistNode, updatePosNodes []*zskiplistNode) {
-	// 更新前节点
-	for i := 0; i < len(x.level); i++ {
+	// 更新前节点，x 层数以上的各层也要把跨度减一
+	for i := 0; i < zsl.level; i++ {
 		if updatePosNodes[i].level[i].forward == x {
 			updatePosNodes[i].level[i].span += x.level[i].span - 1 // rank为什么不是一个一直维持的值？因为删除会影响所有排名。而用span就可以很好计算排名
 			updatePosNodes[i].level[i].forward = x.level[i].forward
@@ -92,13 +116,23 @@ func zslDeleteNode(zsl *zskiplist, x *zskiplistNode, updatePosNodes []*zskiplist
 }
 
 func (this *ZSet) ZScore(ele string) (float64, bool) {
+	this.expireDue()
 	this.mu.RLock()
 	defer this.mu.RUnlock()
 	score, ok := this.dict[ele]
 	return score, ok
 }
 
+// Len 返回元素个数
+func (this *ZSet) Len() int {
+	this.expireDue()
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+	return this.skiplist.length
+}
+
 func (this *ZSet) ZRank(ele string) (int, bool) {
+	this.expireDue()
 	this.mu.RLock()
 	defer this.mu.RUnlock()
 	score, ok := this.dict[ele]
@@ -124,6 +158,24 @@ func (this *ZSet) ZRank(ele string) (int, bool) {
 	return -1, false // 如果没有找到，返回-1和false
 }
 
+// countBeforeInternal 返回排在 (score, ele) 之前的元素个数（不获取锁，由调用方保证线程安全）
+func (this *ZSet) countBeforeInternal(score float64, ele string) int {
+	rank := 0
+	x := this.skiplist.header
+	for i := this.skiplist.level - 1; i >= 0; i-- {
+		for nxt := x.level[i].forward; nxt != nil; {
+			if nxt.score > score || nxt.score == score && nxt.ele > ele {
+				rank += x.level[i].span
+				x = nxt
+				nxt = x.level[i].forward
+			} else {
+				break
+			}
+		}
+	}
+	return rank
+}
+
 func (this *ZSet) ZRevRank(ele string) (int, bool) {
 	rank, ok := this.ZRank(ele)
 	if !ok {
@@ -133,6 +185,7 @@ func (this *ZSet) ZRevRank(ele string) (int, bool) {
 }
 
 func (this *ZSet) ZRange(start, stop int) []string {
+	this.expireDue()
 	this.mu.RLock()
 	defer this.mu.RUnlock()
 	if start > stop || start >= this.skiplist.length {
@@ -162,6 +215,7 @@ func (this *ZSet) ZRange(start, stop int) []string {
 }
 
 func (this *ZSet) ZRevRange(start, stop int) []string {
+	this.expireDue()
 	this.mu.RLock()
 	defer this.mu.RUnlock()
 	if start > stop || start >= this.skiplist.length {
@@ -226,8 +280,11 @@ func newSkipList() *zskiplist {
 
 func NewZSet() *ZSet {
 	return &ZSet{
-		dict:     make(map[string]float64),
-		skiplist: newSkipList(),
+		dict:      make(map[string]float64),
+		skiplist:  newSkipList(),
+		expires:   make(map[string]int64),
+		sweepWake: make(chan struct{}, 1),
+		now:       time.Now,
 	}
 }
 
@@ -242,7 +299,17 @@ func randomLevel() int {
 // todo 复盘一下span
 func (this *ZSet) ZAdd(ele string, score float64) bool {
 	this.mu.Lock()
-	defer this.mu.Unlock()
+	// 先清理到期元素，避免已过期的成员带着旧的过期时间被重新加入
+	events := this.purgeExpiredInternal(this.now().UnixNano())
+	added, events := this.addWithEvent(ele, score, events)
+	this.notifyWatchers(events)
+	this.mu.Unlock()
+	this.emit(events)
+	return added
+}
+
+// zaddInternal 内部添加方法（不获取锁，由调用方保证线程安全），已有的过期时间保持不变
+func (this *ZSet) zaddInternal(ele string, score float64) bool {
 	if old, ok := this.dict[ele]; ok {
 		if old == score {
 			return false